@@ -2,6 +2,8 @@ package cli
 
 import (
 	"github.com/knoebber/dotfile/dotfile"
+	"github.com/knoebber/dotfile/dotfileclient"
+	"github.com/knoebber/dotfile/local"
 	"github.com/knoebber/usererror"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -9,11 +11,22 @@ import (
 type checkoutCommand struct {
 	alias      string
 	commitHash string
+	remote     string
 	force      bool
 }
 
 func (c *checkoutCommand) run(*kingpin.ParseContext) error {
-	s, err := loadFile(c.alias)
+	var (
+		s      *local.Storage
+		client *dotfileclient.Client
+		err    error
+	)
+
+	if c.remote != "" {
+		s, client, err = loadRemoteFile(c.alias, c.remote)
+	} else {
+		s, err = loadFile(c.alias)
+	}
 	if err != nil {
 		return err
 	}
@@ -31,11 +44,11 @@ func (c *checkoutCommand) run(*kingpin.ParseContext) error {
 		}
 	}
 
-	if err := dotfile.Checkout(s, c.commitHash); err != nil {
-		return err
+	if client != nil {
+		return remoteError(s.CheckoutRemote(client, c.commitHash))
 	}
 
-	return nil
+	return dotfile.Checkout(s, c.commitHash)
 }
 
 func addCheckoutSubCommandToApplication(app *kingpin.Application) {
@@ -48,4 +61,5 @@ func addCheckoutSubCommandToApplication(app *kingpin.Application) {
 		StringVar(&cc.alias)
 	c.Arg("commit-hash", "the revision to revert to").StringVar(&cc.commitHash)
 	c.Flag("force", "revert a file with uncommitted changes").Short('f').BoolVar(&cc.force)
+	c.Flag("remote", "also set the file to the revision on the named remote").Short('r').StringVar(&cc.remote)
 }
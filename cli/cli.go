@@ -2,8 +2,13 @@
 package cli
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/knoebber/dotfile/dotfileclient"
 	"github.com/knoebber/dotfile/local"
+	"github.com/knoebber/usererror"
 	"github.com/pkg/errors"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -17,30 +22,152 @@ type globalFlags struct {
 
 var flags globalFlags
 
-func newDotfileClient(tokenRequired bool) (*dotfileclient.Client, error) {
+// Creates a client for the remote named remoteName.
+// When alias is not empty the remote must be one that alias is allowed to sync with.
+func newDotfileClient(alias, remoteName string, tokenRequired bool) (*dotfileclient.Client, error) {
+	remote, err := resolveRemote(alias, remoteName)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRemoteClient(remote, tokenRequired)
+}
+
+// Returns the remote named remoteName from the user's config.
+// When alias is not empty the remote must be one that alias is allowed to sync with.
+func resolveRemote(alias, remoteName string) (*local.RemoteConfig, error) {
 	config, err := local.ReadConfig(flags.configPath)
 	if err != nil {
 		return nil, err
 	}
-	if config.Remote == "" {
-		return nil, errors.New("config value for \"remote\" must be set")
+
+	if alias == "" {
+		return config.Remote(remoteName)
+	}
+
+	return config.RemoteForAlias(alias, remoteName)
+}
+
+func newRemoteClient(remote *local.RemoteConfig, tokenRequired bool) (*dotfileclient.Client, error) {
+	if remote.URL == "" {
+		return nil, fmt.Errorf("config value for remote %q \"url\" must be set", remote.Name)
+	}
+	if remote.Username == "" {
+		return nil, fmt.Errorf("config value for remote %q \"username\" must be set", remote.Name)
+	}
+
+	// Token commands can prompt to unlock a password manager, so they only run when a token is required.
+	// A saved token is still sent so that private files can be read.
+	token := remote.Token
+	if tokenRequired {
+		var err error
+		if token, err = remote.ResolveToken(); err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return nil, fmt.Errorf("config value for remote %q \"token\" or \"token_command\" must be set", remote.Name)
+		}
 	}
-	if config.Username == "" {
-		return nil, errors.New("config value for \"username\" must be set")
+
+	client := dotfileclient.New(remote.URL, remote.Username, token)
+	client.Progress = newProgressReporter()
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		client.Cache = &dotfileclient.Cache{Dir: filepath.Join(cacheDir, "dotfile")}
 	}
-	if tokenRequired && config.Token == "" {
-		return nil, errors.New("config value for \"token\" must be set")
+
+	return client, nil
+}
+
+// Adds a hint for fixing errors that a remote responds with.
+func remoteError(err error) error {
+	switch {
+	case errors.Is(err, dotfileclient.ErrUnauthorized):
+		return usererror.Format("%s (check the remote's username and token)", err)
+	case errors.Is(err, dotfileclient.ErrRateLimited):
+		return usererror.Format("%s (try again later)", err)
 	}
 
-	return dotfileclient.New(config.Remote, config.Username, config.Token), nil
+	return err
 }
 
-func loadFile(alias string) (*local.Storage, error) {
+// Creates storage and a client for syncing alias with the remote named remoteName.
+func newRemoteStorage(alias, remoteName string, tokenRequired bool) (*local.Storage, *dotfileclient.Client, error) {
+	remote, err := resolveRemote(alias, remoteName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := newRemoteClient(remote, tokenRequired)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	storage, err := newStorage(alias, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	storage.RemoteName = remote.Name
+	return storage, client, nil
+}
+
+// Creates storage for alias with the file settings from the user's config.
+// Sets the encryption key when withKey is true and the file is encrypted.
+func newStorage(alias string, withKey bool) (*local.Storage, error) {
 	storage := &local.Storage{
 		Dir:   flags.storageDir,
 		Alias: alias,
 	}
 
+	var (
+		config *local.Config
+		err    error
+	)
+
+	// Commands that work on local files don't require a config file.
+	if _, statErr := os.Stat(flags.configPath); os.IsNotExist(statErr) {
+		config, err = local.DefaultConfig()
+	} else {
+		config, err = local.ReadConfig(flags.configPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings := config.File(alias)
+	storage.RemoteAlias = settings.RemoteAlias
+	storage.LineEndings = settings.LineEndings
+	storage.Encrypt = settings.Encrypt
+
+	if withKey && settings.Encrypt {
+		if storage.Passphrase, err = config.EncryptionKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	return storage, nil
+}
+
+// Loads alias and creates a client for changing it on the remote named remoteName.
+func loadRemoteFile(alias, remoteName string) (*local.Storage, *dotfileclient.Client, error) {
+	storage, client, err := newRemoteStorage(alias, remoteName, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := storage.SetTrackingData(); err != nil {
+		return nil, nil, errors.Wrapf(err, "loading %q", alias)
+	}
+
+	return storage, client, nil
+}
+
+func loadFile(alias string) (*local.Storage, error) {
+	storage, err := newStorage(alias, false)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := storage.SetTrackingData(); err != nil {
 		return nil, errors.Wrapf(err, "loading %q", alias)
 	}
@@ -62,7 +189,7 @@ func setConfig(app *kingpin.Application) error {
 	// Used for tab completion in commands that have an alias argument.
 	flags.defaultAliasList = local.ListAliases(defaultStorageDir)
 
-	app.Version("1.0.6")
+	app.Version(dotfileclient.Version)
 
 	app.Flag("storage-dir", "The directory where dotfile data is stored").
 		Default(defaultStorageDir).
@@ -89,10 +216,16 @@ func AddCommandsToApplication(app *kingpin.Application) error {
 	addPushSubCommandToApplication(app)
 	addPullSubCommandToApplication(app)
 	addConfigSubCommandToApplication(app)
+	addRemoteSubCommandToApplication(app)
 	addMoveSubCommandToApplication(app)
 	addRenameSubCommandToApplication(app)
 	addForgetSubCommandToApplication(app)
 	addRemoveSubCommandToApplication(app)
+	addStashSubCommandToApplication(app)
+	addFetchSubCommandToApplication(app)
+	addShareSubCommandToApplication(app)
+	addStarredSubCommandToApplication(app)
+	addSearchSubCommandToApplication(app)
 
 	return nil
 }
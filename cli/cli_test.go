@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"net/http"
 	"os"
 	"testing"
 
+	"github.com/knoebber/dotfile/dotfileclient"
+	"github.com/knoebber/dotfile/local"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
@@ -21,3 +24,62 @@ func TestAddCommandsToApplication(t *testing.T) {
 		assert.NoError(t, AddCommandsToApplication(app))
 	})
 }
+
+func TestRemoteError(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		assert.NoError(t, remoteError(nil))
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		err := remoteError(&dotfileclient.ResponseError{Action: "pushing", StatusCode: http.StatusUnauthorized})
+		assert.Contains(t, err.Error(), "token")
+	})
+
+	t.Run("other", func(t *testing.T) {
+		err := &dotfileclient.ResponseError{StatusCode: http.StatusNotFound}
+		assert.Equal(t, err, remoteError(err))
+	})
+}
+
+func TestNewRemoteClient(t *testing.T) {
+	remote := &local.RemoteConfig{
+		Name:         "origin",
+		URL:          "https://dotfilehub.com",
+		Username:     "user",
+		TokenCommand: "exit 1",
+	}
+
+	t.Run("token command is not run when a token is not required", func(t *testing.T) {
+		client, err := newRemoteClient(remote, false)
+		assert.NoError(t, err)
+		assert.Empty(t, client.Token)
+	})
+
+	t.Run("token command is run when a token is required", func(t *testing.T) {
+		_, err := newRemoteClient(remote, true)
+		assert.Error(t, err)
+	})
+
+	t.Run("saved token is sent", func(t *testing.T) {
+		client, err := newRemoteClient(&local.RemoteConfig{URL: remote.URL, Username: remote.Username, Token: "saved"}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "saved", client.Token)
+	})
+}
+
+func TestNewStorage(t *testing.T) {
+	clearTestStorage(t)
+	t.Setenv(local.EnvDefaultRemote, "work")
+
+	t.Run("environment is applied without a config file", func(t *testing.T) {
+		t.Setenv(local.EnvUsername, "ci")
+
+		_, err := newStorage(trackedFileAlias, false)
+		assert.Error(t, err, "the default remote from the environment doesn't exist")
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		_, err := newStorage(trackedFileAlias, false)
+		assert.NoError(t, err)
+	})
+}
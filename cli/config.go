@@ -22,15 +22,17 @@ func (cc *configCommand) run(*kingpin.ParseContext) error {
 		return err
 	}
 
-	if cc.key == "remote" {
-		fmt.Println(config.Remote)
-	} else if cc.key == "username" {
-		fmt.Println(config.Username)
-	} else if cc.key == "token" {
-		fmt.Println(config.Token)
-	} else {
+	if cc.key == "" {
 		fmt.Println(config)
+		return nil
 	}
+
+	value, err := config.Get(cc.key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(value)
 	return nil
 }
 
@@ -38,11 +40,17 @@ func addConfigSubCommandToApplication(app *kingpin.Application) {
 	cc := new(configCommand)
 
 	p := app.Command("config", "set or print dotfile configurations").Action(cc.run)
-	p.Arg("key", "the config key to change or print - <remote/username/token>").EnumVar(&cc.key,
-		"remote",
-		"username",
-		"token",
-	)
+	p.Arg("key", "the config key to change or print - <remote/username/token/token_command/default_remote> or "+
+		"remotes.<name>.<url/username/token/token_command> or files.<alias>.<remote/remote_alias/line_endings/encrypt>").
+		HintOptions(
+			"remote",
+			"username",
+			"token",
+			"token_command",
+			"default_remote",
+			"encryption_key_command",
+		).
+		StringVar(&cc.key)
 
 	p.Arg("value", "the new value").StringVar(&cc.value)
 }
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+
 	"github.com/hexops/gotextdiff"
 	"github.com/knoebber/dotfile/dotfile"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -10,8 +11,10 @@ import (
 // TODO commitHash should match on first 7 characters as well.
 // Same for checkout
 type diffCommand struct {
-	alias      string
-	commitHash string
+	alias            string
+	commitHash       string
+	context          int
+	ignoreWhitespace bool
 }
 
 func (d *diffCommand) run(*kingpin.ParseContext) error {
@@ -20,39 +23,47 @@ func (d *diffCommand) run(*kingpin.ParseContext) error {
 		return err
 	}
 
-	if d.commitHash == "" {
-		d.commitHash = s.FileData.Revision
+	hash := d.commitHash
+	if hash == "" {
+		hash = s.FileData.Revision
 	}
 
-	to := d.commitHash
+	// Compare against the last known revision on a remote.
+	states, err := s.RemoteStates()
+	if err != nil {
+		return err
+	}
+	if state, ok := states[hash]; ok {
+		hash = state.Revision
+	}
+
+	to := hash
 	if to == "" {
 		to = "*"
+	} else if d.commitHash != "" && d.commitHash != hash {
+		to = fmt.Sprintf("%s (%s)", d.commitHash, dotfile.ShortenHash(hash))
 	}
 	fmt.Printf("\033[1mdiff %s %s\033[0m\n", s.FileData.Path, to)
 
-	unified, err := dotfile.Diff(s, d.commitHash, "")
+	diff, err := dotfile.Diff(s, hash, "", dotfile.DiffOptions{
+		Context:          d.context,
+		IgnoreWhitespace: d.ignoreWhitespace,
+	})
 	if err != nil {
 		return err
 	}
+	fmt.Println(diff.Stat())
 
-	for _, hunk := range unified.Hunks {
-		if len(unified.Hunks) > 1 {
-			fmt.Println("\033[1m==HUNK==\033[0m")
-		}
+	for _, hunk := range diff.Hunks {
+		fmt.Printf("\x1b[36m%s\x1b[0m\n", hunk.Header())
 		for _, line := range hunk.Lines {
-			text := line.Content
-
 			switch line.Kind {
 			case gotextdiff.Insert:
-				fmt.Print("\x1b[32m")
-				fmt.Print(text)
-				fmt.Print("\x1b[0m")
+				fmt.Printf("\x1b[32m+%s\x1b[0m\n", line.Content)
 			case gotextdiff.Delete:
-				fmt.Print("\x1b[31m")
-				fmt.Print(text)
-				fmt.Print("\x1b[0m")
+				fmt.Printf("\x1b[31m-%s\x1b[0m\n", line.Content)
 			case gotextdiff.Equal:
-				fmt.Print(text)
+				fmt.Printf(" %s\n", line.Content)
 			}
 		}
 	}
@@ -68,7 +79,13 @@ func addDiffSubCommandToApplication(app *kingpin.Application) {
 		Required().
 		StringVar(&dc.alias)
 	c.Arg("commit-hash",
-		"the revision to diff against; default current").
+		"the revision or the name of a fetched remote to diff against; default current").
 		StringVar(&dc.commitHash)
-
+	c.Flag("context", "the amount of unchanged lines to show around changes").
+		Short('U').
+		Default("3").
+		IntVar(&dc.context)
+	c.Flag("ignore-whitespace", "ignore changes that only add or remove whitespace").
+		Short('w').
+		BoolVar(&dc.ignoreWhitespace)
 }
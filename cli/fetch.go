@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"github.com/knoebber/dotfile/local"
+	"github.com/pkg/errors"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+type fetchCommand struct {
+	alias    string
+	remote   string
+	fetchAll bool
+}
+
+func (fc *fetchCommand) run(*kingpin.ParseContext) error {
+	if fc.fetchAll {
+		return fc.all()
+	} else if fc.alias == "" {
+		return errors.New("neither alias nor --all provided to fetch")
+	}
+
+	return fc.fetch(fc.alias)
+}
+
+func (fc *fetchCommand) fetch(alias string) error {
+	s, client, err := newRemoteStorage(alias, fc.remote, false)
+	if err != nil {
+		return err
+	}
+	if err := s.SetTrackingData(); err != nil {
+		return errors.Wrapf(err, "loading %q", alias)
+	}
+
+	return remoteError(s.Fetch(client))
+}
+
+// Fetches every tracked file.
+// Skips files that are assigned to a different remote.
+func (fc *fetchCommand) all() error {
+	config, err := local.ReadConfig(flags.configPath)
+	if err != nil {
+		return err
+	}
+
+	remote, err := config.Remote(fc.remote)
+	if err != nil {
+		return err
+	}
+
+	for _, alias := range local.ListAliases(flags.storageDir)() {
+		if _, err := config.RemoteForAlias(alias, remote.Name); err != nil {
+			continue
+		}
+
+		if err := fc.fetch(alias); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addFetchSubCommandToApplication(app *kingpin.Application) {
+	fc := new(fetchCommand)
+
+	c := app.Command("fetch", "download new revisions from a remote without changing the file").Action(fc.run)
+	c.Arg("alias", "the file to fetch").HintAction(flags.defaultAliasList).StringVar(&fc.alias)
+	c.Flag("remote", "the name of the remote to fetch from").Short('r').StringVar(&fc.remote)
+	c.Flag("all", "fetch all tracked files").Short('a').BoolVar(&fc.fetchAll)
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetch(t *testing.T) {
+	clearTestStorage(t)
+	initTestFile(t)
+
+	t.Run("error when alias and all are not set", func(t *testing.T) {
+		assert.Error(t, new(fetchCommand).run(nil))
+	})
+
+	t.Run("error when remote is not configured", func(t *testing.T) {
+		fetchCommand := &fetchCommand{alias: trackedFileAlias}
+		assert.Error(t, fetchCommand.run(nil))
+	})
+
+	t.Run("error when all and remote is not configured", func(t *testing.T) {
+		fetchCommand := &fetchCommand{fetchAll: true}
+		assert.Error(t, fetchCommand.run(nil))
+	})
+
+	clearTestStorage(t)
+}
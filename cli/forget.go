@@ -4,10 +4,20 @@ import "gopkg.in/alecthomas/kingpin.v2"
 
 type forgetCommand struct {
 	alias   string
+	remote  string
 	commits bool
 }
 
 func (fc *forgetCommand) run(*kingpin.ParseContext) error {
+	if fc.remote != "" {
+		s, client, err := loadRemoteFile(fc.alias, fc.remote)
+		if err != nil {
+			return err
+		}
+
+		return remoteError(s.ForgetRemote(client, fc.commits))
+	}
+
 	s, err := loadFile(fc.alias)
 	if err != nil {
 		return err
@@ -26,4 +36,5 @@ func addForgetSubCommandToApplication(app *kingpin.Application) {
 	c := app.Command("forget", "untrack a file - removes all tracking data").Action(fc.run)
 	c.Arg("alias", "the file to forget").HintAction(flags.defaultAliasList).Required().StringVar(&fc.alias)
 	c.Flag("commits", "remove all commits except the current").Short('c').BoolVar(&fc.commits)
+	c.Flag("remote", "also delete the file on the named remote").Short('r').StringVar(&fc.remote)
 }
@@ -25,6 +25,13 @@ func init() {
 	}
 }
 
+// Removes the test storage after the tests so that runs don't leave files in the tree.
+func TestMain(m *testing.M) {
+	code := m.Run()
+	_ = os.RemoveAll(testDir)
+	os.Exit(code)
+}
+
 func initTestFile(t *testing.T) {
 	if err := os.Mkdir(testDir, 0755); err != nil {
 		t.Fatalf("creating test dir: %s", err)
@@ -9,14 +9,14 @@ import (
 
 type listCommand struct {
 	path     bool
-	remote   bool
+	remote   string
 	username string
 }
 
 func (lc *listCommand) run(*kingpin.ParseContext) (err error) {
 	var result []string
 
-	if lc.remote || lc.username != "" {
+	if lc.remote != "" || lc.username != "" {
 		result, err = lc.listRemote()
 	} else {
 		result, err = local.List(flags.storageDir, lc.path)
@@ -33,21 +33,26 @@ func (lc *listCommand) run(*kingpin.ParseContext) (err error) {
 }
 
 func (lc *listCommand) listRemote() ([]string, error) {
-	client, err := newDotfileClient(false)
+	client, err := newDotfileClient("", lc.remote, false)
 	if err != nil {
 		return nil, err
 	}
 	if lc.username != "" {
-		client.Username = lc.username
+		client.Owner = lc.username
 	}
 
-	return client.List(lc.path)
+	files, err := client.List(lc.path)
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	return files, nil
 }
 
 func addListSubCommandToApplication(app *kingpin.Application) {
 	lc := new(listCommand)
 	c := app.Command("ls", "list all tracked files, an asterisks signifies uncommitted changes").Action(lc.run)
 	c.Flag("path", "include path in list").Short('p').BoolVar(&lc.path)
-	c.Flag("remote", "read file list from remote").Short('r').BoolVar(&lc.remote)
+	c.Flag("remote", "read file list from the named remote").Short('r').StringVar(&lc.remote)
 	c.Flag("username", "read files owned by username on remote").Short('u').StringVar(&lc.username)
 }
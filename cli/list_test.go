@@ -8,7 +8,7 @@ import (
 func TestList(t *testing.T) {
 
 	t.Run("error on attempt to list remote files without config set", func(t *testing.T) {
-		listCommand := &listCommand{remote: true}
+		listCommand := &listCommand{remote: "origin"}
 		assert.Error(t, listCommand.run(nil))
 	})
 
@@ -2,9 +2,12 @@ package cli
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/knoebber/dotfile/local"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -23,17 +26,36 @@ func (l *logCommand) run(*kingpin.ParseContext) error {
 		return err
 	}
 
+	states, err := s.RemoteStates()
+	if err != nil {
+		return err
+	}
+
 	revision := s.FileData.Revision
 	delim := strings.Repeat(delimChar, len(revision))
 
-	halfHeaderDelim := strings.Repeat(delimChar, (len(revision)-9)/2)
-	currentDelim := halfHeaderDelim + " CURRENT " + halfHeaderDelim + delimChar
-	for _, commit := range s.FileData.Commits {
+	for _, name := range sortedRemoteNames(states) {
+		ahead, behind := local.AheadBehind(s.FileData.Commits, states[name].Commits)
+		fmt.Printf("%s: %d ahead, %d behind\n", name, len(ahead), len(behind))
+	}
+
+	for _, commit := range logCommits(s.FileData.Commits, states) {
+		var labels []string
+
 		timeStamp := time.Unix(commit.Timestamp, 0).Format(timestampDisplayFormat)
 
-		fmt.Println("")
 		if commit.Hash == revision {
-			fmt.Println(currentDelim)
+			labels = append(labels, "CURRENT")
+		}
+		for _, name := range sortedRemoteNames(states) {
+			if commit.Hash == states[name].Revision {
+				labels = append(labels, name)
+			}
+		}
+
+		fmt.Println("")
+		if len(labels) > 0 {
+			fmt.Println(headerDelim(strings.Join(labels, " "), len(delim)))
 		} else {
 			fmt.Println(delim)
 		}
@@ -43,11 +65,74 @@ func (l *logCommand) run(*kingpin.ParseContext) error {
 			fmt.Print(commit.Message + "\n")
 		}
 		fmt.Print(commit.Hash)
+		if remotes := commit.remotes; len(remotes) > 0 {
+			fmt.Printf(" (only on %s)", strings.Join(remotes, ", "))
+		}
 		fmt.Printf("\n%s\n", delim)
 	}
 	return nil
 }
 
+// A commit in the log and the remotes that have it when it isn't a local commit.
+type logCommit struct {
+	dotfile.Commit
+	remotes []string
+}
+
+// Returns local commits and commits that were fetched from remotes in order of timestamp.
+func logCommits(commits []dotfile.Commit, states map[string]*local.RemoteState) []*logCommit {
+	result := make([]*logCommit, 0, len(commits))
+	remoteCommits := make(map[string]*logCommit)
+
+	for _, c := range commits {
+		result = append(result, &logCommit{Commit: c})
+	}
+
+	for _, name := range sortedRemoteNames(states) {
+		_, behind := local.AheadBehind(commits, states[name].Commits)
+		commitMap := (&dotfile.TrackingData{Commits: states[name].Commits}).MapCommits()
+
+		for _, hash := range behind {
+			if rc, ok := remoteCommits[hash]; ok {
+				rc.remotes = append(rc.remotes, name)
+				continue
+			}
+
+			rc := &logCommit{Commit: *commitMap[hash], remotes: []string{name}}
+			remoteCommits[hash] = rc
+			result = append(result, rc)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp < result[j].Timestamp
+	})
+
+	return result
+}
+
+func sortedRemoteNames(states map[string]*local.RemoteState) []string {
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Centers label in a delimiter that is width characters long.
+func headerDelim(label string, width int) string {
+	label = " " + label + " "
+
+	padding := width - len(label)
+	if padding < 2 {
+		return delimChar + label + delimChar
+	}
+
+	return strings.Repeat(delimChar, padding/2) + label + strings.Repeat(delimChar, padding-padding/2)
+}
+
 func addLogSubCommandToApplication(app *kingpin.Application) {
 	lc := new(logCommand)
 
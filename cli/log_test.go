@@ -3,6 +3,8 @@ package cli
 import (
 	"testing"
 
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/knoebber/dotfile/local"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,3 +24,23 @@ func TestLog(t *testing.T) {
 		assert.NoError(t, logCommand.run(nil))
 	})
 }
+
+func TestLogCommits(t *testing.T) {
+	commits := []dotfile.Commit{{Hash: "a", Timestamp: 1}, {Hash: "c", Timestamp: 3}}
+	states := map[string]*local.RemoteState{
+		"origin": {Revision: "b", Commits: []dotfile.Commit{{Hash: "a", Timestamp: 1}, {Hash: "b", Timestamp: 2}}},
+		"public": {Revision: "b", Commits: []dotfile.Commit{{Hash: "b", Timestamp: 2}}},
+	}
+
+	result := logCommits(commits, states)
+	assert.Len(t, result, 3)
+	assert.Equal(t, "b", result[1].Hash)
+	assert.Equal(t, []string{"origin", "public"}, result[1].remotes)
+	assert.Empty(t, result[2].remotes)
+}
+
+func TestHeaderDelim(t *testing.T) {
+	assert.Equal(t, "== CURRENT ==", headerDelim("CURRENT", 13))
+	assert.Equal(t, "== CURRENT ===", headerDelim("CURRENT", 14))
+	assert.Equal(t, "= CURRENT origin =", headerDelim("CURRENT origin", 10))
+}
@@ -1,20 +1,42 @@
 package cli
 
-import "gopkg.in/alecthomas/kingpin.v2"
+import (
+	"github.com/knoebber/usererror"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
 
 type moveCommand struct {
 	alias      string
 	newPath    string
+	remote     string
 	parentDirs bool
+	host       bool
 }
 
 func (mc *moveCommand) run(*kingpin.ParseContext) error {
+	if mc.remote != "" {
+		return mc.moveRemote()
+	}
+
 	s, err := loadFile(mc.alias)
 	if err != nil {
 		return err
 	}
 
-	return s.Move(mc.newPath, mc.parentDirs)
+	return s.Move(mc.newPath, mc.parentDirs, mc.host)
+}
+
+func (mc *moveCommand) moveRemote() error {
+	if mc.host {
+		return usererror.New("--host and --remote can't be used together")
+	}
+
+	s, client, err := loadRemoteFile(mc.alias, mc.remote)
+	if err != nil {
+		return err
+	}
+
+	return remoteError(s.MoveRemote(client, mc.newPath, mc.parentDirs))
 }
 
 func addMoveSubCommandToApplication(app *kingpin.Application) {
@@ -24,4 +46,6 @@ func addMoveSubCommandToApplication(app *kingpin.Application) {
 	p.Arg("alias", "the file to move").HintAction(flags.defaultAliasList).Required().StringVar(&mc.alias)
 	p.Arg("new path", "the path to the new destination").StringVar(&mc.newPath)
 	p.Flag("parent-dirs", "create parent directories that do not exist").Short('p').BoolVar(&mc.parentDirs)
+	p.Flag("host", "only change the path on this machine").BoolVar(&mc.host)
+	p.Flag("remote", "also change the path on the named remote").Short('r').StringVar(&mc.remote)
 }
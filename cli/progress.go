@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Prints revision transfer progress on a single line.
+type progressReporter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	action string
+	total  int
+	done   int
+	bytes  int
+}
+
+func newProgressReporter() *progressReporter {
+	return &progressReporter{out: os.Stderr}
+}
+
+func (p *progressReporter) Start(action string, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.action = action
+	p.total = total
+	p.done = 0
+	p.bytes = 0
+	p.print()
+}
+
+func (p *progressReporter) Progress(_ string, bytes int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	p.bytes += bytes
+	p.print()
+}
+
+func (p *progressReporter) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintln(p.out)
+}
+
+func (p *progressReporter) print() {
+	fmt.Fprintf(p.out, "\r%s: %d/%d revisions (%d bytes)", p.action, p.done, p.total, p.bytes)
+}
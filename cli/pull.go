@@ -9,42 +9,82 @@ import (
 
 type pullCommand struct {
 	alias    string
+	remote   string
 	username string
+	strategy string
 	pullAll  bool
 }
 
 func (pc *pullCommand) run(*kingpin.ParseContext) error {
-	var err error
+	if pc.pullAll {
+		return pc.all()
+	} else if pc.alias == "" {
+		return errors.New("neither alias nor --all provided to pull")
+	}
 
-	client, err := newDotfileClient(false)
+	storage, client, err := newRemoteStorage(pc.alias, pc.remote, false)
 	if err != nil {
 		return err
 	}
+	pc.setUsername(storage, client)
 
-	if pc.username != "" {
-		client.Username = pc.username
-	}
-	if pc.pullAll {
-		return pullAll(client)
-	} else if pc.alias != "" {
-		storage := &local.Storage{Dir: flags.storageDir, Alias: pc.alias}
-		return storage.Pull(client)
-	} else {
-		return errors.New("neither alias nor --all provided to pull")
+	return remoteError(storage.Pull(client, local.PullStrategy(pc.strategy)))
+}
+
+// Pulls files from the username flag instead of the configured user.
+// Requests are still authenticated as the configured user so that members can read an organization's private files.
+// The state of another user's file is not recorded as the remote's state.
+func (pc *pullCommand) setUsername(storage *local.Storage, client *dotfileclient.Client) {
+	if pc.username == "" {
+		return
 	}
+
+	client.Owner = pc.username
+	storage.RemoteName = ""
 }
 
-func pullAll(client *dotfileclient.Client) error {
-	files, err := client.List(false)
+// Pulls every file on the remote.
+// Skips files that are assigned to a different remote.
+func (pc *pullCommand) all() error {
+	config, err := local.ReadConfig(flags.configPath)
+	if err != nil {
+		return err
+	}
+
+	remote, err := config.Remote(pc.remote)
 	if err != nil {
 		return err
 	}
 
-	for _, alias := range files {
-		storage := &local.Storage{Dir: flags.storageDir, Alias: alias}
-		if err := storage.Pull(client); err != nil {
+	client, err := newRemoteClient(remote, false)
+	if err != nil {
+		return err
+	}
+	if pc.username != "" {
+		client.Owner = pc.username
+	}
+
+	files, err := client.List(false)
+	if err != nil {
+		return remoteError(err)
+	}
+
+	for _, remoteAlias := range files {
+		alias := config.LocalAlias(remoteAlias)
+		if _, err := config.RemoteForAlias(alias, remote.Name); err != nil {
+			continue
+		}
+
+		storage, err := newStorage(alias, true)
+		if err != nil {
 			return err
 		}
+		storage.RemoteName = remote.Name
+		pc.setUsername(storage, client)
+
+		if err := storage.Pull(client, local.PullStrategy(pc.strategy)); err != nil {
+			return remoteError(err)
+		}
 	}
 	return nil
 }
@@ -54,6 +94,10 @@ func addPullSubCommandToApplication(app *kingpin.Application) {
 
 	p := app.Command("pull", "pull changes from central service").Action(pc.run)
 	p.Arg("alias", "the file to pull").HintAction(flags.defaultAliasList).StringVar(&pc.alias)
-	p.Flag("username", "override config username").Short('u').StringVar(&pc.username)
+	p.Flag("remote", "the name of the remote to pull from").Short('r').StringVar(&pc.remote)
+	p.Flag("username", "pull files owned by another user or organization").Short('u').StringVar(&pc.username)
 	p.Flag("all", "pull all tracked files").Short('a').BoolVar(&pc.pullAll)
+	p.Flag("strategy", "how to handle uncommitted changes - <ours/theirs/merge/stash>").
+		Short('s').
+		EnumVar(&pc.strategy, local.PullStrategies...)
 }
@@ -3,21 +3,29 @@ package cli
 import "gopkg.in/alecthomas/kingpin.v2"
 
 type pushCommand struct {
-	alias string
+	alias      string
+	remote     string
+	username   string
+	visibility string
 }
 
 func (pc *pushCommand) run(*kingpin.ParseContext) error {
-	s, err := loadFile(pc.alias)
+	s, client, err := newRemoteStorage(pc.alias, pc.remote, true)
 	if err != nil {
 		return err
 	}
-
-	client, err := newDotfileClient(true)
-	if err != nil {
+	if err := s.SetTrackingData(); err != nil {
 		return err
 	}
 
-	return s.Push(client)
+	if pc.username != "" {
+		// The remote's state is only recorded for the configured user's files.
+		client.Owner = pc.username
+		s.RemoteName = ""
+	}
+
+	client.Visibility = pc.visibility
+	return remoteError(s.Push(client))
 }
 
 func addPushSubCommandToApplication(app *kingpin.Application) {
@@ -25,4 +33,8 @@ func addPushSubCommandToApplication(app *kingpin.Application) {
 
 	p := app.Command("push", "push committed changes to a dotfile server").Action(pc.run)
 	p.Arg("alias", "the file to push").HintAction(flags.defaultAliasList).Required().StringVar(&pc.alias)
+	p.Flag("remote", "the name of the remote to push to").Short('r').StringVar(&pc.remote)
+	p.Flag("username", "push to the files of an organization").Short('u').StringVar(&pc.username)
+	p.Flag("visibility", "who can see the file on remote").
+		EnumVar(&pc.visibility, "public", "unlisted", "private")
 }
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/knoebber/dotfile/local"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+type remoteCommand struct {
+	name     string
+	url      string
+	username string
+	token    string
+	alias    string
+}
+
+func (rc *remoteCommand) add(*kingpin.ParseContext) error {
+	return local.UpdateConfig(flags.configPath, func(c *local.Config) error {
+		return c.AddRemote(rc.name, rc.url, rc.username, rc.token)
+	})
+}
+
+func (rc *remoteCommand) remove(*kingpin.ParseContext) error {
+	return local.UpdateConfig(flags.configPath, func(c *local.Config) error {
+		return c.RemoveRemote(rc.name)
+	})
+}
+
+func (rc *remoteCommand) setDefault(*kingpin.ParseContext) error {
+	return local.UpdateConfig(flags.configPath, func(c *local.Config) error {
+		return c.SetDefaultRemote(rc.name)
+	})
+}
+
+func (rc *remoteCommand) assign(*kingpin.ParseContext) error {
+	return local.UpdateConfig(flags.configPath, func(c *local.Config) error {
+		return c.AssignRemote(rc.alias, rc.name)
+	})
+}
+
+func (rc *remoteCommand) list(*kingpin.ParseContext) error {
+	config, err := local.ReadConfig(flags.configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range config.RemoteNames() {
+		r := config.Remotes[name]
+		if name == config.DefaultRemote {
+			name += "*"
+		}
+		fmt.Printf("%s %s %s\n", name, r.URL, r.Username)
+	}
+	return nil
+}
+
+func addRemoteSubCommandToApplication(app *kingpin.Application) {
+	rc := new(remoteCommand)
+
+	c := app.Command("remote", "manage dotfile servers")
+
+	add := c.Command("add", "add a named remote").Action(rc.add)
+	add.Arg("name", "the name of the remote").Required().StringVar(&rc.name)
+	add.Arg("url", "the url of the dotfile server").Required().StringVar(&rc.url)
+	add.Flag("username", "the username on the remote").Short('u').StringVar(&rc.username)
+	add.Flag("token", "the cli token for the remote").Short('t').StringVar(&rc.token)
+
+	rm := c.Command("rm", "remove a named remote").Action(rc.remove)
+	rm.Arg("name", "the remote to remove").Required().StringVar(&rc.name)
+
+	c.Command("ls", "list remotes, an asterisks signifies the default").Action(rc.list)
+
+	def := c.Command("default", "set the default remote").Action(rc.setDefault)
+	def.Arg("name", "the remote to use by default").Required().StringVar(&rc.name)
+
+	assign := c.Command("assign", "only allow a file to sync with a remote").Action(rc.assign)
+	assign.Arg("alias", "the file to assign").HintAction(flags.defaultAliasList).Required().StringVar(&rc.alias)
+	assign.Arg("name", "the remote to assign, unassigns when empty").StringVar(&rc.name)
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/knoebber/dotfile/local"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemote(t *testing.T) {
+	resetTestStorage(t)
+
+	t.Run("error when remote doesn't exist", func(t *testing.T) {
+		rc := &remoteCommand{name: "work"}
+		assert.Error(t, rc.remove(nil))
+		assert.Error(t, rc.setDefault(nil))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		rc := &remoteCommand{name: "work", url: "https://dotfile.example.com", username: "test"}
+		assert.NoError(t, rc.add(nil))
+		assert.NoError(t, rc.list(nil))
+
+		rc = &remoteCommand{name: "public", url: "https://dotfilehub.com", username: "test"}
+		assert.NoError(t, rc.add(nil))
+		assert.NoError(t, rc.setDefault(nil))
+
+		rc = &remoteCommand{alias: trackedFileAlias, name: "work"}
+		assert.NoError(t, rc.assign(nil))
+
+		config, err := local.ReadConfig(flags.configPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "public", config.DefaultRemote)
+		assert.Equal(t, "work", config.Files[trackedFileAlias].Remote)
+	})
+
+	t.Run("error when pushing to unassigned remote", func(t *testing.T) {
+		pc := &pushCommand{alias: trackedFileAlias, remote: "public"}
+		assert.Error(t, pc.run(nil))
+
+		_, err := newDotfileClient(trackedFileAlias, "public", false)
+		assert.Error(t, err)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		rc := &remoteCommand{name: "work"}
+		assert.NoError(t, rc.remove(nil))
+	})
+
+	clearTestStorage(t)
+}
@@ -1,19 +1,63 @@
 package cli
 
-import "gopkg.in/alecthomas/kingpin.v2"
+import (
+	"os"
+
+	"github.com/knoebber/dotfile/local"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
 
 type renameCommand struct {
 	alias    string
 	newAlias string
+	remote   string
 }
 
 func (rc *renameCommand) run(*kingpin.ParseContext) error {
+	if rc.remote != "" {
+		return rc.renameRemote()
+	}
+
 	s, err := loadFile(rc.alias)
 	if err != nil {
 		return err
 	}
 
-	return s.Rename(rc.newAlias)
+	if err := s.Rename(rc.newAlias); err != nil {
+		return err
+	}
+
+	return rc.updateConfig(false)
+}
+
+// Renames the file locally and on the remote.
+// The remote file takes the new alias so its remote alias setting is removed.
+func (rc *renameCommand) renameRemote() error {
+	s, client, err := loadRemoteFile(rc.alias, rc.remote)
+	if err != nil {
+		return err
+	}
+
+	if err := s.RenameRemote(client, rc.newAlias); err != nil {
+		return remoteError(err)
+	}
+
+	return rc.updateConfig(true)
+}
+
+// Moves the file's config settings to the new alias.
+func (rc *renameCommand) updateConfig(clearRemoteAlias bool) error {
+	if _, err := os.Stat(flags.configPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	return local.UpdateConfig(flags.configPath, func(c *local.Config) error {
+		c.RenameFile(rc.alias, rc.newAlias)
+		if fc, ok := c.Files[rc.newAlias]; ok && clearRemoteAlias {
+			fc.RemoteAlias = ""
+		}
+		return nil
+	})
 }
 
 func addRenameSubCommandToApplication(app *kingpin.Application) {
@@ -22,4 +66,5 @@ func addRenameSubCommandToApplication(app *kingpin.Application) {
 	p := app.Command("rename", "change a files alias").Action(rc.run)
 	p.Arg("alias", "the file to rename").HintAction(flags.defaultAliasList).Required().StringVar(&rc.alias)
 	p.Arg("new alias", "the new name").Required().StringVar(&rc.newAlias)
+	p.Flag("remote", "also rename the file on the named remote").Short('r').StringVar(&rc.remote)
 }
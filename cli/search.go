@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+type searchCommand struct {
+	query  []string
+	page   int
+	remote string
+}
+
+func (sc *searchCommand) run(*kingpin.ParseContext) error {
+	client, err := newDotfileClient("", sc.remote, false)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Search(strings.Join(sc.query, " "), sc.page)
+	if err != nil {
+		return remoteError(err)
+	}
+
+	for _, f := range result.Results {
+		fmt.Printf("%s/%s %s\n", f.Username, f.Alias, f.Path)
+	}
+	if result.Pages > 1 {
+		fmt.Printf("page %d of %d, %d results\n", result.Page, result.Pages, result.Total)
+	}
+
+	return nil
+}
+
+func addSearchSubCommandToApplication(app *kingpin.Application) {
+	sc := new(searchCommand)
+	c := app.Command("search", "search public files on remote").Action(sc.run)
+	c.Arg("query", "text and filters to search for").Required().StringsVar(&sc.query)
+	c.Flag("page", "the page of results to show").Default("1").IntVar(&sc.page)
+	c.Flag("remote", "search files on the named remote").Short('r').StringVar(&sc.remote)
+}
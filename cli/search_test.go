@@ -0,0 +1,14 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearch(t *testing.T) {
+	t.Run("error on attempt to search without config set", func(t *testing.T) {
+		searchCommand := &searchCommand{query: []string{"vimrc"}, page: 1, remote: "origin"}
+		assert.Error(t, searchCommand.run(nil))
+	})
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knoebber/usererror"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+type shareCommand struct {
+	alias   string
+	remote  string
+	expires string
+	history bool
+}
+
+func (sc *shareCommand) run(*kingpin.ParseContext) error {
+	expiresIn, err := parseExpiration(sc.expires)
+	if err != nil {
+		return err
+	}
+
+	remote, err := resolveRemote(sc.alias, sc.remote)
+	if err != nil {
+		return err
+	}
+
+	client, err := newRemoteClient(remote, true)
+	if err != nil {
+		return err
+	}
+
+	s, err := newStorage(sc.alias, false)
+	if err != nil {
+		return err
+	}
+
+	alias := s.RemoteAlias
+	if alias == "" {
+		alias = sc.alias
+	}
+
+	share, err := client.CreateShare(alias, expiresIn, sc.history)
+	if err != nil {
+		return remoteError(err)
+	}
+
+	fmt.Println(share.URL)
+	if share.ExpiresAt != nil {
+		fmt.Println("expires", share.ExpiresAt.Local().Format(time.RFC1123))
+	}
+
+	return nil
+}
+
+// Parses a duration that can also be a number of days, such as "7d".
+// "never" is a zero duration.
+func parseExpiration(s string) (time.Duration, error) {
+	if s == "never" {
+		return 0, nil
+	}
+
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.Atoi(days)
+		if err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+
+	return 0, usererror.Format("Invalid expiration %q (use a value like 7d, 12h, or never)", s)
+}
+
+func addShareSubCommandToApplication(app *kingpin.Application) {
+	sc := new(shareCommand)
+
+	c := app.Command("share", "create a link that lets anyone read a file on remote").Action(sc.run)
+	c.Arg("alias", "the file to share").HintAction(flags.defaultAliasList).Required().StringVar(&sc.alias)
+	c.Flag("expires", "how long the link works: days (7d), a duration (12h), or never").
+		Short('e').Default("7d").StringVar(&sc.expires)
+	c.Flag("history", "also share the file's commits").BoolVar(&sc.history)
+	c.Flag("remote", "the name of the remote that has the file").Short('r').StringVar(&sc.remote)
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/knoebber/dotfile/local"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExpiration(t *testing.T) {
+	t.Run("days", func(t *testing.T) {
+		d, err := parseExpiration("7d")
+		assert.NoError(t, err)
+		assert.Equal(t, 7*24*time.Hour, d)
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		d, err := parseExpiration("12h")
+		assert.NoError(t, err)
+		assert.Equal(t, 12*time.Hour, d)
+	})
+
+	t.Run("never", func(t *testing.T) {
+		d, err := parseExpiration("never")
+		assert.NoError(t, err)
+		assert.Zero(t, d)
+	})
+
+	t.Run("error on invalid values", func(t *testing.T) {
+		for _, s := range []string{"", "0d", "-1h", "d", "week"} {
+			_, err := parseExpiration(s)
+			assert.Error(t, err, s)
+		}
+	})
+}
+
+func TestShare(t *testing.T) {
+	var sharedPath string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sharedPath = r.URL.Path
+		fmt.Fprint(w, `{"path": "/share/abc"}`)
+	}))
+	defer ts.Close()
+
+	resetTestStorage(t)
+	assert.NoError(t, local.SetConfig(flags.configPath, "remote", ts.URL))
+	assert.NoError(t, local.SetConfig(flags.configPath, "username", "user"))
+	assert.NoError(t, local.SetConfig(flags.configPath, "token", "token"))
+
+	t.Run("shares the local alias", func(t *testing.T) {
+		shareCommand := &shareCommand{alias: trackedFileAlias, expires: "7d"}
+		assert.NoError(t, shareCommand.run(nil))
+		assert.Equal(t, "/api/v1/user/user/"+trackedFileAlias+"/shares", sharedPath)
+	})
+
+	t.Run("shares the remote alias", func(t *testing.T) {
+		assert.NoError(t, local.SetConfig(flags.configPath, "files."+trackedFileAlias+".remote_alias", "vimrc"))
+
+		shareCommand := &shareCommand{alias: trackedFileAlias, expires: "7d"}
+		assert.NoError(t, shareCommand.run(nil))
+		assert.Equal(t, "/api/v1/user/user/vimrc/shares", sharedPath)
+	})
+}
@@ -12,7 +12,7 @@ import (
 type showCommand struct {
 	alias    string
 	data     bool
-	remote   bool
+	remote   string
 	username string
 }
 
@@ -22,7 +22,7 @@ func (sc *showCommand) run(*kingpin.ParseContext) error {
 		err     error
 	)
 
-	if sc.remote || sc.username != "" {
+	if sc.remote != "" || sc.username != "" {
 		content, err = sc.showRemote()
 	} else {
 		content, err = sc.showLocal()
@@ -52,21 +52,22 @@ func (sc *showCommand) showLocal() ([]byte, error) {
 func (sc *showCommand) showRemote() ([]byte, error) {
 	var buff bytes.Buffer
 
-	client, err := newDotfileClient(false)
+	client, err := newDotfileClient("", sc.remote, false)
 	if err != nil {
 		return nil, err
 	}
 	if sc.username != "" {
-		client.Username = sc.username
+		client.Owner = sc.username
 	}
 
 	if !sc.data {
-		return client.Content(sc.alias)
+		content, err := client.Content(sc.alias)
+		return content, remoteError(err)
 	}
 
 	content, err := client.TrackingDataBytes(sc.alias)
 	if err != nil {
-		return nil, err
+		return nil, remoteError(err)
 	}
 	if content == nil {
 		return nil, fmt.Errorf("file not found")
@@ -84,6 +85,6 @@ func addShowSubCommandToApplication(app *kingpin.Application) {
 	c := app.Command("show", "show the file").Action(sc.run)
 	c.Arg("alias", "the file to show").HintAction(flags.defaultAliasList).Required().StringVar(&sc.alias)
 	c.Flag("data", "show the file data in json format").Short('d').BoolVar(&sc.data)
-	c.Flag("remote", "show the file on remote").Short('r').BoolVar(&sc.remote)
+	c.Flag("remote", "show the file on the named remote").Short('r').StringVar(&sc.remote)
 	c.Flag("username", "show the file owned by username on remote").Short('u').StringVar(&sc.username)
 }
@@ -10,11 +10,11 @@ func TestShow(t *testing.T) {
 	clearTestStorage(t)
 
 	t.Run("error on attempt to show remote file without config set", func(t *testing.T) {
-		showCommand := &showCommand{remote: true}
+		showCommand := &showCommand{remote: "origin"}
 		assert.Error(t, showCommand.run(nil))
 	})
 	t.Run("error on attempt to show file that isn't set", func(t *testing.T) {
-		showCommand := &showCommand{remote: true}
+		showCommand := &showCommand{remote: "origin"}
 		assert.Error(t, showCommand.run(nil))
 	})
 
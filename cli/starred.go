@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+type starredCommand struct {
+	path     bool
+	remote   string
+	username string
+}
+
+func (sc *starredCommand) run(*kingpin.ParseContext) error {
+	client, err := newDotfileClient("", sc.remote, false)
+	if err != nil {
+		return err
+	}
+	if sc.username != "" {
+		client.Owner = sc.username
+	}
+
+	files, err := client.Starred(sc.path)
+	if err != nil {
+		return remoteError(err)
+	}
+
+	for _, f := range files {
+		fmt.Println(f)
+	}
+
+	return nil
+}
+
+func addStarredSubCommandToApplication(app *kingpin.Application) {
+	sc := new(starredCommand)
+	c := app.Command("starred", "list files starred on remote").Action(sc.run)
+	c.Flag("path", "include path in list").Short('p').BoolVar(&sc.path)
+	c.Flag("remote", "read starred files from the named remote").Short('r').StringVar(&sc.remote)
+	c.Flag("username", "read files starred by username on remote").Short('u').StringVar(&sc.username)
+}
@@ -0,0 +1,14 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStarred(t *testing.T) {
+	t.Run("error on attempt to list starred files without config set", func(t *testing.T) {
+		starredCommand := &starredCommand{remote: "origin"}
+		assert.Error(t, starredCommand.run(nil))
+	})
+}
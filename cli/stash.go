@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+type stashCommand struct {
+	alias   string
+	message string
+}
+
+func (sc *stashCommand) push(*kingpin.ParseContext) error {
+	s, err := loadFile(sc.alias)
+	if err != nil {
+		return err
+	}
+
+	return s.Stash(sc.message)
+}
+
+func (sc *stashCommand) pop(*kingpin.ParseContext) error {
+	s, err := loadFile(sc.alias)
+	if err != nil {
+		return err
+	}
+
+	return s.PopStash()
+}
+
+func (sc *stashCommand) list(*kingpin.ParseContext) error {
+	s, err := loadFile(sc.alias)
+	if err != nil {
+		return err
+	}
+
+	stashes, err := s.Stashes()
+	if err != nil {
+		return err
+	}
+
+	// The most recent stash is first.
+	for i := len(stashes) - 1; i >= 0; i-- {
+		entry := stashes[i]
+		timeStamp := time.Unix(entry.Timestamp, 0).Format(timestampDisplayFormat)
+
+		fmt.Printf("%d: %s on %s", len(stashes)-1-i, timeStamp, dotfile.ShortenHash(entry.Revision))
+		if entry.Message != "" {
+			fmt.Printf(" - %s", entry.Message)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func addStashSubCommandToApplication(app *kingpin.Application) {
+	sc := new(stashCommand)
+
+	c := app.Command("stash", "set aside uncommitted changes")
+
+	push := c.Command("push", "stash uncommitted changes and checkout the current revision").Action(sc.push)
+	push.Arg("alias", "the file to stash").HintAction(flags.defaultAliasList).Required().StringVar(&sc.alias)
+	push.Flag("message", "describe the stashed changes").Short('m').StringVar(&sc.message)
+
+	pop := c.Command("pop", "apply the most recent stash and remove it").Action(sc.pop)
+	pop.Arg("alias", "the file to apply a stash to").HintAction(flags.defaultAliasList).Required().StringVar(&sc.alias)
+
+	list := c.Command("list", "list stashes, the most recent is first").Action(sc.list)
+	list.Arg("alias", "the file to list stashes for").HintAction(flags.defaultAliasList).Required().StringVar(&sc.alias)
+}
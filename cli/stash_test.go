@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStash(t *testing.T) {
+	clearTestStorage(t)
+	initTestFile(t)
+
+	sc := &stashCommand{alias: trackedFileAlias, message: "test"}
+
+	t.Run("error when file is not tracked", func(t *testing.T) {
+		notTracked := &stashCommand{alias: notTrackedFile}
+		assert.Error(t, notTracked.push(nil))
+		assert.Error(t, notTracked.pop(nil))
+		assert.Error(t, notTracked.list(nil))
+	})
+
+	t.Run("error when there are no changes", func(t *testing.T) {
+		assert.Error(t, sc.push(nil))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		updateTestFile(t)
+		assert.NoError(t, sc.push(nil))
+		assert.NoError(t, sc.list(nil))
+		assert.NoError(t, sc.pop(nil))
+		assert.Error(t, sc.pop(nil))
+	})
+}
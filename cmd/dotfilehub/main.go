@@ -28,6 +28,7 @@ func config() server.Config {
 	secure := flag.Bool("secure", false, "Set session cookie to HTTPS only")
 	proxyHeaders := flag.Bool("proxyheaders", false, "Set request IP by inspecting reverse proxy headers")
 	smtpConfigPath := flag.String("smtp-config-path", "", "Sets up a SMTP client for account recovery")
+	resetExpiry := flag.Duration("reset-expiry", server.DefaultResetExpiry, "How long password reset links are valid")
 	flag.Parse()
 
 	return server.Config{
@@ -37,6 +38,7 @@ func config() server.Config {
 		ProxyHeaders:   *proxyHeaders,
 		Host:           *host,
 		SMTPConfigPath: *smtpConfigPath,
+		ResetExpiry:    *resetExpiry,
 	}
 }
 
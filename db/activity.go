@@ -0,0 +1,93 @@
+package db
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Activity is a commit on a file.
+type Activity struct {
+	Username   string
+	Alias      string
+	Path       string
+	Hash       string
+	Message    string
+	NewFile    bool // Whether the commit is the file's first.
+	Timestamp  time.Time
+	DateString string
+}
+
+const activitySelect = `
+SELECT username,
+       alias,
+       path,
+       hash,
+       message,
+       NOT EXISTS(SELECT 1 FROM commits AS older
+                  WHERE older.file_id = commits.file_id AND older.id < commits.id),
+       timestamp
+FROM commits
+JOIN files ON files.id = commits.file_id
+JOIN users ON users.id = files.user_id
+`
+
+func activity(e Executor, timezone *string, query string, args ...interface{}) ([]Activity, error) {
+	var (
+		timestamp int64
+		result    []Activity
+	)
+
+	rows, err := e.Query(activitySelect+query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying activity")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a := Activity{}
+		if err := rows.Scan(
+			&a.Username,
+			&a.Alias,
+			&a.Path,
+			&a.Hash,
+			&a.Message,
+			&a.NewFile,
+			&timestamp,
+		); err != nil {
+			return nil, errors.Wrap(err, "scanning activity")
+		}
+
+		a.Timestamp = time.Unix(timestamp, 0)
+		a.DateString = formatTime(a.Timestamp, timezone)
+		result = append(result, a)
+	}
+
+	return result, rows.Err()
+}
+
+// FollowingActivity returns the n newest commits on public files of the users that userID follows.
+func FollowingActivity(e Executor, userID int64, n int, timezone *string) ([]Activity, error) {
+	return activity(e, timezone, `
+WHERE visibility = 'public'
+AND files.user_id IN (SELECT following_id FROM follows WHERE follower_id = ?)
+ORDER BY timestamp DESC
+LIMIT ?`, userID, n)
+}
+
+// UserActivity returns the n newest commits on username's public files.
+func UserActivity(e Executor, username string, n int, timezone *string) ([]Activity, error) {
+	return activity(e, timezone, `
+WHERE visibility = 'public' AND username = ?
+ORDER BY timestamp DESC
+LIMIT ?`, username, n)
+}
+
+// FileActivity returns the n newest commits on username/alias.
+// Callers must check that the file can be seen.
+func FileActivity(e Executor, username, alias string, n int, timezone *string) ([]Activity, error) {
+	return activity(e, timezone, `
+WHERE username = ? AND alias = ?
+ORDER BY timestamp DESC
+LIMIT ?`, username, alias, n)
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowingActivity(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testMemberID, testMemberName, testMemberEmail)
+	initialCommit, currentCommit := initTestFileAndCommit(t)
+
+	t.Run("empty when not following", func(t *testing.T) {
+		activity, err := FollowingActivity(Connection, testMemberID, 10, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, activity)
+	})
+
+	failIf(t, Follow(Connection, testMemberID, testUsername), "following test user")
+
+	t.Run("newest commits first", func(t *testing.T) {
+		activity, err := FollowingActivity(Connection, testMemberID, 10, nil)
+		assert.NoError(t, err)
+		if assert.Len(t, activity, 2) {
+			assert.Equal(t, currentCommit.Hash, activity[0].Hash)
+			assert.Equal(t, currentCommit.Message, activity[0].Message)
+			assert.False(t, activity[0].NewFile)
+			assert.Equal(t, initialCommit.Hash, activity[1].Hash)
+			assert.True(t, activity[1].NewFile)
+		}
+	})
+
+	t.Run("excludes files that aren't public", func(t *testing.T) {
+		setTestFileVisibility(t, FileVisibilityUnlisted)
+
+		activity, err := FollowingActivity(Connection, testMemberID, 10, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, activity)
+	})
+}
+
+func TestUserActivity(t *testing.T) {
+	createTestDB(t)
+	_, currentCommit := initTestFileAndCommit(t)
+
+	t.Run("user activity", func(t *testing.T) {
+		activity, err := UserActivity(Connection, testUsername, 1, nil)
+		assert.NoError(t, err)
+		if assert.Len(t, activity, 1) {
+			assert.Equal(t, currentCommit.Hash, activity[0].Hash)
+		}
+	})
+
+	t.Run("file activity", func(t *testing.T) {
+		activity, err := FileActivity(Connection, testUsername, testAlias, 10, nil)
+		assert.NoError(t, err)
+		assert.Len(t, activity, 2)
+	})
+
+	t.Run("user activity excludes files that aren't public", func(t *testing.T) {
+		setTestFileVisibility(t, FileVisibilityUnlisted)
+
+		activity, err := UserActivity(Connection, testUsername, 10, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, activity)
+
+		activity, err = FileActivity(Connection, testUsername, testAlias, 10, nil)
+		assert.NoError(t, err)
+		assert.Len(t, activity, 2)
+	})
+}
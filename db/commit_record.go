@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"strings"
 
 	"github.com/knoebber/usererror"
 	"github.com/pkg/errors"
@@ -160,6 +161,45 @@ WHERE username = ? AND alias = ? AND hash = ?`, username, alias, hash).
 	return result, nil
 }
 
+// Revisions gets the compressed revisions of a file at hashes.
+// Hashes that the file does not have are not included in the result.
+func Revisions(e Executor, username, alias string, hashes []string) (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	if len(hashes) == 0 {
+		return result, nil
+	}
+
+	args := []interface{}{username, alias}
+	for _, hash := range hashes {
+		args = append(args, hash)
+	}
+
+	rows, err := e.Query(`
+SELECT hash, revision
+FROM commits
+JOIN files ON commits.file_id = files.id
+JOIN users ON files.user_id = users.id
+WHERE username = ? AND alias = ? AND hash IN (?`+strings.Repeat(", ?", len(hashes)-1)+`)`, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying revisions for %q %q", username, alias)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			hash     string
+			revision []byte
+		)
+
+		if err := rows.Scan(&hash, &revision); err != nil {
+			return nil, errors.Wrapf(err, "scanning revisions for %q %q", username, alias)
+		}
+		result[hash] = revision
+	}
+
+	return result, rows.Err()
+}
+
 // ClearCommits deletes all commits for a file except the current.
 func ClearCommits(tx *sql.Tx, username, alias string) error {
 	file, err := File(tx, username, alias)
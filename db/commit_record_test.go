@@ -61,3 +61,26 @@ func TestCommitRecord_check(t *testing.T) {
 		assert.Error(t, c.check(Connection))
 	})
 }
+
+func TestRevisions(t *testing.T) {
+	createTestDB(t)
+	initialCommit, currentCommit := initTestFileAndCommit(t)
+
+	t.Run("empty", func(t *testing.T) {
+		revisions, err := Revisions(Connection, testUsername, testAlias, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, revisions)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		revisions, err := Revisions(Connection, testUsername, testAlias, []string{
+			initialCommit.Hash,
+			currentCommit.Hash,
+			"missing",
+		})
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+		assert.NotEmpty(t, revisions[initialCommit.Hash])
+		assert.NotEmpty(t, revisions[currentCommit.Hash])
+	})
+}
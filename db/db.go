@@ -70,9 +70,14 @@ func createTables(e Executor) error {
 		new(UserRecord),
 		new(ReservedUsernameRecord),
 		new(SessionRecord),
+		new(TokenRecord),
+		new(OrgMemberRecord),
 		new(FileRecord),
 		new(TempFileRecord),
 		new(CommitRecord),
+		new(ShareRecord),
+		new(StarRecord),
+		new(FollowRecord),
 	} {
 		_, err := e.Exec(model.createStmt())
 		if err != nil {
@@ -204,7 +209,14 @@ func Start(dbPath string) (err error) {
 	}
 
 	validate = validator.New()
-	return createTables(Connection)
+	if err = createTables(Connection); err != nil {
+		return err
+	}
+	if err = migrate(Connection); err != nil {
+		return err
+	}
+
+	return createSearchIndex(Connection)
 }
 
 // Close closes the connection.
@@ -11,6 +11,30 @@ import (
 	"github.com/pkg/errors"
 )
 
+// FileVisibility controls who can see a file.
+type FileVisibility string
+
+// Valid values for FileVisibility.
+const (
+	FileVisibilityPublic   FileVisibility = "public"   // Listed in search and feeds.
+	FileVisibilityUnlisted FileVisibility = "unlisted" // Readable by anyone with the URL.
+	FileVisibilityPrivate  FileVisibility = "private"  // Readable by the owner only.
+)
+
+// FileVisibilities are all of the visibilities that a file may have.
+var FileVisibilities = []FileVisibility{FileVisibilityPublic, FileVisibilityUnlisted, FileVisibilityPrivate}
+
+// CheckFileVisibility returns a usererror when visibility is invalid.
+func CheckFileVisibility(visibility FileVisibility) error {
+	for _, v := range FileVisibilities {
+		if v == visibility {
+			return nil
+		}
+	}
+
+	return usererror.Format("Visibility %q is invalid.", visibility)
+}
+
 // FileRecord models the files table.
 // It stores the contents of a file at the current revision hash.
 //
@@ -21,6 +45,7 @@ type FileRecord struct {
 	Alias           string `validate:"required"` // Friendly name for a file: bashrc
 	Path            string `validate:"required"` // Where the file lives: ~/.bashrc
 	CurrentCommitID *int64 // The commit that the file is at.
+	Visibility      FileVisibility
 }
 
 // Unique indexes prevent a user from having duplicate alias / path.
@@ -32,6 +57,7 @@ user_id            INTEGER NOT NULL REFERENCES users,
 alias              TEXT NOT NULL COLLATE NOCASE,
 path               TEXT NOT NULL COLLATE NOCASE,
 current_commit_id  INTEGER REFERENCES commits,
+visibility         TEXT NOT NULL DEFAULT 'public',
 created_at         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 updated_at         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
@@ -48,6 +74,12 @@ func (f *FileRecord) check(e Executor) error {
 	if err := checkFile(f.Alias, f.Path); err != nil {
 		return err
 	}
+	if f.Visibility == "" {
+		f.Visibility = FileVisibilityPublic
+	}
+	if err := CheckFileVisibility(f.Visibility); err != nil {
+		return err
+	}
 	if err := ValidateFileNotExists(e, f.UserID, f.Alias, f.Path); err != nil {
 		return err
 	}
@@ -66,11 +98,12 @@ func (f *FileRecord) check(e Executor) error {
 
 func (f *FileRecord) insertStmt(e Executor) (sql.Result, error) {
 	return e.Exec(`
-INSERT INTO files(user_id, alias, path, current_commit_id) VALUES(?, ?, ?, ?)`,
+INSERT INTO files(user_id, alias, path, current_commit_id, visibility) VALUES(?, ?, ?, ?, ?)`,
 		f.UserID,
 		strings.ToLower(f.Alias),
 		f.Path,
 		f.CurrentCommitID,
+		f.Visibility,
 	)
 }
 
@@ -95,7 +128,7 @@ WHERE id = ?
 		return errors.Wrapf(err, "updating file %d to %q %q", f.ID, newAlias, newPath)
 	}
 
-	return nil
+	return indexFile(e, f.ID)
 }
 
 // DeleteFile deletes a users file.
@@ -125,6 +158,20 @@ WHERE id IN (SELECT forked.id
 		return errors.Wrapf(err, "deleting commits for %q %q", username, alias)
 	}
 
+	_, err = tx.Exec("DELETE FROM stars WHERE file_id = ?", record.ID)
+	if err != nil {
+		return errors.Wrapf(err, "deleting stars for %q %q", username, alias)
+	}
+
+	_, err = tx.Exec("DELETE FROM shares WHERE file_id = ?", record.ID)
+	if err != nil {
+		return errors.Wrapf(err, "deleting shares for %q %q", username, alias)
+	}
+
+	if err := unindexFile(tx, record.ID); err != nil {
+		return err
+	}
+
 	_, err = tx.Exec("DELETE FROM files WHERE id = ?", record.ID)
 	if err != nil {
 		return errors.Wrapf(err, "deleting file %q %q", username, alias)
@@ -142,7 +189,8 @@ SELECT files.id,
        user_id, 
        alias, 
        path, 
-       current_commit_id
+       current_commit_id,
+       visibility
 FROM files 
 JOIN users ON user_id = users.id 
 WHERE username = ? AND alias = ?`, username, alias).
@@ -152,6 +200,7 @@ WHERE username = ? AND alias = ?`, username, alias).
 			&record.Alias,
 			&record.Path,
 			&record.CurrentCommitID,
+			&record.Visibility,
 		)
 	if err != nil {
 		return nil, errors.Wrapf(err, "querying file for %q %q", username, alias)
@@ -160,6 +209,51 @@ WHERE username = ? AND alias = ?`, username, alias).
 	return record, nil
 }
 
+// SetVisibility updates who can see the file.
+func (f *FileRecord) SetVisibility(e Executor, visibility FileVisibility) error {
+	if err := CheckFileVisibility(visibility); err != nil {
+		return err
+	}
+
+	_, err := e.Exec("UPDATE files SET visibility = ? WHERE id = ?", visibility, f.ID)
+	if err != nil {
+		return errors.Wrapf(err, "setting file %d visibility to %q", f.ID, visibility)
+	}
+
+	f.Visibility = visibility
+	return nil
+}
+
+// FileAccess checks that the user viewerID can see a file.
+// Private files are reported as not found to everyone except their owner.
+// Every member of an organization can see its private files.
+// Returns the file's visibility on success.
+func FileAccess(e Executor, username, alias string, viewerID int64) (FileVisibility, error) {
+	var (
+		ownerID    int64
+		visibility FileVisibility
+		member     bool
+	)
+
+	err := e.QueryRow(`
+SELECT user_id,
+       visibility,
+       EXISTS(SELECT 1 FROM org_members WHERE org_id = files.user_id AND org_members.user_id = ?)
+FROM files
+JOIN users ON user_id = users.id
+WHERE username = ? AND alias = ?`, viewerID, username, alias).
+		Scan(&ownerID, &visibility, &member)
+	if err != nil {
+		return "", errors.Wrapf(err, "querying file access for %q %q", username, alias)
+	}
+
+	if visibility == FileVisibilityPrivate && ownerID != viewerID && !member {
+		return "", errors.Wrapf(sql.ErrNoRows, "file %q %q is private", username, alias)
+	}
+
+	return visibility, nil
+}
+
 // FileData returns the files dotfile data structure.
 func FileData(e Executor, username, alias string) (*dotfile.TrackingData, error) {
 	var (
@@ -241,8 +335,12 @@ WHERE id = (SELECT file_id FROM new_commit)
 		return fmt.Errorf("commit %q %q %q not found", username, alias, hash)
 	}
 
-	return nil
+	record, err := File(e, username, alias)
+	if err != nil {
+		return err
+	}
 
+	return indexFile(e, record.ID)
 }
 
 // ForkFile creates a copy of username/alias/hash for the user newUserID.
@@ -331,5 +429,5 @@ WHERE id = ?
 		return errors.Wrapf(err, "updating content in file %d", fileID)
 	}
 
-	return nil
+	return indexFile(e, fileID)
 }
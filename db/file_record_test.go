@@ -107,3 +107,57 @@ func TestSetFileToHash(t *testing.T) {
 		assert.Equal(t, initial.Hash, f.Hash)
 	})
 }
+
+func TestFileAccess(t *testing.T) {
+	otherUserID := int64(testUserID + 1)
+	createTestDB(t)
+	createTestUser(t, otherUserID, "user2", "user2@example.com")
+	initTestFile(t)
+
+	record, err := File(Connection, testUsername, testAlias)
+	failIf(t, err, "getting test file")
+	assert.Equal(t, FileVisibilityPublic, record.Visibility)
+
+	t.Run("error on invalid visibility", func(t *testing.T) {
+		assertUsererror(t, record.SetVisibility(Connection, "secret"))
+	})
+
+	t.Run("unlisted files are visible", func(t *testing.T) {
+		failIf(t, record.SetVisibility(Connection, FileVisibilityUnlisted))
+		visibility, err := FileAccess(Connection, testUsername, testAlias, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, FileVisibilityUnlisted, visibility)
+	})
+
+	t.Run("private files are only visible to the owner", func(t *testing.T) {
+		failIf(t, record.SetVisibility(Connection, FileVisibilityPrivate))
+
+		_, err := FileAccess(Connection, testUsername, testAlias, otherUserID)
+		assert.True(t, NotFound(err))
+		_, err = FileAccess(Connection, testUsername, testAlias, 0)
+		assert.True(t, NotFound(err))
+
+		visibility, err := FileAccess(Connection, testUsername, testAlias, testUserID)
+		assert.NoError(t, err)
+		assert.Equal(t, FileVisibilityPrivate, visibility)
+	})
+}
+
+func TestFilesByUsername(t *testing.T) {
+	createTestDB(t)
+	initTestFile(t)
+
+	record, err := File(Connection, testUsername, testAlias)
+	failIf(t, err, "getting test file")
+	failIf(t, record.SetVisibility(Connection, FileVisibilityUnlisted))
+
+	files, err := FilesByUsername(Connection, testUsername, 0, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	files, err = FilesByUsername(Connection, testUsername, testUserID, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, FileVisibilityUnlisted, files[0].Visibility)
+	}
+}
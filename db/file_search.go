@@ -9,9 +9,30 @@ import (
 )
 
 const (
-	fileSearchSelect = "SELECT alias, path, username, updated_at"
-	fileSearchBody   = " FROM users JOIN files ON user_id = users.id"
-	fileSearchWhere  = " WHERE alias LIKE ? OR path LIKE ?"
+	fileSearchColumns = `
+SELECT files.alias,
+       files.path,
+       username,
+       updated_at,
+       (SELECT COUNT(*) FROM stars WHERE file_id = files.id),
+       (SELECT COUNT(DISTINCT forks.file_id)
+        FROM commits AS upstream
+        JOIN commits AS forks ON forks.forked_from = upstream.id
+        JOIN files AS fork_files ON fork_files.id = forks.file_id
+        WHERE upstream.file_id = files.id AND fork_files.visibility = 'public')`
+	fileSearchSelect = fileSearchColumns + ", ''"
+	fileSearchBody   = " FROM users JOIN files ON user_id = users.id WHERE visibility = 'public'"
+	fileSearchWhere  = " AND (alias LIKE ? OR path LIKE ?)"
+
+	// Full text search selects a snippet of the matched content.
+	// Matches in the alias and path rank higher than matches in the content.
+	indexSearchSelect = fileSearchColumns + ", snippet(file_search, 2, char(2), char(3), '...', 16)"
+	indexSearchBody   = `
+FROM users
+JOIN files ON user_id = users.id
+JOIN file_search ON file_search.rowid = files.id
+WHERE visibility = 'public' AND file_search MATCH ?`
+	indexSearchRank = "bm25(file_search, 10.0, 5.0, 1.0)"
 )
 
 // FileSearchResult is the result of a file search.
@@ -21,6 +42,9 @@ type FileSearchResult struct {
 	Path            string
 	UpdatedAtString string
 	UpdatedAt       time.Time
+	Stars           int
+	Forks           int    // The amount of public forks.
+	Snippet         string // Matched content surrounded by SnippetMatchStart and SnippetMatchEnd.
 }
 
 func scanFileSearchResult(rows *sql.Rows, timezone *string) (FileSearchResult, error) {
@@ -31,6 +55,9 @@ func scanFileSearchResult(rows *sql.Rows, timezone *string) (FileSearchResult, e
 		&result.Path,
 		&result.Username,
 		&result.UpdatedAt,
+		&result.Stars,
+		&result.Forks,
+		&result.Snippet,
 	); err != nil {
 		return result, errors.Wrap(err, "scanning file for file search")
 	}
@@ -39,7 +66,7 @@ func scanFileSearchResult(rows *sql.Rows, timezone *string) (FileSearchResult, e
 	return result, nil
 }
 
-// FileFeed returns n of the most recently updated files.
+// FileFeed returns n of the most recently updated public files.
 func FileFeed(e Executor, n int, timezone *string) ([]FileSearchResult, error) {
 	var result []FileSearchResult
 
@@ -62,26 +89,78 @@ func FileFeed(e Executor, n int, timezone *string) ([]FileSearchResult, error) {
 	return result, nil
 }
 
-// SearchFiles looks for files by their alias or path.
+// SearchFiles looks for public files and returns them in a table.
+// See SearchFileResults.
 func SearchFiles(e Executor, controls *PageControls, timezone *string) (*HTMLTable, error) {
 	res := &HTMLTable{
-		Columns:  []string{"Alias", "Path", "Username", "Updated At"},
+		Columns:  []string{"Alias", "Path", "Username", "Updated At", "Stars", "Forks"},
 		Controls: controls,
 	}
+
+	results, err := SearchFileResults(e, controls, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		res.Rows = append(res.Rows, r)
+	}
+
+	return res, nil
+}
+
+// SearchFileResults looks for public files that match the query in controls.
+// Files are matched by their alias, path, and content when the search index is enabled.
+// Otherwise only the alias and path are matched.
+// The query can also have filters, see searchFilter.
+func SearchFileResults(e Executor, controls *PageControls, timezone *string) ([]FileSearchResult, error) {
+	var (
+		args                    []interface{}
+		selectStmt, body, order string
+		result                  []FileSearchResult
+	)
+
 	if controls.query == "" {
-		return res, nil
+		return nil, nil
 	}
-	q := "%" + controls.query + "%"
-	countQuery := "SELECT COUNT(*) " + fileSearchBody + fileSearchWhere
+
+	filter, err := parseSearchQuery(controls.query)
+	if err != nil {
+		return nil, err
+	}
+	if filter.sortBy != 0 && controls.Values.Get("ob") == "" {
+		controls.orderBy, controls.order = filter.sortBy, "desc"
+	}
+
+	if filter.text == "" {
+		selectStmt, body = fileSearchSelect, fileSearchBody
+		order = controls.sqlSuffix()
+	} else if searchIndexEnabled {
+		args = []interface{}{searchIndexQuery(filter.text)}
+		selectStmt, body = indexSearchSelect, indexSearchBody
+		if filter.sortBy == 0 {
+			order = controls.rankedSQLSuffix(indexSearchRank)
+		} else {
+			order = controls.sqlSuffix()
+		}
+	} else {
+		q := "%" + filter.text + "%"
+		args = []interface{}{q, q}
+		selectStmt, body = fileSearchSelect, fileSearchBody+fileSearchWhere
+		order = controls.sqlSuffix()
+	}
+
+	where, filterArgs := filter.where()
+	body += where
+	args = append(args, filterArgs...)
 
 	// Count the total rows and scan it into the page Controls.
-	err := e.QueryRow(countQuery, q, q).Scan(&controls.totalRows)
+	err = e.QueryRow("SELECT COUNT(*) "+body, args...).Scan(&controls.totalRows)
 	if err != nil {
 		return nil, errors.Wrap(err, "counting rows for file search")
 	}
 
-	query := fileSearchSelect + fileSearchBody + fileSearchWhere + controls.sqlSuffix()
-	rows, err := e.Query(query, q, q)
+	rows, err := e.Query(selectStmt+body+order, args...)
 	if err != nil {
 		return nil, errors.Wrap(err, "file search Query")
 	}
@@ -92,8 +171,8 @@ func SearchFiles(e Executor, controls *PageControls, timezone *string) (*HTMLTab
 			return nil, err
 		}
 
-		res.Rows = append(res.Rows, current)
+		result = append(result, current)
 	}
 
-	return res, nil
+	return result, rows.Err()
 }
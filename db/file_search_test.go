@@ -1,9 +1,10 @@
 package db
 
 import (
-	"github.com/stretchr/testify/assert"
 	"net/url"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSearch(t *testing.T) {
@@ -36,6 +37,51 @@ func TestSearch(t *testing.T) {
 			assert.NotEmpty(t, table.Rows)
 			assert.NotEmpty(t, table.TotalRows())
 		})
+
+		t.Run("excludes files that aren't public", func(t *testing.T) {
+			setTestFileVisibility(t, FileVisibilityUnlisted)
+			defer setTestFileVisibility(t, FileVisibilityPublic)
+
+			table, err := SearchFiles(Connection, controls, nil)
+			assert.NoError(t, err)
+			assert.Empty(t, table.Rows)
+			assert.Empty(t, table.TotalRows())
+		})
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		createTestUser(t, testMemberID, testMemberName, testMemberEmail)
+		f, err := UncompressFile(Connection, testUsername, testAlias)
+		failIf(t, err)
+		failIf(t, ForkFile(testUsername, testAlias, f.Hash, testMemberID))
+
+		search := func(q string) []FileSearchResult {
+			controls := &PageControls{Values: url.Values{"q": {q}}}
+			failIf(t, controls.Set())
+
+			results, err := SearchFileResults(Connection, controls, nil)
+			assert.NoError(t, err)
+			return results
+		}
+
+		assert.Len(t, search("user:"+testUsername), 1)
+		assert.Len(t, search("alias:"+testAlias), 2)
+		assert.Empty(t, search("alias:missing"))
+		assert.Len(t, search("path:~/dotfile/*"), 2)
+		assert.Empty(t, search("path:~/.config/*"))
+		assert.Len(t, search("updated:>2020-01-01"), 2)
+		assert.Empty(t, search("updated:<2020-01-01"))
+
+		results := search("alias:" + testAlias + " sort:forks")
+		if assert.Len(t, results, 2) {
+			assert.Equal(t, testUsername, results[0].Username)
+			assert.Equal(t, 1, results[0].Forks)
+		}
+
+		controls := &PageControls{Values: url.Values{"q": {"sort:likes"}}}
+		failIf(t, controls.Set())
+		_, err = SearchFileResults(Connection, controls, nil)
+		assertUsererror(t, err)
 	})
 }
 
@@ -55,4 +101,17 @@ func TestFileFeed(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotEmpty(t, res)
 	})
+
+	t.Run("excludes files that aren't public", func(t *testing.T) {
+		setTestFileVisibility(t, FileVisibilityPrivate)
+		res, err := FileFeed(Connection, 1, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, res)
+	})
+}
+
+func setTestFileVisibility(t *testing.T, visibility FileVisibility) {
+	record, err := File(Connection, testUsername, testAlias)
+	failIf(t, err, "getting test file")
+	failIf(t, record.SetVisibility(Connection, visibility), "setting test file visibility")
 }
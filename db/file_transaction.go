@@ -98,6 +98,12 @@ func (ft *FileTransaction) SaveFile(userID int64, alias, path string) error {
 	return nil
 }
 
+// SetVisibility updates who can see the file.
+func (ft *FileTransaction) SetVisibility(visibility FileVisibility) error {
+	f := &FileRecord{ID: ft.FileID}
+	return f.SetVisibility(ft.tx, visibility)
+}
+
 // HasCommit returns whether the file has a commit with hash.
 func (ft *FileTransaction) HasCommit(hash string) (exists bool, err error) {
 	exists, err = hasCommit(ft.tx, ft.FileID, hash)
@@ -144,7 +150,13 @@ func (ft *FileTransaction) SaveCommit(buff *bytes.Buffer, c *dotfile.Commit) err
 		return err
 	}
 
-	return setFileToCommitID(ft.tx, ft.FileID, newCommitID)
+	if err := setFileToCommitID(ft.tx, ft.FileID, newCommitID); err != nil {
+		return err
+	}
+
+	ft.CurrentCommitID = newCommitID
+	ft.Hash = c.Hash
+	return nil
 }
 
 // Revision returns the compressed content at hash.
@@ -162,7 +174,13 @@ func (ft *FileTransaction) SetRevision(hash string) error {
 		return errors.Wrapf(err, "setting file %d to revision %q", ft.FileID, hash)
 	}
 
-	return setFileToCommitID(ft.tx, ft.FileID, newCommitID)
+	if err := setFileToCommitID(ft.tx, ft.FileID, newCommitID); err != nil {
+		return err
+	}
+
+	ft.CurrentCommitID = newCommitID
+	ft.Hash = hash
+	return nil
 }
 
 // InitOrCommit uses the content in a temp file to initialize a file or create a new commit.
@@ -21,6 +21,7 @@ type FileSummary struct {
 	Path       string
 	NumCommits int
 	UpdatedAt  string
+	Visibility FileVisibility
 }
 
 func (fv *FileView) scan(row *sql.Row) error {
@@ -30,6 +31,7 @@ func (fv *FileView) scan(row *sql.Row) error {
 		&fv.Alias,
 		&fv.Path,
 		&fv.CurrentCommitID,
+		&fv.Visibility,
 		&fv.Content,
 		&fv.Hash,
 	); err != nil {
@@ -54,6 +56,7 @@ SELECT files.id,
        files.alias,
        files.path,
        files.current_commit_id,
+       files.visibility,
        commits.revision,
        commits.hash
 FROM files
@@ -69,8 +72,9 @@ WHERE username = ? AND alias = ?
 	return fv, nil
 }
 
-// FilesByUsername returns all of a users files.
-func FilesByUsername(e Executor, username string, timezone *string) ([]FileSummary, error) {
+// FilesByUsername returns a users files.
+// Only public files are returned unless viewerID is the user or a member of the organization.
+func FilesByUsername(e Executor, username string, viewerID int64, timezone *string) ([]FileSummary, error) {
 	var (
 		updatedAt time.Time
 		result    []FileSummary
@@ -82,13 +86,15 @@ func FilesByUsername(e Executor, username string, timezone *string) ([]FileSumma
 SELECT alias,
        path,
        COUNT(commits.id) AS num_commits,
-       updated_at
+       updated_at,
+       visibility
 FROM users
 JOIN files ON user_id = users.id
 LEFT JOIN commits ON file_id = files.id
-WHERE username = ?
+WHERE username = ? AND (visibility = 'public' OR users.id = ? OR EXISTS(
+  SELECT 1 FROM org_members WHERE org_id = users.id AND org_members.user_id = ?))
 GROUP BY files.id
-ORDER BY alias`, username)
+ORDER BY alias`, username, viewerID, viewerID)
 	if err != nil {
 		return nil, errors.Wrapf(err, "querying user %q files", username)
 	}
@@ -100,6 +106,7 @@ ORDER BY alias`, username)
 			&f.Path,
 			&f.NumCommits,
 			&updatedAt,
+			&f.Visibility,
 		); err != nil {
 			return nil, errors.Wrapf(err, "scanning files for user %q", username)
 		}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/knoebber/usererror"
+	"github.com/pkg/errors"
+)
+
+// FollowRecord models the follows table.
+// Users follow other users to see their activity in a personal feed.
+type FollowRecord struct {
+	ID          int64
+	FollowerID  int64 `validate:"required"`
+	FollowingID int64 `validate:"required"`
+	CreatedAt   time.Time
+}
+
+func (*FollowRecord) createStmt() string {
+	return `
+CREATE TABLE IF NOT EXISTS follows(
+id           INTEGER PRIMARY KEY,
+follower_id  INTEGER NOT NULL REFERENCES users,
+following_id INTEGER NOT NULL REFERENCES users,
+created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS follows_follower_following_index ON follows(follower_id, following_id);
+CREATE INDEX IF NOT EXISTS follows_following_index ON follows(following_id);`
+}
+
+func (f *FollowRecord) insertStmt(e Executor) (sql.Result, error) {
+	return e.Exec("INSERT INTO follows(follower_id, following_id) VALUES(?, ?)", f.FollowerID, f.FollowingID)
+}
+
+func (f *FollowRecord) check(e Executor) error {
+	if f.FollowerID == f.FollowingID {
+		return usererror.New("You can't follow yourself.")
+	}
+
+	return nil
+}
+
+// Follow makes followerID follow username.
+// Following a user that is already followed does nothing.
+func Follow(e Executor, followerID int64, username string) error {
+	var followingID int64
+
+	following, err := IsFollowing(e, followerID, username)
+	if err != nil || following {
+		return err
+	}
+
+	err = e.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&followingID)
+	if err != nil {
+		return errors.Wrapf(err, "querying id for user %q", username)
+	}
+
+	_, err = insert(e, &FollowRecord{FollowerID: followerID, FollowingID: followingID})
+	return err
+}
+
+// Unfollow makes followerID stop following username.
+func Unfollow(e Executor, followerID int64, username string) error {
+	_, err := e.Exec(`
+DELETE FROM follows
+WHERE follower_id = ? AND following_id = (SELECT id FROM users WHERE username = ?)`, followerID, username)
+	if err != nil {
+		return errors.Wrapf(err, "unfollowing %q for user %d", username, followerID)
+	}
+
+	return nil
+}
+
+// IsFollowing returns whether followerID follows username.
+func IsFollowing(e Executor, followerID int64, username string) (bool, error) {
+	var count int
+
+	err := e.QueryRow(`
+SELECT COUNT(*)
+FROM follows
+JOIN users ON users.id = following_id
+WHERE follower_id = ? AND username = ?`, followerID, username).Scan(&count)
+	if err != nil {
+		return false, errors.Wrapf(err, "checking if user %d follows %q", followerID, username)
+	}
+
+	return count > 0, nil
+}
+
+// Followers returns the amount of users that follow username.
+func Followers(e Executor, username string) (count int, err error) {
+	err = e.QueryRow(`
+SELECT COUNT(*)
+FROM follows
+JOIN users ON users.id = following_id
+WHERE username = ?`, username).Scan(&count)
+	if err != nil {
+		err = errors.Wrapf(err, "counting followers of %q", username)
+	}
+
+	return
+}
+
+// Following returns the usernames that userID follows.
+func Following(e Executor, userID int64) ([]string, error) {
+	var result []string
+
+	rows, err := e.Query(`
+SELECT username
+FROM follows
+JOIN users ON users.id = following_id
+WHERE follower_id = ?
+ORDER BY username`, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying users followed by %d", userID)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, errors.Wrapf(err, "scanning users followed by %d", userID)
+		}
+
+		result = append(result, username)
+	}
+
+	return result, rows.Err()
+}
+
+func deleteFollows(e Executor, userID int64) error {
+	if _, err := e.Exec("DELETE FROM follows WHERE follower_id = ? OR following_id = ?", userID, userID); err != nil {
+		return errors.Wrapf(err, "deleting follows for user %d", userID)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollow(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testUserID, testUsername, testEmail)
+	createTestUser(t, testMemberID, testMemberName, testMemberEmail)
+
+	t.Run("error when following yourself", func(t *testing.T) {
+		assertUsererror(t, Follow(Connection, testUserID, testUsername))
+	})
+
+	t.Run("error when user doesn't exist", func(t *testing.T) {
+		assert.True(t, NotFound(Follow(Connection, testUserID, "missing")))
+	})
+
+	t.Run("follow is counted once", func(t *testing.T) {
+		assert.NoError(t, Follow(Connection, testUserID, testMemberName))
+		assert.NoError(t, Follow(Connection, testUserID, testMemberName))
+
+		following, err := IsFollowing(Connection, testUserID, testMemberName)
+		assert.NoError(t, err)
+		assert.True(t, following)
+
+		count, err := Followers(Connection, testMemberName)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		usernames, err := Following(Connection, testUserID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{testMemberName}, usernames)
+	})
+
+	t.Run("unfollow", func(t *testing.T) {
+		assert.NoError(t, Unfollow(Connection, testUserID, testMemberName))
+
+		following, err := IsFollowing(Connection, testUserID, testMemberName)
+		assert.NoError(t, err)
+		assert.False(t, following)
+	})
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ForkSummary is a file that was forked from another file.
+type ForkSummary struct {
+	Username   string
+	Alias      string
+	Path       string
+	Visibility FileVisibility
+	UpdatedAt  string
+}
+
+// UpstreamFile is the file that a fork was forked from.
+type UpstreamFile struct {
+	Username  string
+	Alias     string
+	Hash      string // The upstream's current hash.
+	ForkHash  string // The newest upstream hash that the fork has.
+	Ahead     int    // The amount of upstream commits newer than ForkHash that the fork doesn't have.
+	timestamp int64  // The timestamp of ForkHash.
+	fileID    int64
+}
+
+// Forks returns the files that were forked from username/alias.
+// Only public forks are returned unless viewerID owns them or is a member of their organization.
+func Forks(e Executor, username, alias string, viewerID int64, timezone *string) ([]ForkSummary, error) {
+	var (
+		updatedAt time.Time
+		result    []ForkSummary
+	)
+
+	rows, err := e.Query(`
+SELECT users.username,
+       files.alias,
+       files.path,
+       files.visibility,
+       files.updated_at
+FROM files
+JOIN users ON users.id = files.user_id
+WHERE files.id IN (SELECT forks.file_id
+                   FROM commits AS forks
+                   JOIN commits AS upstream ON upstream.id = forks.forked_from
+                   JOIN files AS upstream_files ON upstream_files.id = upstream.file_id
+                   JOIN users AS upstream_users ON upstream_users.id = upstream_files.user_id
+                   WHERE upstream_users.username = ? AND upstream_files.alias = ?)
+AND (files.visibility = 'public' OR files.user_id = ? OR EXISTS(
+  SELECT 1 FROM org_members WHERE org_id = files.user_id AND org_members.user_id = ?))
+ORDER BY files.updated_at DESC`, username, alias, viewerID, viewerID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying forks of %q %q", username, alias)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		f := ForkSummary{}
+		if err := rows.Scan(&f.Username, &f.Alias, &f.Path, &f.Visibility, &updatedAt); err != nil {
+			return nil, errors.Wrapf(err, "scanning forks of %q %q", username, alias)
+		}
+
+		f.UpdatedAt = formatTime(updatedAt, timezone)
+		result = append(result, f)
+	}
+
+	return result, rows.Err()
+}
+
+// Upstream returns the file that username/alias was forked from.
+// Returns sql.ErrNoRows when the file is not a fork or viewerID can't see the upstream.
+func Upstream(e Executor, username, alias string, viewerID int64) (*UpstreamFile, error) {
+	var forkID int64
+
+	result := new(UpstreamFile)
+
+	// The newest forked commit is the last sync with upstream.
+	err := e.QueryRow(`
+SELECT files.id,
+       upstream_users.username,
+       upstream_files.id,
+       upstream_files.alias,
+       upstream_current.hash,
+       upstream.hash,
+       upstream.timestamp
+FROM commits
+JOIN files ON files.id = commits.file_id
+JOIN users ON users.id = files.user_id
+JOIN commits AS upstream ON upstream.id = commits.forked_from
+JOIN files AS upstream_files ON upstream_files.id = upstream.file_id
+JOIN users AS upstream_users ON upstream_users.id = upstream_files.user_id
+JOIN commits AS upstream_current ON upstream_current.id = upstream_files.current_commit_id
+WHERE users.username = ? AND files.alias = ?
+ORDER BY commits.id DESC
+LIMIT 1`, username, alias).Scan(
+		&forkID,
+		&result.Username,
+		&result.fileID,
+		&result.Alias,
+		&result.Hash,
+		&result.ForkHash,
+		&result.timestamp,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying upstream of %q %q", username, alias)
+	}
+
+	if _, err := FileAccess(e, result.Username, result.Alias, viewerID); err != nil {
+		return nil, err
+	}
+
+	err = e.QueryRow(`
+SELECT COUNT(*)
+FROM commits
+WHERE file_id = ? AND timestamp > ? AND hash NOT IN (SELECT hash FROM commits WHERE file_id = ?)`,
+		result.fileID, result.timestamp, forkID).Scan(&result.Ahead)
+	if err != nil {
+		return nil, errors.Wrapf(err, "counting upstream commits for %q %q", username, alias)
+	}
+
+	return result, nil
+}
+
+// SyncFork imports the commits that upstream gained since username/alias last synced.
+// The newest imported commit becomes the fork's current revision.
+// Returns the amount of commits imported.
+func SyncFork(username, alias string) (int, error) {
+	tx, err := Connection.Begin()
+	if err != nil {
+		return 0, errors.Wrap(err, "starting sync fork transaction")
+	}
+
+	count, err := syncFork(tx, username, alias)
+	if err != nil {
+		return 0, Rollback(tx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "committing sync fork transaction")
+	}
+
+	return count, nil
+}
+
+func syncFork(tx *sql.Tx, username, alias string) (int, error) {
+	var newCommits []*CommitRecord
+
+	fork, err := File(tx, username, alias)
+	if err != nil {
+		return 0, err
+	}
+
+	// The fork's owner can sync as long as they could fork.
+	upstream, err := Upstream(tx, username, alias, fork.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query(`
+SELECT id, hash, message, revision, timestamp
+FROM commits
+WHERE file_id = ? AND timestamp > ? AND hash NOT IN (SELECT hash FROM commits WHERE file_id = ?)
+ORDER BY timestamp`, upstream.fileID, upstream.timestamp, fork.ID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "querying upstream commits for %q %q", username, alias)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var forkedFrom int64
+
+		c := &CommitRecord{FileID: fork.ID}
+		if err := rows.Scan(&forkedFrom, &c.Hash, &c.Message, &c.Revision, &c.Timestamp); err != nil {
+			return 0, errors.Wrapf(err, "scanning upstream commits for %q %q", username, alias)
+		}
+
+		c.ForkedFrom = &forkedFrom
+		newCommits = append(newCommits, c)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, errors.Wrapf(err, "reading upstream commits for %q %q", username, alias)
+	}
+	if len(newCommits) == 0 {
+		return 0, nil
+	}
+
+	for _, c := range newCommits {
+		if err := c.create(tx); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := setFileToCommitID(tx, fork.ID, newCommits[len(newCommits)-1].ID); err != nil {
+		return 0, err
+	}
+
+	return len(newCommits), nil
+}
+
+// ForkContent implements dotfile.Getter for a fork and its upstream.
+// Revisions are looked up on the fork first.
+type ForkContent struct {
+	Fork     FileContent
+	Upstream FileContent
+}
+
+// DirtyContent always returns nil; forks are only compared at commits.
+func (fc *ForkContent) DirtyContent() ([]byte, error) {
+	return nil, nil
+}
+
+// Revision returns the compressed content at hash from the fork or the upstream.
+func (fc *ForkContent) Revision(hash string) ([]byte, error) {
+	revision, err := fc.Fork.Revision(hash)
+	if NotFound(err) {
+		revision, err = fc.Upstream.Revision(hash)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "revision %q not found in fork or upstream", hash)
+	}
+
+	return revision, nil
+}
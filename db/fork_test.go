@@ -0,0 +1,79 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForks(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testMemberID, testMemberName, testMemberEmail)
+	initialCommit, currentCommit := initTestFileAndCommit(t)
+	failIf(t, ForkFile(testUsername, testAlias, initialCommit.Hash, testMemberID), "forking test file")
+
+	t.Run("error when file is not a fork", func(t *testing.T) {
+		_, err := Upstream(Connection, testUsername, testAlias, testUserID)
+		assert.True(t, NotFound(err))
+	})
+
+	t.Run("forks", func(t *testing.T) {
+		forks, err := Forks(Connection, testUsername, testAlias, 0, nil)
+		assert.NoError(t, err)
+		if assert.Len(t, forks, 1) {
+			assert.Equal(t, testMemberName, forks[0].Username)
+		}
+	})
+
+	t.Run("upstream is ahead", func(t *testing.T) {
+		upstream, err := Upstream(Connection, testMemberName, testAlias, testMemberID)
+		assert.NoError(t, err)
+		assert.Equal(t, testUsername, upstream.Username)
+		assert.Equal(t, initialCommit.Hash, upstream.ForkHash)
+		assert.Equal(t, currentCommit.Hash, upstream.Hash)
+		assert.Equal(t, 1, upstream.Ahead)
+	})
+
+	t.Run("diff against upstream", func(t *testing.T) {
+		content := &ForkContent{
+			Fork:     FileContent{Connection: Connection, Username: testMemberName, Alias: testAlias},
+			Upstream: FileContent{Connection: Connection, Username: testUsername, Alias: testAlias},
+		}
+		_, err := dotfile.Diff(content, initialCommit.Hash, currentCommit.Hash, dotfile.DiffOptions{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		count, err := SyncFork(testMemberName, testAlias)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		f, err := UncompressFile(Connection, testMemberName, testAlias)
+		assert.NoError(t, err)
+		assert.Equal(t, currentCommit.Hash, f.Hash)
+
+		upstream, err := Upstream(Connection, testMemberName, testAlias, testMemberID)
+		assert.NoError(t, err)
+		assert.Equal(t, currentCommit.Hash, upstream.ForkHash)
+		assert.Zero(t, upstream.Ahead)
+
+		count, err = SyncFork(testMemberName, testAlias)
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("private forks are hidden", func(t *testing.T) {
+		fork, err := File(Connection, testMemberName, testAlias)
+		failIf(t, err)
+		failIf(t, fork.SetVisibility(Connection, FileVisibilityPrivate))
+
+		forks, err := Forks(Connection, testUsername, testAlias, testUserID, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, forks)
+
+		forks, err = Forks(Connection, testUsername, testAlias, testMemberID, nil)
+		assert.NoError(t, err)
+		assert.Len(t, forks, 1)
+	})
+}
@@ -72,6 +72,7 @@ func testUserList(t *testing.T) (usernames []string) {
 }
 
 func resetTestDB(t *testing.T) {
+	deleteTestOrgs(t)
 	usernames := testUserList(t)
 
 	for _, u := range usernames {
@@ -81,6 +82,38 @@ func resetTestDB(t *testing.T) {
 	}
 }
 
+// Organizations can't be deleted with DeleteUser because they don't have passwords.
+func deleteTestOrgs(t *testing.T) {
+	var (
+		orgID int64
+		name  string
+		orgs  = make(map[int64]string)
+	)
+
+	rows, err := Connection.Query("SELECT id, username FROM users WHERE is_org")
+	failIf(t, err, "listing test orgs")
+	for rows.Next() {
+		failIf(t, rows.Scan(&orgID, &name), "scanning test orgs")
+		orgs[orgID] = name
+	}
+	rows.Close()
+
+	tx := testTransaction(t)
+	for orgID, name := range orgs {
+		files, err := FilesByUsername(tx, name, orgID, nil)
+		failIf(t, err, "listing test org files")
+		for _, f := range files {
+			failIf(t, DeleteFile(tx, name, f.Alias), "deleting test org file")
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM org_members")
+	failIf(t, err, "deleting test org members")
+	_, err = tx.Exec("DELETE FROM users WHERE is_org")
+	failIf(t, err, "deleting test orgs")
+	failIf(t, tx.Commit())
+}
+
 func createTestUser(t *testing.T, userID int64, username, email string) {
 	exists, err := userExists(Connection, username)
 	failIf(t, err, "counting", username)
@@ -94,14 +127,15 @@ func createTestUser(t *testing.T, userID int64, username, email string) {
 	}
 
 	_, err = Connection.Exec(`
-INSERT INTO users(id, username, email, password_hash, cli_token, password_reset_token) 
-VALUES(?, ?, ?, ?, ?, ?)`,
+INSERT INTO users(id, username, email, password_hash, cli_token, password_reset_token, password_reset_expires_at)
+VALUES(?, ?, ?, ?, ?, ?, ?)`,
 		userID,
 		username,
 		email,
 		hashed,
-		testCliToken,
-		testPasswordResetToken,
+		hashToken(testCliToken),
+		hashToken(testPasswordResetToken),
+		time.Now().Add(time.Hour),
 	)
 	if err != nil {
 		t.Fatalf("creating test user %q: %s", username, err)
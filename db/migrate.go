@@ -0,0 +1,87 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Brings databases that were created by older versions up to date.
+// Each step is safe to run more than once.
+func migrate(e Executor) error {
+	if err := addColumn(e, "users", "password_reset_expires_at", "DATETIME"); err != nil {
+		return err
+	}
+	if err := addColumn(e, "files", "visibility", "TEXT NOT NULL DEFAULT 'public'"); err != nil {
+		return err
+	}
+	if err := addColumn(e, "users", "is_org", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumn(e, "users", "feed_token", "TEXT"); err != nil {
+		return err
+	}
+
+	return hashPlaintextTokens(e)
+}
+
+func addColumn(e Executor, table, column, definition string) error {
+	var count int
+
+	err := e.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).
+		Scan(&count)
+	if err != nil {
+		return errors.Wrapf(err, "checking for column %s.%s", table, column)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if _, err := e.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition); err != nil {
+		return errors.Wrapf(err, "adding column %s.%s", table, column)
+	}
+
+	return nil
+}
+
+// Replaces CLI tokens that were saved before tokens were hashed.
+// Plaintext tokens are hex encoded tokenLength bytes; hashes are longer.
+// Outstanding password reset tokens are cleared because they have no expiration.
+func hashPlaintextTokens(e Executor) error {
+	var (
+		id       int64
+		cliToken string
+		tokens   = make(map[int64]string)
+	)
+
+	rows, err := e.Query("SELECT id, cli_token FROM users WHERE LENGTH(cli_token) = ?", tokenLength*2)
+	if err != nil {
+		return errors.Wrap(err, "querying plaintext cli tokens")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := rows.Scan(&id, &cliToken); err != nil {
+			return errors.Wrap(err, "scanning plaintext cli tokens")
+		}
+		tokens[id] = cliToken
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for id, cliToken := range tokens {
+		if _, err := e.Exec("UPDATE users SET cli_token = ? WHERE id = ?", hashToken(cliToken), id); err != nil {
+			return errors.Wrapf(err, "hashing cli token for user %d", id)
+		}
+	}
+
+	_, err = e.Exec(`
+UPDATE users
+SET password_reset_token = NULL
+WHERE password_reset_token IS NOT NULL AND password_reset_expires_at IS NULL`)
+	if err != nil {
+		return errors.Wrap(err, "clearing plaintext password reset tokens")
+	}
+
+	return nil
+}
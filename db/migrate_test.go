@@ -0,0 +1,45 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testUserID, testUsername, testEmail)
+
+	plaintext, err := token()
+	failIf(t, err, "creating plaintext token")
+
+	_, err = Connection.Exec(`
+UPDATE users
+SET cli_token = ?, password_reset_token = ?, password_reset_expires_at = NULL
+WHERE id = ?`, plaintext, plaintext, testUserID)
+	failIf(t, err, "saving plaintext tokens")
+
+	assert.NoError(t, migrate(Connection))
+
+	t.Run("cli token is hashed and still valid", func(t *testing.T) {
+		var stored string
+		err := Connection.QueryRow("SELECT cli_token FROM users WHERE id = ?", testUserID).Scan(&stored)
+		failIf(t, err, "querying cli token")
+		assert.Equal(t, hashToken(plaintext), stored)
+
+		_, err = UserLoginAPI(Connection, testUsername, plaintext, TokenScopePush, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("plaintext reset token is cleared", func(t *testing.T) {
+		_, err := CheckPasswordResetToken(Connection, plaintext)
+		assertUsererror(t, err)
+	})
+
+	t.Run("migrate is idempotent", func(t *testing.T) {
+		assert.NoError(t, migrate(Connection))
+
+		_, err := UserLoginAPI(Connection, testUsername, plaintext, TokenScopePush, "")
+		assert.NoError(t, err)
+	})
+}
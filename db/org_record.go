@@ -0,0 +1,401 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/knoebber/usererror"
+	"github.com/pkg/errors"
+)
+
+// OrgRole is a member's permission in an organization.
+type OrgRole string
+
+// Valid values for OrgRole.
+const (
+	OrgRoleOwner      OrgRole = "owner"      // Manage members and files.
+	OrgRoleMaintainer OrgRole = "maintainer" // Push and manage files.
+	OrgRoleReader     OrgRole = "reader"     // Read private files.
+)
+
+// OrgRoles are all of the roles that a member may have.
+var OrgRoles = []OrgRole{OrgRoleOwner, OrgRoleMaintainer, OrgRoleReader}
+
+// ErrOrgRole is returned when a user's role doesn't allow an action on an organization.
+var ErrOrgRole = errors.New("organization role does not allow this action")
+
+// OrgMemberRecord models the org_members table.
+// Organizations are rows in the users table that can't log in.
+// They own files like any other user and members act on their behalf.
+type OrgMemberRecord struct {
+	ID        int64
+	OrgID     int64   `validate:"required"`
+	UserID    int64   `validate:"required"`
+	Role      OrgRole `validate:"required"`
+	CreatedAt time.Time
+}
+
+// OrgMember is a view of a member of an organization.
+type OrgMember struct {
+	Username  string
+	Role      OrgRole
+	CreatedAt string
+}
+
+// OrgSummary is a view of an organization that a user belongs to.
+type OrgSummary struct {
+	Name string
+	Role OrgRole
+}
+
+func (*OrgMemberRecord) createStmt() string {
+	return `
+CREATE TABLE IF NOT EXISTS org_members(
+id         INTEGER PRIMARY KEY,
+org_id     INTEGER NOT NULL REFERENCES users,
+user_id    INTEGER NOT NULL REFERENCES users,
+role       TEXT NOT NULL,
+created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS org_members_org_user_index ON org_members(org_id, user_id);
+CREATE INDEX IF NOT EXISTS org_members_user_index ON org_members(user_id);`
+}
+
+func (m *OrgMemberRecord) insertStmt(e Executor) (sql.Result, error) {
+	return e.Exec("INSERT INTO org_members(org_id, user_id, role) VALUES(?, ?, ?)",
+		m.OrgID,
+		m.UserID,
+		m.Role,
+	)
+}
+
+func (m *OrgMemberRecord) check(e Executor) error {
+	var isOrg bool
+
+	if err := checkOrgRole(m.Role); err != nil {
+		return err
+	}
+
+	err := e.QueryRow("SELECT is_org FROM users WHERE id = ?", m.UserID).Scan(&isOrg)
+	if err != nil {
+		return errors.Wrapf(err, "checking if user %d is an organization", m.UserID)
+	}
+	if isOrg {
+		return usererror.New("Organizations can't be members of organizations.")
+	}
+
+	return nil
+}
+
+func checkOrgRole(role OrgRole) error {
+	for _, r := range OrgRoles {
+		if r == role {
+			return nil
+		}
+	}
+
+	return usererror.Format("Role %q is invalid.", role)
+}
+
+// CreateOrg creates an organization in the user namespace.
+// The user ownerID becomes the organization's first owner.
+func CreateOrg(name string, ownerID int64) error {
+	tx, err := Connection.Begin()
+	if err != nil {
+		return errors.Wrap(err, "starting transaction for create org")
+	}
+	if err := createOrg(tx, name, ownerID); err != nil {
+		return Rollback(tx, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "committing transaction for create org")
+	}
+
+	return nil
+}
+
+func createOrg(tx *sql.Tx, name string, ownerID int64) error {
+	// Organizations don't log in; their credentials are random and never revealed.
+	cliToken, err := token()
+	if err != nil {
+		return err
+	}
+
+	org := &UserRecord{
+		Username:     name,
+		PasswordHash: []byte{},
+		CLIToken:     cliToken,
+		IsOrg:        true,
+	}
+
+	orgID, err := insert(tx, org)
+	if err != nil {
+		return errors.Wrapf(err, "creating record for new org %q", name)
+	}
+
+	_, err = insert(tx, &OrgMemberRecord{OrgID: orgID, UserID: ownerID, Role: OrgRoleOwner})
+	return err
+}
+
+// IsOrg returns whether name is an organization.
+func IsOrg(e Executor, name string) (bool, error) {
+	var isOrg bool
+
+	err := e.QueryRow("SELECT is_org FROM users WHERE username = ?", name).Scan(&isOrg)
+	if err != nil {
+		return false, errors.Wrapf(err, "checking if %q is an organization", name)
+	}
+
+	return isOrg, nil
+}
+
+// OrgRoleFor returns the role of userID in the organization orgName.
+// Returns sql.ErrNoRows when the user isn't a member.
+func OrgRoleFor(e Executor, orgName string, userID int64) (OrgRole, error) {
+	var role OrgRole
+
+	err := e.QueryRow(`
+SELECT role
+FROM org_members
+JOIN users ON users.id = org_id
+WHERE username = ? AND user_id = ?`, orgName, userID).Scan(&role)
+	if err != nil {
+		return "", errors.Wrapf(err, "querying role of user %d in %q", userID, orgName)
+	}
+
+	return role, nil
+}
+
+// OrgFileManager checks that userID can push and manage the files of orgName.
+// Returns the ID of the organization.
+func OrgFileManager(e Executor, orgName string, userID int64) (int64, error) {
+	var orgID int64
+
+	err := e.QueryRow(`
+SELECT org_id
+FROM org_members
+JOIN users ON users.id = org_id
+WHERE username = ? AND user_id = ? AND role IN (?, ?)`,
+		orgName, userID, OrgRoleOwner, OrgRoleMaintainer).Scan(&orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.Wrapf(ErrOrgRole, "user %d managing files of %q", userID, orgName)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "querying file manager %d of %q", userID, orgName)
+	}
+
+	return orgID, nil
+}
+
+// Checks that userID is an owner of orgName.
+// Returns the ID of the organization.
+func checkOrgOwner(e Executor, orgName string, userID int64) (int64, error) {
+	var orgID int64
+
+	err := e.QueryRow(`
+SELECT org_id
+FROM org_members
+JOIN users ON users.id = org_id
+WHERE username = ? AND user_id = ? AND role = ?`, orgName, userID, OrgRoleOwner).Scan(&orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, usererror.Format("Only owners can manage the members of %q.", orgName)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "checking if user %d owns %q", userID, orgName)
+	}
+
+	return orgID, nil
+}
+
+// Returns an error when removing memberID's owner role would leave orgID without owners.
+func checkRemainingOwners(e Executor, orgID, memberID int64) error {
+	var count int
+
+	err := e.QueryRow(`
+SELECT COUNT(*)
+FROM org_members
+WHERE org_id = ? AND user_id != ? AND role = ?`, orgID, memberID, OrgRoleOwner).Scan(&count)
+	if err != nil {
+		return errors.Wrapf(err, "counting owners of org %d", orgID)
+	}
+	if count == 0 {
+		return usererror.New("Organizations must have at least one owner.")
+	}
+
+	return nil
+}
+
+// SetOrgMember adds username to an organization or changes their role.
+// Only owners of the organization can set members.
+func SetOrgMember(e Executor, orgName string, ownerID int64, username string, role OrgRole) error {
+	var memberID int64
+
+	orgID, err := checkOrgOwner(e, orgName, ownerID)
+	if err != nil {
+		return err
+	}
+
+	err = e.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&memberID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return usererror.Format("User %q not found.", username)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "querying id of user %q", username)
+	}
+
+	if _, err := OrgRoleFor(e, orgName, memberID); NotFound(err) {
+		_, err = insert(e, &OrgMemberRecord{OrgID: orgID, UserID: memberID, Role: role})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if err := checkOrgRole(role); err != nil {
+		return err
+	}
+	if role != OrgRoleOwner {
+		if err := checkRemainingOwners(e, orgID, memberID); err != nil {
+			return err
+		}
+	}
+
+	_, err = e.Exec("UPDATE org_members SET role = ? WHERE org_id = ? AND user_id = ?", role, orgID, memberID)
+	if err != nil {
+		return errors.Wrapf(err, "setting role of %q in %q", username, orgName)
+	}
+
+	return nil
+}
+
+// RemoveOrgMember removes username from an organization.
+// Only owners of the organization can remove members.
+func RemoveOrgMember(e Executor, orgName string, ownerID int64, username string) error {
+	orgID, err := checkOrgOwner(e, orgName, ownerID)
+	if err != nil {
+		return err
+	}
+
+	var memberID int64
+	err = e.QueryRow(`
+SELECT user_id
+FROM org_members
+JOIN users ON users.id = user_id
+WHERE org_id = ? AND username = ?`, orgID, username).Scan(&memberID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return usererror.Format("%q is not a member of %q.", username, orgName)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "querying member %q of %q", username, orgName)
+	}
+
+	if err := checkRemainingOwners(e, orgID, memberID); err != nil {
+		return err
+	}
+
+	_, err = e.Exec("DELETE FROM org_members WHERE org_id = ? AND user_id = ?", orgID, memberID)
+	if err != nil {
+		return errors.Wrapf(err, "removing %q from %q", username, orgName)
+	}
+
+	return nil
+}
+
+// OrgMembers returns the members of an organization.
+func OrgMembers(e Executor, orgName string, timezone *string) ([]OrgMember, error) {
+	var (
+		createdAt time.Time
+		result    []OrgMember
+	)
+
+	rows, err := e.Query(`
+SELECT members.username,
+       role,
+       org_members.created_at
+FROM org_members
+JOIN users AS orgs ON orgs.id = org_id
+JOIN users AS members ON members.id = user_id
+WHERE orgs.username = ?
+ORDER BY members.username`, orgName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying members of %q", orgName)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := OrgMember{}
+		if err := rows.Scan(&m.Username, &m.Role, &createdAt); err != nil {
+			return nil, errors.Wrapf(err, "scanning members of %q", orgName)
+		}
+
+		m.CreatedAt = formatTime(createdAt, timezone)
+		result = append(result, m)
+	}
+
+	return result, nil
+}
+
+// OrgsByUserID returns the organizations that a user belongs to.
+func OrgsByUserID(e Executor, userID int64) ([]OrgSummary, error) {
+	var result []OrgSummary
+
+	rows, err := e.Query(`
+SELECT username, role
+FROM org_members
+JOIN users ON users.id = org_id
+WHERE user_id = ?
+ORDER BY username`, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying orgs of user %d", userID)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o := OrgSummary{}
+		if err := rows.Scan(&o.Name, &o.Role); err != nil {
+			return nil, errors.Wrapf(err, "scanning orgs of user %d", userID)
+		}
+
+		result = append(result, o)
+	}
+
+	return result, nil
+}
+
+// Removes a user from their organizations.
+// Returns an error when the user is the last owner of one.
+func leaveOrgs(e Executor, userID int64) error {
+	var (
+		orgID int64
+		owned []int64
+	)
+
+	rows, err := e.Query("SELECT org_id FROM org_members WHERE user_id = ? AND role = ?", userID, OrgRoleOwner)
+	if err != nil {
+		return errors.Wrapf(err, "querying orgs owned by user %d", userID)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := rows.Scan(&orgID); err != nil {
+			return errors.Wrapf(err, "scanning orgs owned by user %d", userID)
+		}
+		owned = append(owned, orgID)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, orgID := range owned {
+		if err := checkRemainingOwners(e, orgID, userID); err != nil {
+			return usererror.New("Add another owner to your organizations before deleting your account.")
+		}
+	}
+
+	if _, err := e.Exec("DELETE FROM org_members WHERE user_id = ?", userID); err != nil {
+		return errors.Wrapf(err, "deleting org memberships of user %d", userID)
+	}
+
+	return nil
+}
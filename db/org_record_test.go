@@ -0,0 +1,142 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testOrgName     = "acme"
+	testMemberID    = testUserID + 1
+	testMemberName  = "member"
+	testMemberEmail = "member@example.com"
+)
+
+func createTestOrg(t *testing.T) int64 {
+	var orgID int64
+
+	createTestUser(t, testUserID, testUsername, testEmail)
+	createTestUser(t, testMemberID, testMemberName, testMemberEmail)
+	failIf(t, CreateOrg(testOrgName, testUserID), "creating test org")
+	failIf(t, Connection.QueryRow("SELECT id FROM users WHERE username = ?", testOrgName).Scan(&orgID))
+
+	return orgID
+}
+
+func TestCreateOrg(t *testing.T) {
+	createTestDB(t)
+	createTestOrg(t)
+
+	t.Run("error when name is a user", func(t *testing.T) {
+		assertUsererror(t, CreateOrg(testMemberName, testUserID))
+	})
+
+	t.Run("creator is owner", func(t *testing.T) {
+		isOrg, err := IsOrg(Connection, testOrgName)
+		assert.NoError(t, err)
+		assert.True(t, isOrg)
+
+		role, err := OrgRoleFor(Connection, testOrgName, testUserID)
+		assert.NoError(t, err)
+		assert.Equal(t, OrgRoleOwner, role)
+	})
+
+	t.Run("users can't take the name", func(t *testing.T) {
+		_, err := CreateUser(Connection, testOrgName, "", "password")
+		assertUsererror(t, err)
+	})
+
+	t.Run("organizations can't log in", func(t *testing.T) {
+		_, err := UserLogin(Connection, testOrgName, "", "")
+		assert.Error(t, err)
+	})
+}
+
+func TestSetOrgMember(t *testing.T) {
+	createTestDB(t)
+	createTestOrg(t)
+
+	t.Run("error when not an owner", func(t *testing.T) {
+		assertUsererror(t, SetOrgMember(Connection, testOrgName, testMemberID, testMemberName, OrgRoleOwner))
+	})
+
+	t.Run("error on invalid role", func(t *testing.T) {
+		assertUsererror(t, SetOrgMember(Connection, testOrgName, testUserID, testMemberName, "admin"))
+	})
+
+	t.Run("add and change role", func(t *testing.T) {
+		assert.NoError(t, SetOrgMember(Connection, testOrgName, testUserID, testMemberName, OrgRoleReader))
+		_, err := OrgFileManager(Connection, testOrgName, testMemberID)
+		assert.ErrorIs(t, err, ErrOrgRole)
+
+		assert.NoError(t, SetOrgMember(Connection, testOrgName, testUserID, testMemberName, OrgRoleMaintainer))
+		_, err = OrgFileManager(Connection, testOrgName, testMemberID)
+		assert.NoError(t, err)
+
+		members, err := OrgMembers(Connection, testOrgName, nil)
+		assert.NoError(t, err)
+		assert.Len(t, members, 2)
+
+		orgs, err := OrgsByUserID(Connection, testMemberID)
+		assert.NoError(t, err)
+		assert.Equal(t, []OrgSummary{{Name: testOrgName, Role: OrgRoleMaintainer}}, orgs)
+	})
+
+	t.Run("error when demoting the last owner", func(t *testing.T) {
+		assertUsererror(t, SetOrgMember(Connection, testOrgName, testUserID, testUsername, OrgRoleReader))
+	})
+}
+
+func TestRemoveOrgMember(t *testing.T) {
+	createTestDB(t)
+	createTestOrg(t)
+	failIf(t, SetOrgMember(Connection, testOrgName, testUserID, testMemberName, OrgRoleReader))
+
+	t.Run("error when removing the last owner", func(t *testing.T) {
+		assertUsererror(t, RemoveOrgMember(Connection, testOrgName, testUserID, testUsername))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		assert.NoError(t, RemoveOrgMember(Connection, testOrgName, testUserID, testMemberName))
+		_, err := OrgRoleFor(Connection, testOrgName, testMemberID)
+		assert.True(t, NotFound(err))
+	})
+
+	t.Run("error when not a member", func(t *testing.T) {
+		assertUsererror(t, RemoveOrgMember(Connection, testOrgName, testUserID, testMemberName))
+	})
+}
+
+func TestOrgFileAccess(t *testing.T) {
+	createTestDB(t)
+	orgID := createTestOrg(t)
+	file := initTestFile(t)
+	failIf(t, file.SetVisibility(Connection, FileVisibilityPrivate))
+	_, err := Connection.Exec("UPDATE files SET user_id = ? WHERE id = ?", orgID, file.ID)
+	failIf(t, err, "moving test file to org")
+
+	t.Run("private files are hidden from non members", func(t *testing.T) {
+		_, err := FileAccess(Connection, testOrgName, testAlias, testMemberID)
+		assert.True(t, NotFound(err))
+
+		files, err := FilesByUsername(Connection, testOrgName, testMemberID, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, files)
+	})
+
+	t.Run("readers can see private files", func(t *testing.T) {
+		failIf(t, SetOrgMember(Connection, testOrgName, testUserID, testMemberName, OrgRoleReader))
+
+		_, err := FileAccess(Connection, testOrgName, testAlias, testMemberID)
+		assert.NoError(t, err)
+
+		files, err := FilesByUsername(Connection, testOrgName, testMemberID, nil)
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+	})
+
+	t.Run("last owner can't delete their account", func(t *testing.T) {
+		assertUsererror(t, DeleteUser(testUsername, testPassword))
+	})
+}
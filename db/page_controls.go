@@ -77,10 +77,35 @@ func (p *PageControls) Set() error {
 	return nil
 }
 
+// Page returns the current page, 'p'.
+func (p *PageControls) Page() int {
+	return p.page
+}
+
+// TotalRows returns the total amount of rows available.
+func (p *PageControls) TotalRows() int {
+	return p.totalRows
+}
+
+// TotalPages returns the amount of pages that are needed for the total rows.
+func (p *PageControls) TotalPages() int {
+	return p.totalPages()
+}
+
 func (p *PageControls) sqlSuffix() string {
 	return fmt.Sprintf(" ORDER BY %d %s LIMIT %d OFFSET %d", p.orderBy, p.order, p.limit, (p.page-1)*p.limit)
 }
 
+// Orders by rank until a column is chosen with 'ob'.
+func (p *PageControls) rankedSQLSuffix(rank string) string {
+	if p.Values.Get("ob") != "" {
+		return p.sqlSuffix()
+	}
+
+	p.orderBy = 0
+	return fmt.Sprintf(" ORDER BY %s LIMIT %d OFFSET %d", rank, p.limit, (p.page-1)*p.limit)
+}
+
 func (p *PageControls) totalPages() int {
 	if p.totalRows == 0 || p.limit == 0 {
 		return 0
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/knoebber/usererror"
+)
+
+const searchDateLayout = "2006-01-02"
+
+// The columns of the file search table that can be sorted with "sort:".
+var searchSortColumns = map[string]int{
+	"updated": 4,
+	"stars":   5,
+	"forks":   6,
+}
+
+// Filters parsed from a search query.
+//
+// user:knoebber         Files owned by knoebber.
+// alias:vimrc           Files with the alias vimrc.
+// path:~/.config/*      Files with paths that match a glob.
+// updated:>2026-01-01   Files updated after a day. Supports >, >=, <, <= and a single day.
+// sort:stars            Sorts by updated, stars or forks, most first.
+//
+// Everything else is text that is matched against the alias, path and content.
+type searchFilter struct {
+	text     string
+	username string
+	alias    string
+	pathGlob string
+	sortBy   int // The column to order by, 0 when not set.
+
+	updatedAfter  *time.Time // Inclusive.
+	updatedBefore *time.Time // Exclusive.
+}
+
+func parseSearchQuery(query string) (*searchFilter, error) {
+	var text []string
+
+	f := new(searchFilter)
+
+	for _, term := range splitSearchQuery(query) {
+		name, value, found := strings.Cut(term, ":")
+		if !found || value == "" {
+			text = append(text, term)
+			continue
+		}
+
+		switch strings.ToLower(name) {
+		case "user":
+			f.username = value
+		case "alias":
+			f.alias = value
+		case "path":
+			f.pathGlob = value
+		case "updated":
+			if err := f.setUpdated(value); err != nil {
+				return nil, err
+			}
+		case "sort":
+			if f.sortBy = searchSortColumns[strings.ToLower(value)]; f.sortBy == 0 {
+				return nil, usererror.Format(`Invalid search: "sort:%s" must be one of: updated, stars, forks.`, value)
+			}
+		default:
+			text = append(text, term)
+		}
+	}
+
+	f.text = strings.Join(text, " ")
+	return f, nil
+}
+
+// Sets the updated range from a comparison with a day, for example ">2026-01-01".
+func (f *searchFilter) setUpdated(value string) error {
+	date := strings.TrimLeft(value, "<>=")
+	operator := strings.TrimSuffix(value, date)
+
+	day, err := time.Parse(searchDateLayout, date)
+	if err != nil {
+		return usererror.Format(`Invalid search: "updated:%s" must compare with a date like "updated:>2026-01-01".`, value)
+	}
+	nextDay := day.AddDate(0, 0, 1)
+
+	switch operator {
+	case ">":
+		f.updatedAfter = &nextDay
+	case ">=":
+		f.updatedAfter = &day
+	case "<":
+		f.updatedBefore = &day
+	case "<=":
+		f.updatedBefore = &nextDay
+	case "", "=":
+		f.updatedAfter, f.updatedBefore = &day, &nextDay
+	default:
+		return usererror.Format(`Invalid search: "updated:%s" must use one of: >, >=, <, <=.`, value)
+	}
+
+	return nil
+}
+
+// Returns the SQL conditions for the filters.
+func (f *searchFilter) where() (string, []interface{}) {
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+
+	add := func(condition string, arg interface{}) {
+		_, _ = b.WriteString(" AND " + condition)
+		args = append(args, arg)
+	}
+
+	if f.username != "" {
+		add("users.username = ?", f.username)
+	}
+	if f.alias != "" {
+		add("files.alias = ?", f.alias)
+	}
+	if f.pathGlob != "" {
+		add("files.path GLOB ?", f.pathGlob)
+	}
+	// Timestamps are stored as UTC text by CURRENT_TIMESTAMP.
+	if f.updatedAfter != nil {
+		add("files.updated_at >= ?", f.updatedAfter.Format("2006-01-02 15:04:05"))
+	}
+	if f.updatedBefore != nil {
+		add("files.updated_at < ?", f.updatedBefore.Format("2006-01-02 15:04:05"))
+	}
+
+	return b.String(), args
+}
+
+// Splits a search query on spaces that are not inside double quotes.
+func splitSearchQuery(query string) []string {
+	var (
+		terms   []string
+		b       strings.Builder
+		inQuote bool
+	)
+
+	flush := func() {
+		if b.Len() > 0 {
+			terms = append(terms, b.String())
+			b.Reset()
+		}
+	}
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case unicode.IsSpace(r) && !inQuote:
+			flush()
+		default:
+			_, _ = b.WriteRune(r)
+		}
+	}
+	flush()
+
+	return terms
+}
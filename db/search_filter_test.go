@@ -0,0 +1,53 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	t.Run("filters and text", func(t *testing.T) {
+		f, err := parseSearchQuery(`vim user:alice alias:vimrc path:"~/My Files/*" sort:Stars colors`)
+		assert.NoError(t, err)
+		assert.Equal(t, "vim colors", f.text)
+		assert.Equal(t, "alice", f.username)
+		assert.Equal(t, "vimrc", f.alias)
+		assert.Equal(t, "~/My Files/*", f.pathGlob)
+		assert.Equal(t, 5, f.sortBy)
+	})
+
+	t.Run("unknown filters are text", func(t *testing.T) {
+		f, err := parseSearchQuery("https://example.com color:")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com color:", f.text)
+	})
+
+	t.Run("updated", func(t *testing.T) {
+		day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		nextDay := day.AddDate(0, 0, 1)
+
+		f, err := parseSearchQuery("updated:>2026-01-01")
+		assert.NoError(t, err)
+		assert.Equal(t, &nextDay, f.updatedAfter)
+		assert.Nil(t, f.updatedBefore)
+
+		f, err = parseSearchQuery("updated:<=2026-01-01")
+		assert.NoError(t, err)
+		assert.Nil(t, f.updatedAfter)
+		assert.Equal(t, &nextDay, f.updatedBefore)
+
+		f, err = parseSearchQuery("updated:2026-01-01")
+		assert.NoError(t, err)
+		assert.Equal(t, &day, f.updatedAfter)
+		assert.Equal(t, &nextDay, f.updatedBefore)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, q := range []string{"updated:>>2026-01-01", "updated:yesterday", "sort:alias"} {
+			_, err := parseSearchQuery(q)
+			assertUsererror(t, err)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"log"
+	"strings"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/pkg/errors"
+)
+
+// Markers that surround matched terms in search snippets.
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
+// Whether the full text search index exists.
+// FTS5 is only available when sqlite3 is built with the sqlite_fts5 tag.
+// Without it search falls back to matching alias and path.
+var searchIndexEnabled bool
+
+// Creates the full text search index of each file's current content.
+// The index's rowid is the file's id.
+func createSearchIndex(e Executor) error {
+	_, err := e.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS file_search USING fts5(alias, path, content)")
+	if err != nil && strings.Contains(err.Error(), "no such module: fts5") {
+		log.Print("full text search is disabled: sqlite3 was built without the sqlite_fts5 tag")
+		searchIndexEnabled = false
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "creating search index")
+	}
+
+	searchIndexEnabled = true
+	return indexAllFiles(e)
+}
+
+// Indexes every file when the index is empty.
+// This fills the index for databases that existed before it.
+func indexAllFiles(e Executor) error {
+	var (
+		count   int
+		fileIDs []int64
+	)
+
+	if err := e.QueryRow("SELECT COUNT(*) FROM file_search").Scan(&count); err != nil {
+		return errors.Wrap(err, "counting search index rows")
+	}
+	if count > 0 {
+		return nil
+	}
+
+	rows, err := e.Query("SELECT id FROM files WHERE current_commit_id IS NOT NULL")
+	if err != nil {
+		return errors.Wrap(err, "querying files to index")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return errors.Wrap(err, "scanning files to index")
+		}
+		fileIDs = append(fileIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "reading files to index")
+	}
+
+	for _, id := range fileIDs {
+		if err := indexFile(e, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Replaces the indexed content of a file with its current revision.
+func indexFile(e Executor, fileID int64) error {
+	var (
+		alias, path string
+		revision    []byte
+	)
+
+	if !searchIndexEnabled {
+		return nil
+	}
+
+	err := e.QueryRow(`
+SELECT alias, path, revision
+FROM files
+JOIN commits ON commits.id = current_commit_id
+WHERE files.id = ?`, fileID).Scan(&alias, &path, &revision)
+	if err != nil {
+		return errors.Wrapf(err, "querying file %d to index", fileID)
+	}
+
+	// Content that can't be read is left out so that the file can still be saved.
+	var content string
+	if uncompressed, err := dotfile.Uncompress(revision); err != nil {
+		log.Printf("indexing file %d without content: %s", fileID, err)
+	} else {
+		content = uncompressed.String()
+	}
+
+	if err := unindexFile(e, fileID); err != nil {
+		return err
+	}
+
+	_, err = e.Exec("INSERT INTO file_search(rowid, alias, path, content) VALUES(?, ?, ?, ?)",
+		fileID,
+		alias,
+		path,
+		content,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "indexing file %d", fileID)
+	}
+
+	return nil
+}
+
+func unindexFile(e Executor, fileID int64) error {
+	if !searchIndexEnabled {
+		return nil
+	}
+
+	if _, err := e.Exec("DELETE FROM file_search WHERE rowid = ?", fileID); err != nil {
+		return errors.Wrapf(err, "removing file %d from search index", fileID)
+	}
+
+	return nil
+}
+
+// Converts a user's query to an FTS5 query.
+// Each word is quoted so that FTS5 operators are matched literally and
+// is a prefix so that partial words match like they do in alias search.
+func searchIndexQuery(query string) string {
+	terms := strings.Fields(query)
+
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchIndexQuery(t *testing.T) {
+	assert.Equal(t, `"escape-time"*`, searchIndexQuery("escape-time"))
+	assert.Equal(t, `"set"* """quoted"""*`, searchIndexQuery(` set  "quoted" `))
+	assert.Empty(t, searchIndexQuery(""))
+}
+
+func TestSearchIndex(t *testing.T) {
+	createTestDB(t)
+	if !searchIndexEnabled {
+		t.Skip("sqlite3 was built without the sqlite_fts5 tag")
+	}
+
+	initialCommit, _ := initTestFileAndCommit(t)
+
+	search := func(t *testing.T, query string) *HTMLTable {
+		controls := &PageControls{Values: url.Values{"q": []string{query}}}
+		failIf(t, controls.Set(), "setting page controls")
+
+		table, err := SearchFiles(Connection, controls, nil)
+		failIf(t, err, "searching files")
+		return table
+	}
+
+	t.Run("matches current content with a snippet", func(t *testing.T) {
+		// The current commit's content is the test username.
+		table := search(t, testUsername)
+		if assert.Len(t, table.Rows, 1) {
+			snippet := table.Rows[0].(FileSearchResult).Snippet
+			assert.Equal(t, SnippetMatchStart+testUsername+SnippetMatchEnd, snippet)
+		}
+		assert.Empty(t, search(t, "blob").Rows)
+	})
+
+	t.Run("reindexes when the revision changes", func(t *testing.T) {
+		failIf(t, SetFileToHash(Connection, testUsername, testAlias, initialCommit.Hash))
+
+		assert.Len(t, search(t, "blob").Rows, 1)
+		assert.Empty(t, search(t, testUsername).Rows)
+	})
+
+	t.Run("reindexes when the path changes", func(t *testing.T) {
+		file, err := File(Connection, testUsername, testAlias)
+		failIf(t, err)
+		failIf(t, file.Update(Connection, testAlias, "~/.tmux.conf"))
+
+		assert.Len(t, search(t, "tmux").Rows, 1)
+	})
+
+	t.Run("removes deleted files", func(t *testing.T) {
+		tx := testTransaction(t)
+		failIf(t, DeleteFile(tx, testUsername, testAlias))
+		failIf(t, tx.Commit())
+
+		var count int
+		failIf(t, Connection.QueryRow("SELECT COUNT(*) FROM file_search").Scan(&count))
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/knoebber/usererror"
+	"github.com/pkg/errors"
+)
+
+const maxSharesPerFile = 20
+
+// ShareRecord models the shares table.
+// Shares are revocable links that grant read access to a file to anyone with the token.
+type ShareRecord struct {
+	ID             int64
+	FileID         int64  `validate:"required"`
+	TokenHash      string `validate:"required"`
+	History        bool   // Whether the link grants access to the file's commits.
+	ExpiresAt      *time.Time
+	AccessCount    int64
+	LastAccessedAt *time.Time
+	CreatedAt      time.Time
+}
+
+// ShareSummary is a view of a share link for the file's owner.
+// It does not include the secret value.
+type ShareSummary struct {
+	ID             int64
+	History        bool
+	ExpiresAt      string
+	Expired        bool
+	AccessCount    int64
+	LastAccessedAt string
+	CreatedAt      string
+}
+
+// ShareView is the file that a share link grants access to.
+type ShareView struct {
+	ShareID  int64
+	Username string
+	Alias    string
+	History  bool
+}
+
+func (*ShareRecord) createStmt() string {
+	return `
+CREATE TABLE IF NOT EXISTS shares(
+id               INTEGER PRIMARY KEY,
+file_id          INTEGER NOT NULL REFERENCES files,
+token_hash       TEXT NOT NULL UNIQUE,
+history          INTEGER NOT NULL DEFAULT 0,
+expires_at       DATETIME,
+access_count     INTEGER NOT NULL DEFAULT 0,
+last_accessed_at DATETIME,
+created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS shares_file_index ON shares(file_id);`
+}
+
+func (s *ShareRecord) insertStmt(e Executor) (sql.Result, error) {
+	return e.Exec(`
+INSERT INTO shares(file_id, token_hash, history, expires_at) VALUES(?, ?, ?, ?)`,
+		s.FileID,
+		s.TokenHash,
+		s.History,
+		s.ExpiresAt,
+	)
+}
+
+func (s *ShareRecord) check(e Executor) error {
+	var count int
+
+	if s.ExpiresAt != nil && s.ExpiresAt.Before(time.Now()) {
+		return usererror.New("Share expiration must be in the future.")
+	}
+
+	err := e.QueryRow("SELECT COUNT(*) FROM shares WHERE file_id = ?", s.FileID).Scan(&count)
+	if err != nil {
+		return errors.Wrapf(err, "counting shares for file %d", s.FileID)
+	}
+	if count >= maxSharesPerFile {
+		return usererror.Format("Maximum amount of share links reached (%d).", maxSharesPerFile)
+	}
+
+	return nil
+}
+
+// CreateShare creates a share link for a file.
+// Returns the secret value of the link; only its hash is saved.
+func CreateShare(e Executor, fileID int64, history bool, expiresAt *time.Time) (string, error) {
+	value, err := token()
+	if err != nil {
+		return "", err
+	}
+
+	s := &ShareRecord{
+		FileID:    fileID,
+		TokenHash: hashToken(value),
+		History:   history,
+		ExpiresAt: expiresAt,
+	}
+
+	if _, err := insert(e, s); err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+// Shares returns a summary of a file's share links.
+func Shares(e Executor, fileID int64, timezone *string) ([]ShareSummary, error) {
+	var (
+		expiresAt      *time.Time
+		lastAccessedAt *time.Time
+		createdAt      time.Time
+		result         []ShareSummary
+	)
+
+	rows, err := e.Query(`
+SELECT id,
+       history,
+       expires_at,
+       access_count,
+       last_accessed_at,
+       created_at
+FROM shares
+WHERE file_id = ?
+ORDER BY created_at DESC`, fileID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying shares for file %d", fileID)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := ShareSummary{}
+
+		if err := rows.Scan(
+			&s.ID,
+			&s.History,
+			&expiresAt,
+			&s.AccessCount,
+			&lastAccessedAt,
+			&createdAt,
+		); err != nil {
+			return nil, errors.Wrapf(err, "scanning shares for file %d", fileID)
+		}
+
+		if expiresAt != nil {
+			s.ExpiresAt = formatTime(*expiresAt, timezone)
+			s.Expired = expiresAt.Before(time.Now())
+		}
+		if lastAccessedAt != nil {
+			s.LastAccessedAt = formatTime(*lastAccessedAt, timezone)
+		}
+		s.CreatedAt = formatTime(createdAt, timezone)
+
+		result = append(result, s)
+	}
+
+	return result, nil
+}
+
+// RevokeShare deletes a file's share link.
+func RevokeShare(e Executor, fileID, shareID int64) error {
+	res, err := e.Exec("DELETE FROM shares WHERE id = ? AND file_id = ?", shareID, fileID)
+	if err != nil {
+		return errors.Wrapf(err, "revoking share %d", shareID)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return usererror.New("Share link not found.")
+	}
+
+	return nil
+}
+
+// OpenShare finds the file that a share link grants access to and records the access.
+// Unknown and expired links are reported as not found.
+func OpenShare(e Executor, value string) (*ShareView, error) {
+	var (
+		tokenHash string
+		expiresAt *time.Time
+	)
+
+	sv := new(ShareView)
+
+	err := e.QueryRow(`
+SELECT shares.id,
+       token_hash,
+       history,
+       expires_at,
+       username,
+       alias
+FROM shares
+JOIN files ON files.id = file_id
+JOIN users ON users.id = files.user_id
+WHERE token_hash = ?`, hashToken(value)).
+		Scan(&sv.ShareID, &tokenHash, &sv.History, &expiresAt, &sv.Username, &sv.Alias)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying share")
+	}
+	if !tokenMatches(value, tokenHash) {
+		return nil, errors.Wrap(sql.ErrNoRows, "share token does not match")
+	}
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return nil, errors.Wrapf(sql.ErrNoRows, "share %d is expired", sv.ShareID)
+	}
+
+	_, err = e.Exec(`
+UPDATE shares
+SET access_count = access_count + 1, last_accessed_at = ?
+WHERE id = ?`, time.Now(), sv.ShareID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "recording access of share %d", sv.ShareID)
+	}
+
+	return sv, nil
+}
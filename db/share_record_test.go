@@ -0,0 +1,91 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateShare(t *testing.T) {
+	createTestDB(t)
+	file := initTestFile(t)
+
+	t.Run("error on past expiration", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		_, err := CreateShare(Connection, file.ID, false, &expiresAt)
+		assertUsererror(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		token, err := CreateShare(Connection, file.ID, true, &expiresAt)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+
+		shares, err := Shares(Connection, file.ID, nil)
+		assert.NoError(t, err)
+		if assert.Len(t, shares, 1) {
+			assert.True(t, shares[0].History)
+			assert.NotEmpty(t, shares[0].ExpiresAt)
+			assert.False(t, shares[0].Expired)
+			assert.Zero(t, shares[0].AccessCount)
+		}
+	})
+}
+
+func TestOpenShare(t *testing.T) {
+	createTestDB(t)
+	file := initTestFile(t)
+	failIf(t, file.SetVisibility(Connection, FileVisibilityPrivate))
+
+	token, err := CreateShare(Connection, file.ID, false, nil)
+	failIf(t, err, "creating test share")
+
+	t.Run("error on unknown token", func(t *testing.T) {
+		_, err := OpenShare(Connection, "unknown")
+		assert.True(t, NotFound(err))
+	})
+
+	t.Run("ok records access", func(t *testing.T) {
+		sv, err := OpenShare(Connection, token)
+		assert.NoError(t, err)
+		assert.Equal(t, testUsername, sv.Username)
+		assert.Equal(t, testAlias, sv.Alias)
+		assert.False(t, sv.History)
+
+		shares, err := Shares(Connection, file.ID, nil)
+		failIf(t, err, "listing test shares")
+		assert.Equal(t, int64(1), shares[0].AccessCount)
+		assert.NotEmpty(t, shares[0].LastAccessedAt)
+	})
+
+	t.Run("error when expired", func(t *testing.T) {
+		_, err := Connection.Exec("UPDATE shares SET expires_at = ?", time.Now().Add(-time.Hour))
+		failIf(t, err, "expiring share")
+
+		_, err = OpenShare(Connection, token)
+		assert.True(t, NotFound(err))
+	})
+}
+
+func TestRevokeShare(t *testing.T) {
+	createTestDB(t)
+	file := initTestFile(t)
+
+	token, err := CreateShare(Connection, file.ID, false, nil)
+	failIf(t, err, "creating test share")
+	shares, err := Shares(Connection, file.ID, nil)
+	failIf(t, err, "listing test shares")
+
+	t.Run("error when file doesn't own share", func(t *testing.T) {
+		assertUsererror(t, RevokeShare(Connection, file.ID+1, shares[0].ID))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		assert.NoError(t, RevokeShare(Connection, file.ID, shares[0].ID))
+
+		_, err := OpenShare(Connection, token)
+		assert.True(t, NotFound(err))
+	})
+}
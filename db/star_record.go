@@ -0,0 +1,143 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StarRecord models the stars table.
+// Users star files that they want to find again.
+type StarRecord struct {
+	ID        int64
+	UserID    int64 `validate:"required"`
+	FileID    int64 `validate:"required"`
+	CreatedAt time.Time
+}
+
+// StarredFile is a file that a user starred.
+type StarredFile struct {
+	Username  string
+	Alias     string
+	Path      string
+	Stars     int
+	StarredAt string
+}
+
+func (*StarRecord) createStmt() string {
+	return `
+CREATE TABLE IF NOT EXISTS stars(
+id         INTEGER PRIMARY KEY,
+user_id    INTEGER NOT NULL REFERENCES users,
+file_id    INTEGER NOT NULL REFERENCES files,
+created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS stars_user_file_index ON stars(user_id, file_id);
+CREATE INDEX IF NOT EXISTS stars_file_index ON stars(file_id);`
+}
+
+func (s *StarRecord) insertStmt(e Executor) (sql.Result, error) {
+	return e.Exec("INSERT INTO stars(user_id, file_id) VALUES(?, ?)", s.UserID, s.FileID)
+}
+
+// Star stars a file for userID.
+// Starring a file that is already starred does nothing.
+func Star(e Executor, userID int64, username, alias string) error {
+	if _, err := FileAccess(e, username, alias, userID); err != nil {
+		return err
+	}
+
+	file, err := File(e, username, alias)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = e.QueryRow("SELECT COUNT(*) FROM stars WHERE user_id = ? AND file_id = ?", userID, file.ID).
+		Scan(&count)
+	if err != nil {
+		return errors.Wrapf(err, "checking star of user %d on file %d", userID, file.ID)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err = insert(e, &StarRecord{UserID: userID, FileID: file.ID})
+	return err
+}
+
+// Unstar removes userID's star from a file.
+func Unstar(e Executor, userID int64, username, alias string) error {
+	file, err := File(e, username, alias)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.Exec("DELETE FROM stars WHERE user_id = ? AND file_id = ?", userID, file.ID)
+	if err != nil {
+		return errors.Wrapf(err, "removing star of user %d on file %d", userID, file.ID)
+	}
+
+	return nil
+}
+
+// FileStars returns the amount of stars that a file has and whether viewerID starred it.
+func FileStars(e Executor, username, alias string, viewerID int64) (count int, starred bool, err error) {
+	err = e.QueryRow(`
+SELECT COUNT(*),
+       COALESCE(SUM(stars.user_id = ?), 0) > 0
+FROM stars
+JOIN files ON files.id = file_id
+JOIN users ON users.id = files.user_id
+WHERE username = ? AND alias = ?`, viewerID, username, alias).Scan(&count, &starred)
+	if err != nil {
+		err = errors.Wrapf(err, "counting stars for %q %q", username, alias)
+	}
+
+	return
+}
+
+// StarredFiles returns the files that username starred.
+// Files are only returned when viewerID can see them.
+// Unlisted files are only listed for the user that starred them.
+func StarredFiles(e Executor, username string, viewerID int64, timezone *string) ([]StarredFile, error) {
+	var (
+		starredAt time.Time
+		result    []StarredFile
+	)
+
+	rows, err := e.Query(`
+SELECT owners.username,
+       alias,
+       path,
+       (SELECT COUNT(*) FROM stars AS counted WHERE counted.file_id = files.id),
+       stars.created_at
+FROM stars
+JOIN users AS starrers ON starrers.id = stars.user_id
+JOIN files ON files.id = stars.file_id
+JOIN users AS owners ON owners.id = files.user_id
+WHERE starrers.username = ? AND (
+  visibility = 'public'
+  OR (visibility = 'unlisted' AND stars.user_id = ?)
+  OR files.user_id = ?
+  OR EXISTS(SELECT 1 FROM org_members WHERE org_id = files.user_id AND org_members.user_id = ?))
+ORDER BY stars.created_at DESC`, username, viewerID, viewerID, viewerID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying files starred by %q", username)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		f := StarredFile{}
+		if err := rows.Scan(&f.Username, &f.Alias, &f.Path, &f.Stars, &starredAt); err != nil {
+			return nil, errors.Wrapf(err, "scanning files starred by %q", username)
+		}
+
+		f.StarredAt = formatTime(starredAt, timezone)
+		result = append(result, f)
+	}
+
+	return result, nil
+}
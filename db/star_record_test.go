@@ -0,0 +1,66 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStar(t *testing.T) {
+	createTestDB(t)
+	file := initTestFile(t)
+	createTestUser(t, testMemberID, testMemberName, testMemberEmail)
+
+	t.Run("error when file doesn't exist", func(t *testing.T) {
+		assert.True(t, NotFound(Star(Connection, testMemberID, testUsername, "missing")))
+	})
+
+	t.Run("star is counted once", func(t *testing.T) {
+		assert.NoError(t, Star(Connection, testMemberID, testUsername, testAlias))
+		assert.NoError(t, Star(Connection, testMemberID, testUsername, testAlias))
+
+		count, starred, err := FileStars(Connection, testUsername, testAlias, testMemberID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.True(t, starred)
+
+		_, starred, err = FileStars(Connection, testUsername, testAlias, testUserID)
+		assert.NoError(t, err)
+		assert.False(t, starred)
+	})
+
+	t.Run("starred files", func(t *testing.T) {
+		files, err := StarredFiles(Connection, testMemberName, 0, nil)
+		assert.NoError(t, err)
+		if assert.Len(t, files, 1) {
+			assert.Equal(t, testUsername, files[0].Username)
+			assert.Equal(t, testAlias, files[0].Alias)
+			assert.Equal(t, 1, files[0].Stars)
+		}
+	})
+
+	t.Run("unlisted files are only listed for the starrer", func(t *testing.T) {
+		failIf(t, file.SetVisibility(Connection, FileVisibilityUnlisted))
+
+		files, err := StarredFiles(Connection, testMemberName, 0, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, files)
+
+		files, err = StarredFiles(Connection, testMemberName, testMemberID, nil)
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+	})
+
+	t.Run("error when file is private", func(t *testing.T) {
+		failIf(t, file.SetVisibility(Connection, FileVisibilityPrivate))
+		assert.True(t, NotFound(Star(Connection, testMemberID, testUsername, testAlias)))
+	})
+
+	t.Run("unstar", func(t *testing.T) {
+		assert.NoError(t, Unstar(Connection, testMemberID, testUsername, testAlias))
+
+		count, _, err := FileStars(Connection, testUsername, testAlias, testMemberID)
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
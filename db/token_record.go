@@ -0,0 +1,314 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/knoebber/usererror"
+	"github.com/pkg/errors"
+)
+
+// TokenScope is a permission that an API token grants.
+type TokenScope string
+
+// Valid values for TokenScope.
+const (
+	TokenScopeRead  TokenScope = "read"      // Read private resources.
+	TokenScopePush  TokenScope = "push"      // Push files and revisions.
+	TokenScopeAdmin TokenScope = "admin-api" // Rename, move, delete, and restore files.
+)
+
+// TokenScopes are all of the scopes that a token may be granted.
+var TokenScopes = []TokenScope{TokenScopeRead, TokenScopePush, TokenScopeAdmin}
+
+const maxTokensPerUser = 20
+
+// ErrTokenScope is returned when a valid token is missing a required scope.
+var ErrTokenScope = errors.New("token does not have the required scope")
+
+// TokenRecord models the tokens table.
+// Tokens are named, scoped, and revocable credentials for the API.
+type TokenRecord struct {
+	ID         int64
+	UserID     int64  `validate:"required"`
+	Name       string `validate:"required"`
+	TokenHash  string `validate:"required"`
+	Scopes     string `validate:"required"` // Space separated list of TokenScope.
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	LastUsedIP *string
+	CreatedAt  time.Time
+}
+
+// TokenSummary is a view of a token for its owner.
+// It does not include the secret value.
+type TokenSummary struct {
+	ID         int64
+	Name       string
+	Scopes     string
+	ExpiresAt  string
+	Expired    bool
+	LastUsedAt string
+	LastUsedIP string
+	CreatedAt  string
+}
+
+func (*TokenRecord) createStmt() string {
+	return `
+CREATE TABLE IF NOT EXISTS tokens(
+id           INTEGER PRIMARY KEY,
+user_id      INTEGER NOT NULL REFERENCES users,
+name         TEXT NOT NULL,
+token_hash   TEXT NOT NULL UNIQUE,
+scopes       TEXT NOT NULL,
+expires_at   DATETIME,
+last_used_at DATETIME,
+last_used_ip TEXT,
+created_at   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tokens_user_name_index ON tokens(user_id, name);`
+}
+
+func (t *TokenRecord) insertStmt(e Executor) (sql.Result, error) {
+	return e.Exec(`
+INSERT INTO tokens(user_id, name, token_hash, scopes, expires_at) VALUES(?, ?, ?, ?, ?)`,
+		t.UserID,
+		t.Name,
+		t.TokenHash,
+		t.Scopes,
+		t.ExpiresAt,
+	)
+}
+
+func (t *TokenRecord) check(e Executor) error {
+	var count int
+
+	if err := validateStringSizes(t.Name); err != nil {
+		return err
+	}
+
+	for _, s := range strings.Fields(t.Scopes) {
+		if !validTokenScope(TokenScope(s)) {
+			return usererror.Format("Token scope %q is invalid.", s)
+		}
+	}
+
+	if t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()) {
+		return usererror.New("Token expiration must be in the future.")
+	}
+
+	err := e.QueryRow(`
+SELECT COUNT(*) FROM tokens WHERE user_id = ? AND name = ?`, t.UserID, t.Name).Scan(&count)
+	if err != nil {
+		return errors.Wrapf(err, "counting tokens named %q", t.Name)
+	}
+	if count > 0 {
+		return usererror.Format("Token %q already exists.", t.Name)
+	}
+
+	err = e.QueryRow("SELECT COUNT(*) FROM tokens WHERE user_id = ?", t.UserID).Scan(&count)
+	if err != nil {
+		return errors.Wrapf(err, "counting tokens for user %d", t.UserID)
+	}
+	if count >= maxTokensPerUser {
+		return usererror.Format("Maximum amount of tokens reached (%d).", maxTokensPerUser)
+	}
+
+	return nil
+}
+
+func validTokenScope(scope TokenScope) bool {
+	for _, s := range TokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasScope(scopes string, scope TokenScope) bool {
+	for _, s := range strings.Fields(scopes) {
+		if TokenScope(s) == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CreateToken creates a new API token for a user.
+// Returns the secret value of the token; only its hash is saved.
+func CreateToken(e Executor, userID int64, name string, scopes []TokenScope, expiresAt *time.Time) (string, error) {
+	if len(scopes) == 0 {
+		return "", usererror.New("Token must have at least one scope.")
+	}
+
+	value, err := token()
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, len(scopes))
+	for i, s := range scopes {
+		names[i] = string(s)
+	}
+
+	t := &TokenRecord{
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		TokenHash: hashToken(value),
+		Scopes:    strings.Join(names, " "),
+		ExpiresAt: expiresAt,
+	}
+
+	if _, err := insert(e, t); err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+// Tokens returns a summary of a user's API tokens.
+func Tokens(e Executor, userID int64, timezone *string) ([]TokenSummary, error) {
+	var (
+		expiresAt  *time.Time
+		lastUsedAt *time.Time
+		lastUsedIP *string
+		createdAt  time.Time
+		result     []TokenSummary
+	)
+
+	rows, err := e.Query(`
+SELECT id,
+       name,
+       scopes,
+       expires_at,
+       last_used_at,
+       last_used_ip,
+       created_at
+FROM tokens
+WHERE user_id = ?
+ORDER BY name`, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying tokens for user %d", userID)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t := TokenSummary{}
+
+		if err := rows.Scan(
+			&t.ID,
+			&t.Name,
+			&t.Scopes,
+			&expiresAt,
+			&lastUsedAt,
+			&lastUsedIP,
+			&createdAt,
+		); err != nil {
+			return nil, errors.Wrapf(err, "scanning tokens for user %d", userID)
+		}
+
+		if expiresAt != nil {
+			t.ExpiresAt = formatTime(*expiresAt, timezone)
+			t.Expired = expiresAt.Before(time.Now())
+		}
+		if lastUsedAt != nil {
+			t.LastUsedAt = formatTime(*lastUsedAt, timezone)
+		}
+		if lastUsedIP != nil {
+			t.LastUsedIP = *lastUsedIP
+		}
+		t.CreatedAt = formatTime(createdAt, timezone)
+
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+// RevokeToken deletes a user's API token.
+func RevokeToken(e Executor, userID, tokenID int64) error {
+	res, err := e.Exec("DELETE FROM tokens WHERE id = ? AND user_id = ?", tokenID, userID)
+	if err != nil {
+		return errors.Wrapf(err, "revoking token %d", tokenID)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return usererror.New("Token not found.")
+	}
+
+	return nil
+}
+
+// Finds the token that matches value.
+// Every token is compared so that timing doesn't reveal which one matched.
+func findToken(e Executor, username, value string) (*TokenRecord, error) {
+	var match *TokenRecord
+
+	rows, err := e.Query(`
+SELECT tokens.id,
+       user_id,
+       token_hash,
+       scopes,
+       expires_at
+FROM tokens
+JOIN users ON users.id = user_id
+WHERE username = ?`, username)
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying tokens for user %q", username)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t := new(TokenRecord)
+		if err := rows.Scan(&t.ID, &t.UserID, &t.TokenHash, &t.Scopes, &t.ExpiresAt); err != nil {
+			return nil, errors.Wrapf(err, "scanning tokens for user %q", username)
+		}
+		if tokenMatches(value, t.TokenHash) {
+			match = t
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if match == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return match, nil
+}
+
+// Checks a username and API token combination.
+// Records the token usage on success.
+func tokenLogin(e Executor, username, value string, scope TokenScope, ip string) (int64, error) {
+	t, err := findToken(e, username, value)
+	if err != nil {
+		return 0, err
+	}
+
+	if t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()) {
+		return 0, errors.Errorf("token %d for user %q is expired", t.ID, username)
+	}
+
+	if !hasScope(t.Scopes, scope) {
+		return 0, errors.Wrapf(ErrTokenScope, "token %d for user %q missing %q", t.ID, username, scope)
+	}
+
+	_, err = e.Exec(`
+UPDATE tokens
+SET last_used_at = ?, last_used_ip = ?
+WHERE id = ?`, time.Now(), ip, t.ID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "recording usage of token %d", t.ID)
+	}
+
+	return t.UserID, nil
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testTokenName = "laptop"
+
+func TestCreateToken(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testUserID, testUsername, testEmail)
+
+	t.Run("error without scopes", func(t *testing.T) {
+		_, err := CreateToken(Connection, testUserID, testTokenName, nil, nil)
+		assertUsererror(t, err)
+	})
+
+	t.Run("error on invalid scope", func(t *testing.T) {
+		_, err := CreateToken(Connection, testUserID, testTokenName, []TokenScope{"invalid"}, nil)
+		assertUsererror(t, err)
+	})
+
+	t.Run("error on past expiration", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		_, err := CreateToken(Connection, testUserID, testTokenName, []TokenScope{TokenScopePush}, &expiresAt)
+		assertUsererror(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		token, err := CreateToken(Connection, testUserID, testTokenName, []TokenScope{TokenScopePush}, nil)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+	})
+
+	t.Run("error on duplicate name", func(t *testing.T) {
+		_, err := CreateToken(Connection, testUserID, testTokenName, []TokenScope{TokenScopePush}, nil)
+		assertUsererror(t, err)
+	})
+}
+
+func TestTokens(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testUserID, testUsername, testEmail)
+
+	expiresAt := time.Now().Add(time.Hour)
+	_, err := CreateToken(Connection, testUserID, testTokenName, TokenScopes, &expiresAt)
+	failIf(t, err, "creating test token")
+
+	tokens, err := Tokens(Connection, testUserID, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, tokens, 1) {
+		assert.Equal(t, testTokenName, tokens[0].Name)
+		assert.Equal(t, "read push admin-api", tokens[0].Scopes)
+		assert.NotEmpty(t, tokens[0].ExpiresAt)
+		assert.False(t, tokens[0].Expired)
+		assert.Empty(t, tokens[0].LastUsedAt)
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testUserID, testUsername, testEmail)
+	createTestUser(t, testUserID+1, testUsername+"1", "other@example.com")
+
+	token, err := CreateToken(Connection, testUserID, testTokenName, []TokenScope{TokenScopePush}, nil)
+	failIf(t, err, "creating test token")
+	tokens, err := Tokens(Connection, testUserID, nil)
+	failIf(t, err, "listing test tokens")
+
+	t.Run("error when user doesn't own token", func(t *testing.T) {
+		assertUsererror(t, RevokeToken(Connection, testUserID+1, tokens[0].ID))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		assert.NoError(t, RevokeToken(Connection, testUserID, tokens[0].ID))
+
+		_, err := UserLoginAPI(Connection, testUsername, token, TokenScopePush, "")
+		assert.True(t, NotFound(err))
+	})
+}
+
+func TestUserLoginAPI(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testUserID, testUsername, testEmail)
+
+	pushToken, err := CreateToken(Connection, testUserID, "push", []TokenScope{TokenScopePush}, nil)
+	failIf(t, err, "creating push token")
+
+	expiresAt := time.Now().Add(time.Hour)
+	expiredToken, err := CreateToken(Connection, testUserID, "expired", TokenScopes, &expiresAt)
+	failIf(t, err, "creating expired token")
+	_, err = Connection.Exec("UPDATE tokens SET expires_at = ? WHERE name = 'expired'", time.Now().Add(-time.Hour))
+	failIf(t, err, "expiring token")
+
+	t.Run("cli token has every scope", func(t *testing.T) {
+		for _, scope := range TokenScopes {
+			userID, err := UserLoginAPI(Connection, testUsername, testCliToken, scope, "")
+			assert.NoError(t, err)
+			assert.Equal(t, int64(testUserID), userID)
+		}
+	})
+
+	t.Run("error when scope is missing", func(t *testing.T) {
+		_, err := UserLoginAPI(Connection, testUsername, pushToken, TokenScopeAdmin, "")
+		assert.ErrorIs(t, err, ErrTokenScope)
+	})
+
+	t.Run("error when token is expired", func(t *testing.T) {
+		_, err := UserLoginAPI(Connection, testUsername, expiredToken, TokenScopeRead, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("error on wrong username", func(t *testing.T) {
+		_, err := UserLoginAPI(Connection, "other", pushToken, TokenScopePush, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("ok records usage", func(t *testing.T) {
+		userID, err := UserLoginAPI(Connection, testUsername, pushToken, TokenScopePush, "127.0.0.1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(testUserID), userID)
+
+		tokens, err := Tokens(Connection, testUserID, nil)
+		failIf(t, err, "listing test tokens")
+		for _, token := range tokens {
+			if token.Name == "push" {
+				assert.NotEmpty(t, token.LastUsedAt)
+				assert.Equal(t, "127.0.0.1", token.LastUsedIP)
+			}
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package db
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"strings"
@@ -31,8 +34,9 @@ type UserRecord struct {
 	Email              string `validate:"omitempty,email"` // Not required; users may opt in to enable account recovery.
 	EmailConfirmed     bool
 	PasswordHash       []byte
-	CLIToken           string `validate:"required"` // Allows CLI to write to server.
+	CLIToken           string `validate:"required"` // Allows CLI to write to server. Stored as a hash.
 	PasswordResetToken *string
+	IsOrg              bool // Organizations can't log in; their members manage their files.
 	Theme              string
 	Timezone           string
 	CreatedAt          string
@@ -41,16 +45,19 @@ type UserRecord struct {
 func (*UserRecord) createStmt() string {
 	return `
 CREATE TABLE IF NOT EXISTS users(
-id                   INTEGER PRIMARY KEY,
-username             TEXT NOT NULL UNIQUE COLLATE NOCASE,
-email                TEXT UNIQUE,
-email_confirmed      INTEGER NOT NULL DEFAULT 0,
-password_hash        BLOB NOT NULL,
-cli_token            TEXT NOT NULL,
-password_reset_token TEXT,
-timezone             TEXT,
-theme                TEXT NOT NULL DEFAULT "Light",
-created_at           DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+id                        INTEGER PRIMARY KEY,
+username                  TEXT NOT NULL UNIQUE COLLATE NOCASE,
+email                     TEXT UNIQUE,
+email_confirmed           INTEGER NOT NULL DEFAULT 0,
+password_hash             BLOB NOT NULL,
+cli_token                 TEXT NOT NULL,
+password_reset_token      TEXT,
+password_reset_expires_at DATETIME,
+is_org                    INTEGER NOT NULL DEFAULT 0,
+feed_token                TEXT,
+timezone                  TEXT,
+theme                     TEXT NOT NULL DEFAULT "Light",
+created_at                DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 CREATE INDEX IF NOT EXISTS users_username_index ON users(username);`
 }
@@ -61,11 +68,12 @@ func (u *UserRecord) insertStmt(e Executor) (sql.Result, error) {
 		u.Email = strings.ToLower(u.Email)
 		email = &u.Email
 	}
-	return e.Exec("INSERT INTO users(username, email, password_hash, cli_token) VALUES(?, ?, ?, ?)",
+	return e.Exec("INSERT INTO users(username, email, password_hash, cli_token, is_org) VALUES(?, ?, ?, ?, ?)",
 		u.Username,
 		email,
 		u.PasswordHash,
-		u.CLIToken,
+		hashToken(u.CLIToken),
+		u.IsOrg,
 	)
 }
 
@@ -142,8 +150,9 @@ func ValidateUserExists(e Executor, username string) error {
 }
 
 // SetPasswordResetToken creates and saves a reset token for the user.
+// The token expires after expiry or once it is used.
 // Returns the newly created token.
-func SetPasswordResetToken(e Executor, email string) (string, error) {
+func SetPasswordResetToken(e Executor, email string, expiry time.Duration) (string, error) {
 	token, err := token()
 	if err != nil {
 		return "", err
@@ -151,8 +160,8 @@ func SetPasswordResetToken(e Executor, email string) (string, error) {
 
 	res, err := e.Exec(`
 UPDATE users
-SET password_reset_token = ?
-WHERE email = ?`, token, email)
+SET password_reset_token = ?, password_reset_expires_at = ?
+WHERE email = ?`, hashToken(token), time.Now().Add(expiry), email)
 	if err != nil {
 		return "", errors.Wrapf(err, "setting password reset token for %q", email)
 	}
@@ -169,28 +178,35 @@ WHERE email = ?`, token, email)
 	return token, nil
 }
 
-// CheckPasswordResetToken checks if the password reset token exists.
+// CheckPasswordResetToken checks if the password reset token exists and has not expired.
 // Returns username for the token on success.
 func CheckPasswordResetToken(e Executor, token string) (string, error) {
 	var (
-		count    int
-		username *string
+		count     int
+		username  *string
+		tokenHash *string
+		expiresAt *time.Time
 	)
 
 	err := e.QueryRow(`
 SELECT COUNT(*),
-       username
+       username,
+       password_reset_token,
+       password_reset_expires_at
 FROM users
-WHERE password_reset_token = ?`, token).
-		Scan(&count, &username)
+WHERE password_reset_token = ?`, hashToken(token)).
+		Scan(&count, &username, &tokenHash, &expiresAt)
 	if err != nil {
 		return "", errors.Wrap(err, "counting users for password reset")
 	}
-	if count == 0 {
+	if count == 0 || !tokenMatches(token, *tokenHash) {
 		return "", usererror.New("Token not found")
 	}
 	if count > 1 {
-		return "", fmt.Errorf("token %q has %d matches", token, count)
+		return "", fmt.Errorf("password reset token has %d matches", count)
+	}
+	if expiresAt == nil || expiresAt.Before(time.Now()) {
+		return "", usererror.New("Token is expired")
 	}
 
 	return *username, nil
@@ -198,6 +214,7 @@ WHERE password_reset_token = ?`, token).
 }
 
 // ResetPassword hashes and sets a new password to the user with the password reset token.
+// The token is cleared so that it can't be used again.
 func ResetPassword(e Executor, token, newPassword string) (string, error) {
 	username, err := CheckPasswordResetToken(e, token)
 	if err != nil {
@@ -211,7 +228,7 @@ func ResetPassword(e Executor, token, newPassword string) (string, error) {
 
 	_, err = e.Exec(`
 UPDATE users
-SET password_hash = ?, password_reset_token = NULL
+SET password_hash = ?, password_reset_token = NULL, password_reset_expires_at = NULL
 WHERE username = ?`, passwordHash, username)
 	if err != nil {
 		return "", errors.Wrapf(err, "resetting %q password", username)
@@ -280,30 +297,55 @@ func UpdateTimezone(e Executor, userID int64, timezone string) error {
 }
 
 // RotateCLIToken creates a new CLI token for the user.
-func RotateCLIToken(e Executor, userID int64, currentToken string) error {
+// Returns the new token; only its hash is saved.
+func RotateCLIToken(e Executor, userID int64) (string, error) {
 	newToken, err := token()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	res, err := e.Exec(`
-UPDATE users
-SET cli_token = ?
-WHERE id = ? AND cli_token = ?`, newToken, userID, currentToken)
+	_, err = e.Exec("UPDATE users SET cli_token = ? WHERE id = ?", hashToken(newToken), userID)
 	if err != nil {
-		return errors.Wrap(err, "rotating cli token")
+		return "", errors.Wrap(err, "rotating cli token")
 	}
 
-	affected, err := res.RowsAffected()
+	return newToken, nil
+}
+
+// RotateFeedToken creates a new token for the user's private feed URL.
+// Returns the new token; only its hash is saved.
+func RotateFeedToken(e Executor, userID int64) (string, error) {
+	newToken, err := token()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if affected == 0 {
-		return usererror.New("User token mismatch")
+	_, err = e.Exec("UPDATE users SET feed_token = ? WHERE id = ?", hashToken(newToken), userID)
+	if err != nil {
+		return "", errors.Wrap(err, "rotating feed token")
 	}
 
-	return err
+	return newToken, nil
+}
+
+// FeedTokenUserID returns the ID of username when token is their feed token.
+// Returns sql.ErrNoRows when it does not match.
+func FeedTokenUserID(e Executor, username, token string) (int64, error) {
+	var (
+		userID    int64
+		tokenHash *string
+	)
+
+	err := e.QueryRow("SELECT id, feed_token FROM users WHERE username = ?", username).
+		Scan(&userID, &tokenHash)
+	if err != nil {
+		return 0, errors.Wrapf(err, "querying feed token for %q", username)
+	}
+	if tokenHash == nil || !tokenMatches(token, *tokenHash) {
+		return 0, errors.Wrapf(sql.ErrNoRows, "feed token for %q does not match", username)
+	}
+
+	return userID, nil
 }
 
 // CheckPassword checks username and password combination.
@@ -358,16 +400,27 @@ func UserLogin(e Executor, username, password, ip string) (*SessionRecord, error
 	return createSession(e, username, ip)
 }
 
-// UserLoginAPI checks a username and CLI token combination.
-func UserLoginAPI(e Executor, username, cliToken string) (int64, error) {
-	var userID int64
+// UserLoginAPI checks a username and API token combination.
+// The token must grant scope when it is a named API token.
+// The CLI token is accepted for every scope.
+func UserLoginAPI(e Executor, username, token string, scope TokenScope, ip string) (int64, error) {
+	var cliToken string
 
-	err := e.QueryRow(`
-SELECT id FROM users WHERE username = ? AND cli_token = ?`, username, cliToken).
-		Scan(&userID)
+	userID, err := tokenLogin(e, username, token, scope, ip)
+	if err == nil {
+		return userID, nil
+	} else if !NotFound(err) {
+		return 0, errors.Wrapf(err, "attempting to authenticate user %q for API access", username)
+	}
+
+	err = e.QueryRow("SELECT id, cli_token FROM users WHERE username = ?", username).
+		Scan(&userID, &cliToken)
 	if err != nil {
 		return 0, errors.Wrapf(err, "attempting to authenticate user %q for API access", username)
 	}
+	if !tokenMatches(token, cliToken) {
+		return 0, errors.Wrapf(sql.ErrNoRows, "attempting to authenticate user %q for API access", username)
+	}
 
 	return userID, nil
 }
@@ -408,7 +461,12 @@ func deleteUser(tx *sql.Tx, username, password string) error {
 		return err
 	}
 
-	fileList, err := FilesByUsername(tx, username, nil)
+	var userID int64
+	if err := tx.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&userID); err != nil {
+		return errors.Wrapf(err, "querying id for user %q", username)
+	}
+
+	fileList, err := FilesByUsername(tx, username, userID, nil)
 	if err != nil {
 		return err
 	}
@@ -419,6 +477,25 @@ func deleteUser(tx *sql.Tx, username, password string) error {
 		}
 	}
 
+	if err := leaveOrgs(tx, userID); err != nil {
+		return err
+	}
+
+	if err := deleteFollows(tx, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM stars WHERE user_id = ?", userID); err != nil {
+		return errors.Wrapf(err, "deleting stars for user %q", username)
+	}
+
+	_, err = tx.Exec(`
+DELETE FROM tokens
+WHERE user_id = (SELECT id FROM users WHERE username = ?)`, username)
+	if err != nil {
+		return errors.Wrapf(err, "deleting tokens for user %q", username)
+	}
+
 	_, err = tx.Exec(`
 DELETE FROM sessions 
 WHERE user_id = (SELECT id FROM users WHERE username = ?)`, username)
@@ -442,3 +519,15 @@ func token() (string, error) {
 
 	return fmt.Sprintf("%x", buff), nil
 }
+
+// Hashes a token for storage.
+// Tokens are long and random so a fast hash is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Compares a token to a stored hash in constant time.
+func tokenMatches(token, tokenHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(tokenHash)) == 1
+}
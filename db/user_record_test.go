@@ -3,6 +3,7 @@ package db
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -68,6 +69,18 @@ func TestCheckPasswordResetToken(t *testing.T) {
 		assert.Equal(t, testUsername, username)
 	})
 
+	t.Run("expired token error", func(t *testing.T) {
+		_, err := Connection.Exec("UPDATE users SET password_reset_expires_at = ?", time.Now().Add(-time.Minute))
+		failIf(t, err, "expiring reset token")
+		defer func() {
+			_, err := Connection.Exec("UPDATE users SET password_reset_expires_at = ?", time.Now().Add(time.Hour))
+			failIf(t, err, "restoring reset token")
+		}()
+
+		_, err = CheckPasswordResetToken(Connection, testPasswordResetToken)
+		assertUsererror(t, err)
+	})
+
 	t.Run("multiple token error", func(t *testing.T) {
 		createTestUser(t, testUserID+1, testUsername+"2", "other@example.com")
 		_, err := CheckPasswordResetToken(Connection, testPasswordResetToken)
@@ -75,3 +88,60 @@ func TestCheckPasswordResetToken(t *testing.T) {
 	})
 
 }
+
+func TestSetPasswordResetToken(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testUserID, testUsername, testEmail)
+
+	token, err := SetPasswordResetToken(Connection, testEmail, time.Hour)
+	assert.NoError(t, err)
+
+	t.Run("token is stored as a hash", func(t *testing.T) {
+		var stored string
+		err := Connection.QueryRow("SELECT password_reset_token FROM users WHERE id = ?", testUserID).Scan(&stored)
+		failIf(t, err, "querying reset token")
+		assert.NotEqual(t, token, stored)
+	})
+
+	t.Run("token is single use", func(t *testing.T) {
+		username, err := ResetPassword(Connection, token, testPassword)
+		assert.NoError(t, err)
+		assert.Equal(t, testUsername, username)
+
+		_, err = ResetPassword(Connection, token, testPassword)
+		assertUsererror(t, err)
+	})
+}
+
+func TestRotateCLIToken(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testUserID, testUsername, testEmail)
+
+	token, err := RotateCLIToken(Connection, testUserID)
+	assert.NoError(t, err)
+
+	_, err = UserLoginAPI(Connection, testUsername, testCliToken, TokenScopePush, "")
+	assert.Error(t, err)
+
+	userID, err := UserLoginAPI(Connection, testUsername, token, TokenScopePush, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(testUserID), userID)
+}
+
+func TestRotateFeedToken(t *testing.T) {
+	createTestDB(t)
+	createTestUser(t, testUserID, testUsername, testEmail)
+
+	_, err := FeedTokenUserID(Connection, testUsername, "")
+	assert.True(t, NotFound(err))
+
+	token, err := RotateFeedToken(Connection, testUserID)
+	assert.NoError(t, err)
+
+	_, err = FeedTokenUserID(Connection, testUsername, testCliToken)
+	assert.True(t, NotFound(err))
+
+	userID, err := FeedTokenUserID(Connection, testUsername, token)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(testUserID), userID)
+}
@@ -13,7 +13,6 @@ type UserSession struct {
 	UserID        int64
 	Username      string
 	Email         *string
-	CLIToken      string
 	Timezone      *string
 	Theme         UserTheme
 	UserCreatedAt string
@@ -31,7 +30,6 @@ SELECT session,
        user_id,
        username,
        email,
-       cli_token,
        timezone,
        theme,
        users.created_at
@@ -44,7 +42,6 @@ WHERE session = ?
 		&res.UserID,
 		&res.Username,
 		&res.Email,
-		&res.CLIToken,
 		&res.Timezone,
 		&res.Theme,
 		&createdAt,
@@ -0,0 +1,304 @@
+package dotfile
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+)
+
+// DefaultDiffContext is the default amount of unchanged lines around changes in a diff.
+const DefaultDiffContext = 3
+
+// Lines with more words than this are compared as a whole by WordDiff.
+const maxWordDiffWords = 200
+
+// DiffOptions changes how revisions are compared.
+type DiffOptions struct {
+	Context          int  // The amount of unchanged lines to show around changes.
+	IgnoreWhitespace bool // Lines that only differ in whitespace are equal.
+}
+
+// FileDiff is the difference between two revisions of a file.
+type FileDiff struct {
+	Hunks      []DiffHunk
+	Insertions int
+	Deletions  int
+}
+
+// DiffHunk is a group of changed lines and the unchanged lines around them.
+type DiffHunk struct {
+	FromLine, FromCount int // The lines in the old revision.
+	ToLine, ToCount     int // The lines in the new revision.
+	Lines               []DiffLine
+}
+
+// DiffLine is a line in a diff.
+// Equal lines have the content of the new revision.
+type DiffLine struct {
+	Kind    gotextdiff.OpKind
+	Content string // Without the newline.
+	OldLine int    // The line number in the old revision, zero for inserts.
+	NewLine int    // The line number in the new revision, zero for deletes.
+}
+
+// DiffSegment is a part of a changed line.
+type DiffSegment struct {
+	Text    string
+	Changed bool
+}
+
+// Stat summarizes the diff like "1 file changed, 2 insertions(+), 1 deletion(-)".
+func (d *FileDiff) Stat() string {
+	stat := "1 file changed"
+
+	if d.Insertions > 0 {
+		stat += fmt.Sprintf(", %d insertion%s(+)", d.Insertions, plural(d.Insertions))
+	}
+	if d.Deletions > 0 {
+		stat += fmt.Sprintf(", %d deletion%s(-)", d.Deletions, plural(d.Deletions))
+	}
+
+	return stat
+}
+
+// Header returns the range of the hunk like "@@ -10,7 +10,8 @@".
+func (h *DiffHunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.FromLine, h.FromCount, h.ToLine, h.ToCount)
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// Compares the lines of text1 and text2 and groups the changes into hunks.
+func diffText(text1, text2 string, options DiffOptions) *FileDiff {
+	result := new(FileDiff)
+
+	lines := diffLines(text1, text2, options.IgnoreWhitespace)
+	for _, line := range lines {
+		switch line.Kind {
+		case gotextdiff.Insert:
+			result.Insertions++
+		case gotextdiff.Delete:
+			result.Deletions++
+		}
+	}
+
+	result.Hunks = groupHunks(lines, options.Context)
+	return result
+}
+
+// Returns every line of the old and new text in diff order.
+func diffLines(text1, text2 string, ignoreWhitespace bool) []DiffLine {
+	var result []DiffLine
+
+	oldLines, newLines := lineContents(text1), lineContents(text2)
+	if ignoreWhitespace {
+		text1, text2 = removeWhitespace(oldLines), removeWhitespace(newLines)
+	}
+
+	// package gotextdiff is a copy from the internal gopls implementation, so it's not a perfect fit for this usecase.
+	// Its hunks always have three lines of context; the lines between them are equal.
+	unified := gotextdiff.ToUnified("", "", text1, myers.ComputeEdits("", text1, text2))
+
+	oldLine, newLine := 1, 1
+	equalUntil := func(line int) {
+		for ; oldLine < line && oldLine <= len(oldLines) && newLine <= len(newLines); oldLine, newLine = oldLine+1, newLine+1 {
+			result = append(result, DiffLine{Kind: gotextdiff.Equal, Content: newLines[newLine-1], OldLine: oldLine, NewLine: newLine})
+		}
+	}
+
+	for _, hunk := range unified.Hunks {
+		equalUntil(hunk.FromLine)
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case gotextdiff.Equal:
+				equalUntil(oldLine + 1)
+			case gotextdiff.Delete:
+				result = append(result, DiffLine{Kind: gotextdiff.Delete, Content: oldLines[oldLine-1], OldLine: oldLine})
+				oldLine++
+			case gotextdiff.Insert:
+				result = append(result, DiffLine{Kind: gotextdiff.Insert, Content: newLines[newLine-1], NewLine: newLine})
+				newLine++
+			}
+		}
+	}
+	equalUntil(len(oldLines) + 1)
+
+	return result
+}
+
+// Groups changed lines with context unchanged lines around them.
+// Changes that are close enough to share context are in the same hunk.
+func groupHunks(lines []DiffLine, context int) []DiffHunk {
+	var hunks []DiffHunk
+
+	if context < 0 {
+		context = 0
+	}
+
+	// The amount of old and new lines before each index.
+	oldBefore, newBefore := make([]int, len(lines)+1), make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldBefore[i+1], newBefore[i+1] = oldBefore[i], newBefore[i]
+		if line.Kind != gotextdiff.Insert {
+			oldBefore[i+1]++
+		}
+		if line.Kind != gotextdiff.Delete {
+			newBefore[i+1]++
+		}
+	}
+
+	addHunk := func(start, end int) {
+		h := DiffHunk{
+			FromLine:  oldBefore[start] + 1,
+			FromCount: oldBefore[end] - oldBefore[start],
+			ToLine:    newBefore[start] + 1,
+			ToCount:   newBefore[end] - newBefore[start],
+			Lines:     lines[start:end],
+		}
+		// Like unified diffs, empty ranges start at the line before them.
+		if h.FromCount == 0 {
+			h.FromLine--
+		}
+		if h.ToCount == 0 {
+			h.ToLine--
+		}
+		hunks = append(hunks, h)
+	}
+
+	start, end := -1, -1
+	for i, line := range lines {
+		if line.Kind == gotextdiff.Equal {
+			continue
+		}
+
+		low, high := i-context, i+context+1
+		if low < 0 {
+			low = 0
+		}
+		if high > len(lines) {
+			high = len(lines)
+		}
+
+		if start >= 0 && low <= end {
+			end = high
+			continue
+		}
+		if start >= 0 {
+			addHunk(start, end)
+		}
+		start, end = low, high
+	}
+	if start >= 0 {
+		addHunk(start, end)
+	}
+
+	return hunks
+}
+
+// Splits text into lines without their newlines.
+func lineContents(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Returns lines joined without any of their whitespace.
+func removeWhitespace(lines []string) string {
+	var b strings.Builder
+
+	for _, line := range lines {
+		_, _ = b.WriteString(strings.Join(strings.Fields(line), ""))
+		_ = b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// WordDiff compares two versions of a line word by word.
+// Words that are only in one version are changed.
+func WordDiff(oldLine, newLine string) (oldSegments, newSegments []DiffSegment) {
+	oldWords, newWords := splitWords(oldLine), splitWords(newLine)
+	if len(oldWords) > maxWordDiffWords || len(newWords) > maxWordDiffWords {
+		return []DiffSegment{{Text: oldLine, Changed: true}}, []DiffSegment{{Text: newLine, Changed: true}}
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of oldWords[i:] and newWords[j:].
+	lcs := make([][]int, len(oldWords)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newWords)+1)
+	}
+	for i := len(oldWords) - 1; i >= 0; i-- {
+		for j := len(newWords) - 1; j >= 0; j-- {
+			if oldWords[i] == newWords[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(oldWords) || j < len(newWords) {
+		switch {
+		case i < len(oldWords) && j < len(newWords) && oldWords[i] == newWords[j]:
+			oldSegments = appendSegment(oldSegments, oldWords[i], false)
+			newSegments = appendSegment(newSegments, newWords[j], false)
+			i++
+			j++
+		case j == len(newWords) || (i < len(oldWords) && lcs[i+1][j] >= lcs[i][j+1]):
+			oldSegments = appendSegment(oldSegments, oldWords[i], true)
+			i++
+		default:
+			newSegments = appendSegment(newSegments, newWords[j], true)
+			j++
+		}
+	}
+
+	return
+}
+
+// Adds text to the last segment when it has the same changed value.
+func appendSegment(segments []DiffSegment, text string, changed bool) []DiffSegment {
+	if n := len(segments); n > 0 && segments[n-1].Changed == changed {
+		segments[n-1].Text += text
+		return segments
+	}
+
+	return append(segments, DiffSegment{Text: text, Changed: changed})
+}
+
+// Splits a line into words, runs of whitespace, and single punctuation characters.
+func splitWords(line string) []string {
+	var words []string
+
+	isWord := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	start := 0
+	runes := []rune(line)
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) {
+			prev, curr := runes[i-1], runes[i]
+			if (isWord(prev) && isWord(curr)) || (unicode.IsSpace(prev) && unicode.IsSpace(curr)) {
+				continue
+			}
+		}
+		words = append(words, string(runes[start:i]))
+		start = i
+	}
+
+	return words
+}
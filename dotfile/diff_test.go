@@ -0,0 +1,78 @@
+package dotfile
+
+import (
+	"testing"
+
+	"github.com/hexops/gotextdiff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffText(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	new := "1\ntwo\n3\n4\n5\n6\n7\n8\n9\n10\neleven\n"
+
+	t.Run("context", func(t *testing.T) {
+		diff := diffText(old, new, DiffOptions{Context: 1})
+		assert.Equal(t, 1, diff.Deletions)
+		assert.Equal(t, 2, diff.Insertions)
+		assert.Equal(t, "1 file changed, 2 insertions(+), 1 deletion(-)", diff.Stat())
+
+		if assert.Len(t, diff.Hunks, 2) {
+			assert.Equal(t, "@@ -1,3 +1,3 @@", diff.Hunks[0].Header())
+			assert.Equal(t, []DiffLine{
+				{Kind: gotextdiff.Equal, Content: "1", OldLine: 1, NewLine: 1},
+				{Kind: gotextdiff.Delete, Content: "2", OldLine: 2},
+				{Kind: gotextdiff.Insert, Content: "two", NewLine: 2},
+				{Kind: gotextdiff.Equal, Content: "3", OldLine: 3, NewLine: 3},
+			}, diff.Hunks[0].Lines)
+
+			assert.Equal(t, "@@ -10,1 +10,2 @@", diff.Hunks[1].Header())
+		}
+	})
+
+	t.Run("hunks merge when their context overlaps", func(t *testing.T) {
+		diff := diffText(old, new, DiffOptions{Context: DefaultDiffContext + 2})
+		if assert.Len(t, diff.Hunks, 1) {
+			assert.Equal(t, "@@ -1,10 +1,11 @@", diff.Hunks[0].Header())
+		}
+	})
+
+	t.Run("no context", func(t *testing.T) {
+		diff := diffText(old, new, DiffOptions{})
+		if assert.Len(t, diff.Hunks, 2) {
+			assert.Len(t, diff.Hunks[0].Lines, 2)
+			assert.Equal(t, "@@ -10,0 +11,1 @@", diff.Hunks[1].Header())
+		}
+	})
+
+	t.Run("ignore whitespace", func(t *testing.T) {
+		diff := diffText("if x {\n\ty = 1\n}\n", "if x {\n    y  =  1\n}\nz\n", DiffOptions{Context: DefaultDiffContext, IgnoreWhitespace: true})
+		assert.Equal(t, 1, diff.Insertions)
+		assert.Zero(t, diff.Deletions)
+		if assert.Len(t, diff.Hunks, 1) {
+			// Equal lines have the new content.
+			assert.Equal(t, "    y  =  1", diff.Hunks[0].Lines[1].Content)
+		}
+
+		diff = diffText("a\n", " a \n", DiffOptions{IgnoreWhitespace: true})
+		assert.Empty(t, diff.Hunks)
+	})
+}
+
+func TestWordDiff(t *testing.T) {
+	oldSegments, newSegments := WordDiff("set tabstop=4 expandtab", "set tabstop=2 expandtab")
+	assert.Equal(t, []DiffSegment{
+		{Text: "set tabstop="},
+		{Text: "4", Changed: true},
+		{Text: " expandtab"},
+	}, oldSegments)
+	assert.Equal(t, []DiffSegment{
+		{Text: "set tabstop="},
+		{Text: "2", Changed: true},
+		{Text: " expandtab"},
+	}, newSegments)
+
+	oldSegments, newSegments = WordDiff("", "new")
+	assert.Empty(t, oldSegments)
+	assert.Equal(t, []DiffSegment{{Text: "new", Changed: true}}, newSegments)
+}
@@ -29,9 +29,10 @@ var (
 
 // TrackingData is the data that dotfile uses to track files.
 type TrackingData struct {
-	Path     string   `json:"path"`
-	Revision string   `json:"revision"`
-	Commits  []Commit `json:"commits"`
+	Path     string            `json:"path"`
+	Revision string            `json:"revision"`
+	Commits  []Commit          `json:"commits"`
+	Hosts    map[string]string `json:"hosts,omitempty"` // Maps hostnames to paths that override Path.
 }
 
 // Commit represents a file revision.
@@ -56,12 +57,36 @@ func (td *TrackingData) MapCommits() map[string]*Commit {
 	return result
 }
 
+// HostPath returns the path of the file on host.
+func (td *TrackingData) HostPath(host string) string {
+	if path, ok := td.Hosts[host]; ok {
+		return path
+	}
+
+	return td.Path
+}
+
+// SetHostPath sets the path of the file on host.
+// Removes the override when path is the same as Path.
+func (td *TrackingData) SetHostPath(host, path string) {
+	if path == td.Path {
+		delete(td.Hosts, host)
+		return
+	}
+
+	if td.Hosts == nil {
+		td.Hosts = make(map[string]string)
+	}
+	td.Hosts[host] = path
+}
+
 func hashContent(contents []byte) string {
 	return fmt.Sprintf("%x", sha1.Sum(contents))
 }
 
 // MergeTrackingData merges the new data into old.
 // Returns the merged data and a slice of the hashes that are new.
+// The merged path is the new path, host paths from new take precedence over old.
 func MergeTrackingData(old, new *TrackingData) (merged *TrackingData, newHashes []string, err error) {
 	if new == nil {
 		return nil, nil, errors.New("new tracking data must be set")
@@ -69,8 +94,6 @@ func MergeTrackingData(old, new *TrackingData) (merged *TrackingData, newHashes
 
 	if old == nil {
 		old = &TrackingData{}
-	} else if old.Path != new.Path {
-		return nil, nil, fmt.Errorf("merging tracking data: old path %q does not match new %q", old.Path, new.Path)
 	}
 
 	merged = &TrackingData{
@@ -79,6 +102,12 @@ func MergeTrackingData(old, new *TrackingData) (merged *TrackingData, newHashes
 		Commits:  old.Commits,
 	}
 
+	for _, hosts := range []map[string]string{old.Hosts, new.Hosts} {
+		for host, path := range hosts {
+			merged.SetHostPath(host, path)
+		}
+	}
+
 	newHashes = []string{}
 
 	oldMap := old.MapCommits()
@@ -92,3 +92,43 @@ func TestCompress(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestMergeTrackingData(t *testing.T) {
+	t.Run("error when new is nil", func(t *testing.T) {
+		_, _, err := MergeTrackingData(nil, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("different paths are merged", func(t *testing.T) {
+		old := &TrackingData{
+			Path:    "~/Library/Application Support/Code/User/settings.json",
+			Commits: []Commit{{Hash: "a", Timestamp: 1}},
+			Hosts:   map[string]string{"desktop": "/home/user/settings.json"},
+		}
+		new := &TrackingData{
+			Path:     "~/.config/Code/User/settings.json",
+			Revision: "b",
+			Commits:  []Commit{{Hash: "a", Timestamp: 1}, {Hash: "b", Timestamp: 2}},
+			Hosts:    map[string]string{"laptop": "~/.config/Code/User/settings.json"},
+		}
+
+		merged, newHashes, err := MergeTrackingData(old, new)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b"}, newHashes)
+		assert.Equal(t, new.Path, merged.Path)
+		assert.Equal(t, "b", merged.Revision)
+		assert.Len(t, merged.Commits, 2)
+		assert.Equal(t, map[string]string{"desktop": "/home/user/settings.json"}, merged.Hosts)
+	})
+}
+
+func TestTrackingData_HostPath(t *testing.T) {
+	td := &TrackingData{Path: "~/.bashrc"}
+
+	td.SetHostPath("laptop", "~/.bashrc_laptop")
+	assert.Equal(t, "~/.bashrc_laptop", td.HostPath("laptop"))
+	assert.Equal(t, "~/.bashrc", td.HostPath("desktop"))
+
+	td.SetHostPath("laptop", "~/.bashrc")
+	assert.Empty(t, td.Hosts)
+}
@@ -0,0 +1,114 @@
+package dotfile
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/knoebber/usererror"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted content starts with this line so that it stays readable as text on a server.
+const encryptedHeader = "$DOTFILE;AES256-GCM;v1\n"
+
+const (
+	saltSize      = 16
+	keySize       = 32
+	base64LineLen = 64
+)
+
+// IsEncrypted returns whether content was created with Encrypt.
+func IsEncrypted(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(encryptedHeader))
+}
+
+func encryptionCipher(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "deriving encryption key")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating encryption cipher")
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts plaintext with a key derived from passphrase.
+// The result is base64 text so that servers can store and show it like any other file.
+func Encrypt(passphrase, plaintext []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("encryption passphrase is empty")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "generating salt")
+	}
+
+	gcm, err := encryptionCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "generating nonce")
+	}
+
+	sealed := append(salt, nonce...)
+	sealed = gcm.Seal(sealed, nonce, plaintext, nil)
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+
+	result := bytes.NewBufferString(encryptedHeader)
+	for len(encoded) > base64LineLen {
+		result.WriteString(encoded[:base64LineLen] + "\n")
+		encoded = encoded[base64LineLen:]
+	}
+	result.WriteString(encoded + "\n")
+
+	return result.Bytes(), nil
+}
+
+// Decrypt decrypts content that was created by Encrypt.
+func Decrypt(passphrase, content []byte) ([]byte, error) {
+	if !IsEncrypted(content) {
+		return nil, errors.New("content is not encrypted")
+	}
+
+	encoded := bytes.ReplaceAll(content[len(encryptedHeader):], []byte("\n"), nil)
+	sealed := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+
+	n, err := base64.StdEncoding.Decode(sealed, encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding encrypted content")
+	}
+	sealed = sealed[:n]
+
+	if len(sealed) < saltSize {
+		return nil, errors.New("encrypted content is too short")
+	}
+
+	gcm, err := encryptionCipher(passphrase, sealed[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	sealed = sealed[saltSize:]
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted content is too short")
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, usererror.New("Failed to decrypt content (wrong encryption key?)")
+	}
+
+	return plaintext, nil
+}
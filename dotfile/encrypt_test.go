@@ -0,0 +1,43 @@
+package dotfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncrypt(t *testing.T) {
+	passphrase := []byte("secret")
+	plaintext := []byte(strings.Repeat("export SECRET=value\n", 10))
+
+	t.Run("error when passphrase is empty", func(t *testing.T) {
+		_, err := Encrypt(nil, plaintext)
+		assert.Error(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		encrypted, err := Encrypt(passphrase, plaintext)
+		assert.NoError(t, err)
+		assert.True(t, IsEncrypted(encrypted))
+		assert.NotContains(t, string(encrypted), "SECRET")
+
+		decrypted, err := Decrypt(passphrase, encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("error when passphrase is wrong", func(t *testing.T) {
+		encrypted, err := Encrypt(passphrase, plaintext)
+		assert.NoError(t, err)
+
+		_, err = Decrypt([]byte("wrong"), encrypted)
+		assert.Error(t, err)
+	})
+
+	t.Run("error when content is not encrypted", func(t *testing.T) {
+		assert.False(t, IsEncrypted(plaintext))
+		_, err := Decrypt(passphrase, plaintext)
+		assert.Error(t, err)
+	})
+}
@@ -2,9 +2,6 @@ package dotfile
 
 import (
 	"bytes"
-
-	"github.com/hexops/gotextdiff"
-	"github.com/hexops/gotextdiff/myers"
 )
 
 // Getter is an interface that wraps methods for reading tracked files.
@@ -38,10 +35,10 @@ func IsClean(g Getter, hash string) (bool, error) {
 	return hash == hashContent(contents), nil
 }
 
-// Runs a diff on the revision at hash1 against the revision at hash2.
+// Diff runs a diff on the revision at hash1 against the revision at hash2.
 // If hash2 is empty, compares the dirty content of the file.
 // Returns an usererror when there is no difference.
-func Diff(g Getter, hash1, hash2 string) (*gotextdiff.Unified, error) {
+func Diff(g Getter, hash1, hash2 string, options DiffOptions) (*FileDiff, error) {
 	var text1, text2 string
 
 	revision1, err := UncompressRevision(g, hash1)
@@ -65,11 +62,9 @@ func Diff(g Getter, hash1, hash2 string) (*gotextdiff.Unified, error) {
 		text2 = revision2.String()
 	}
 
-	// package gotextdiff is a copy from the internal gopls implementation, so it's not a perfect fit for this usecase.
-	edits := myers.ComputeEdits("", text1, text2)
-	diff := gotextdiff.ToUnified("", "", text1, edits)
+	diff := diffText(text1, text2, options)
 	if len(diff.Hunks) == 0 {
 		return nil, ErrNoChanges
 	}
-	return &diff, nil
+	return diff, nil
 }
@@ -51,19 +51,19 @@ func TestIsClean(t *testing.T) {
 func TestDiff(t *testing.T) {
 	t.Run("uncompress error", func(t *testing.T) {
 		s := &MockStorer{uncompressErr: true}
-		_, err := Diff(s, testHash, testHash)
+		_, err := Diff(s, testHash, testHash, DiffOptions{})
 		assert.Error(t, err)
 	})
 
 	t.Run("get content error", func(t *testing.T) {
 		s := &MockStorer{dirtyContentErr: true}
-		_, err := Diff(s, testHash, "")
+		_, err := Diff(s, testHash, "", DiffOptions{})
 		assert.Error(t, err)
 	})
 
 	t.Run("no changes error", func(t *testing.T) {
 		s := &MockStorer{}
-		_, err := Diff(s, testHash, testHash)
+		_, err := Diff(s, testHash, testHash, DiffOptions{})
 		assert.True(t, errors.Is(err, ErrNoChanges))
 	})
 
@@ -0,0 +1,157 @@
+package dotfile
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Conflict markers that are written around lines that changed in both versions.
+const (
+	conflictStart  = "<<<<<<< "
+	conflictMiddle = "=======\n"
+	conflictEnd    = ">>>>>>> "
+)
+
+// Merge does a three way merge of lines in ours and theirs that changed from base.
+// Lines that changed in both are written between conflict markers that are labeled with ours and theirs.
+// Returns the merged content and the number of conflicts.
+func Merge(base, ours, theirs []byte, oursLabel, theirsLabel string) (merged []byte, conflicts int) {
+	baseLines := splitLines(base)
+	ourLines := splitLines(ours)
+	theirLines := splitLines(theirs)
+
+	ourMatches := matchLines(baseLines, ourLines)
+	theirMatches := matchLines(baseLines, theirLines)
+
+	result := new(bytes.Buffer)
+	i, o, t := 0, 0, 0
+
+	for i < len(baseLines) || o < len(ourLines) || t < len(theirLines) {
+		// Copy lines that are the same in all three.
+		stable := 0
+		for i+stable < len(baseLines) &&
+			ourMatches[i+stable] == o+stable &&
+			theirMatches[i+stable] == t+stable {
+			stable++
+		}
+		if stable > 0 {
+			writeLines(result, baseLines[i:i+stable])
+			i, o, t = i+stable, o+stable, t+stable
+			continue
+		}
+
+		// Find the next base line that is in both ours and theirs.
+		next := i
+		for next < len(baseLines) && (ourMatches[next] < 0 || theirMatches[next] < 0) {
+			next++
+		}
+
+		oEnd, tEnd := len(ourLines), len(theirLines)
+		if next < len(baseLines) {
+			oEnd, tEnd = ourMatches[next], theirMatches[next]
+		}
+
+		baseChunk := baseLines[i:next]
+		ourChunk := ourLines[o:oEnd]
+		theirChunk := theirLines[t:tEnd]
+
+		switch {
+		case equalLines(ourChunk, baseChunk):
+			writeLines(result, theirChunk)
+		case equalLines(theirChunk, baseChunk), equalLines(ourChunk, theirChunk):
+			writeLines(result, ourChunk)
+		default:
+			conflicts++
+			result.WriteString(conflictStart + oursLabel + "\n")
+			writeConflictLines(result, ourChunk)
+			result.WriteString(conflictMiddle)
+			writeConflictLines(result, theirChunk)
+			result.WriteString(conflictEnd + theirsLabel + "\n")
+		}
+
+		i, o, t = next, oEnd, tEnd
+	}
+
+	return result.Bytes(), conflicts
+}
+
+// Splits content into lines that keep their line ending.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// Returns a slice the size of a where each value is the matching index in b or -1.
+// Matches are the longest common subsequence of lines.
+func matchLines(a, b []string) []int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	matches := make([]int, len(a))
+	i, j := 0, 0
+	for i < len(a) {
+		switch {
+		case j < len(b) && a[i] == b[j]:
+			matches[i] = j
+			i++
+			j++
+		case j < len(b) && lengths[i][j+1] > lengths[i+1][j]:
+			j++
+		default:
+			matches[i] = -1
+			i++
+		}
+	}
+
+	return matches
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func writeLines(buff *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		buff.WriteString(line)
+	}
+}
+
+// Conflict markers must start on their own line.
+func writeConflictLines(buff *bytes.Buffer, lines []string) {
+	writeLines(buff, lines)
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		buff.WriteString("\n")
+	}
+}
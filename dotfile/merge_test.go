@@ -0,0 +1,71 @@
+package dotfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	base := "one\ntwo\nthree\nfour\n"
+
+	for name, test := range map[string]struct {
+		ours, theirs, expected string
+		conflicts              int
+	}{
+		"no changes": {
+			ours:     base,
+			theirs:   base,
+			expected: base,
+		},
+		"only ours changed": {
+			ours:     "one\n2\nthree\nfour\n",
+			theirs:   base,
+			expected: "one\n2\nthree\nfour\n",
+		},
+		"only theirs changed": {
+			ours:     base,
+			theirs:   "one\ntwo\nthree\nfour\nfive\n",
+			expected: "one\ntwo\nthree\nfour\nfive\n",
+		},
+		"different lines changed": {
+			ours:     "zero\none\ntwo\nthree\nfour\n",
+			theirs:   "one\ntwo\nthree\n4\n",
+			expected: "zero\none\ntwo\nthree\n4\n",
+		},
+		"same change": {
+			ours:     "one\n2\nthree\nfour\n",
+			theirs:   "one\n2\nthree\nfour\n",
+			expected: "one\n2\nthree\nfour\n",
+		},
+		"deleted lines": {
+			ours:     "one\nthree\nfour\n",
+			theirs:   "one\ntwo\nthree\n",
+			expected: "one\nthree\n",
+		},
+		"conflict": {
+			ours:      "one\nours\nthree\nfour\n",
+			theirs:    "one\ntheirs\nthree\nfour\n",
+			expected:  "one\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\nthree\nfour\n",
+			conflicts: 1,
+		},
+		"conflict without trailing newline": {
+			ours:      "one\ntwo\nthree\nours",
+			theirs:    "one\ntwo\nthree\ntheirs",
+			expected:  "one\ntwo\nthree\n<<<<<<< ours\nours\n=======\ntheirs\n>>>>>>> theirs\n",
+			conflicts: 1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			merged, conflicts := Merge([]byte(base), []byte(test.ours), []byte(test.theirs), "ours", "theirs")
+			assert.Equal(t, test.expected, string(merged))
+			assert.Equal(t, test.conflicts, conflicts)
+		})
+	}
+
+	t.Run("empty base", func(t *testing.T) {
+		merged, conflicts := Merge(nil, []byte("a\n"), []byte("a\n"), "ours", "theirs")
+		assert.Equal(t, "a\n", string(merged))
+		assert.Zero(t, conflicts)
+	})
+}
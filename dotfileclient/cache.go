@@ -0,0 +1,135 @@
+package dotfileclient
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The most responses that a cache keeps.
+// The oldest half are removed when there are more.
+const maxCacheEntries = 1000
+
+// Cache saves responses on disk keyed by their URL.
+// Cached responses are revalidated with their ETag so unchanged responses are not downloaded again.
+// Private responses are not saved, and saved responses are only readable by the user.
+// Errors are ignored - a broken cache only means that responses are downloaded.
+type Cache struct {
+	Dir string
+}
+
+type cacheEntry struct {
+	ETag      string `json:"etag"`
+	Immutable bool   `json:"immutable"` // Immutable responses are reused without a request.
+	body      []byte
+}
+
+func (e *cacheEntry) response() *http.Response {
+	return &http.Response{
+		Status:     http.StatusText(http.StatusOK),
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Etag": {e.ETag}},
+		Body:       io.NopCloser(bytes.NewReader(e.body)),
+	}
+}
+
+func (c *Cache) path(url string) string {
+	return filepath.Join(c.Dir, fmt.Sprintf("%x", sha1.Sum([]byte(url))))
+}
+
+// Returns nil when url is not cached.
+func (c *Cache) load(url string) *cacheEntry {
+	if c == nil {
+		return nil
+	}
+
+	entry := new(cacheEntry)
+	path := c.path(url)
+
+	meta, err := os.ReadFile(path + ".json")
+	if err != nil || json.Unmarshal(meta, entry) != nil {
+		return nil
+	}
+
+	if entry.body, err = os.ReadFile(path); err != nil {
+		return nil
+	}
+
+	return entry
+}
+
+// Saves the body of resp when it has an ETag.
+// The body of resp is replaced so that it can still be read.
+// Returns an error when the body can't be read.
+func (c *Cache) save(url string, resp *http.Response) error {
+	etag := resp.Header.Get("ETag")
+	if c == nil || etag == "" || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	// Private responses could stay readable from the cache after access to them is removed.
+	cacheControl := resp.Header.Get("Cache-Control")
+	if strings.Contains(cacheControl, "private") {
+		c.remove(url)
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	meta, err := json.Marshal(cacheEntry{
+		ETag:      etag,
+		Immutable: strings.Contains(cacheControl, "immutable"),
+	})
+	if err != nil || os.MkdirAll(c.Dir, 0700) != nil {
+		return nil
+	}
+
+	path := c.path(url)
+	if os.WriteFile(path, body, 0600) == nil {
+		_ = os.WriteFile(path+".json", meta, 0600)
+	}
+
+	c.prune()
+	return nil
+}
+
+func (c *Cache) remove(url string) {
+	path := c.path(url)
+
+	_ = os.Remove(path + ".json")
+	_ = os.Remove(path)
+}
+
+// Removes the oldest half of the entries when the cache is full.
+func (c *Cache) prune() {
+	metas, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil || len(metas) <= maxCacheEntries {
+		return
+	}
+
+	modified := make(map[string]int64)
+	for _, meta := range metas {
+		if info, err := os.Stat(meta); err == nil {
+			modified[meta] = info.ModTime().UnixNano()
+		}
+	}
+
+	sort.Slice(metas, func(i, j int) bool { return modified[metas[i]] < modified[metas[j]] })
+
+	for _, meta := range metas[:len(metas)/2] {
+		_ = os.Remove(meta)
+		_ = os.Remove(strings.TrimSuffix(meta, ".json"))
+	}
+}
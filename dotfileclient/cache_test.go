@@ -0,0 +1,111 @@
+package dotfileclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	var requests, notModified int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.URL.Path == "/api/v1/user/test/test/abc" {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, "content")
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "test", "test")
+	client.Cache = &Cache{Dir: t.TempDir()}
+
+	t.Run("revalidates", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			content, err := client.Content("test")
+			assert.NoError(t, err)
+			assert.Equal(t, "content", string(content))
+		}
+		assert.Equal(t, 2, requests)
+		assert.Equal(t, 1, notModified)
+	})
+
+	t.Run("immutable responses are reused", func(t *testing.T) {
+		requests = 0
+		for i := 0; i < 2; i++ {
+			content, err := client.revision(context.Background(), "test", "abc")
+			assert.NoError(t, err)
+			assert.Equal(t, "content", string(content))
+		}
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("entries are only readable by the user", func(t *testing.T) {
+		entries, err := filepath.Glob(filepath.Join(client.Cache.Dir, "*"))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, entries)
+
+		for _, entry := range entries {
+			info, err := os.Stat(entry)
+			assert.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		}
+	})
+
+	t.Run("private responses are not saved", func(t *testing.T) {
+		var requests int
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+			fmt.Fprint(w, "secret")
+		}))
+		defer ts.Close()
+
+		client := New(ts.URL, "test", "test")
+		client.Cache = &Cache{Dir: t.TempDir()}
+
+		for i := 0; i < 2; i++ {
+			content, err := client.revision(context.Background(), "test", "abc")
+			assert.NoError(t, err)
+			assert.Equal(t, "secret", string(content))
+		}
+		assert.Equal(t, 2, requests)
+
+		entries, err := filepath.Glob(filepath.Join(client.Cache.Dir, "*"))
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("prunes oldest entries", func(t *testing.T) {
+		cache := &Cache{Dir: t.TempDir()}
+		for i := 0; i <= maxCacheEntries; i++ {
+			_ = os.WriteFile(filepath.Join(cache.Dir, fmt.Sprintf("%d.json", i)), nil, 0644)
+		}
+
+		cache.prune()
+		entries, err := filepath.Glob(filepath.Join(cache.Dir, "*.json"))
+		assert.NoError(t, err)
+		assert.Len(t, entries, maxCacheEntries+1-(maxCacheEntries+1)/2)
+	})
+
+	t.Run("nil cache", func(t *testing.T) {
+		var cache *Cache
+		assert.Nil(t, cache.load("url"))
+	})
+}
@@ -2,12 +2,17 @@ package dotfileclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/textproto"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/knoebber/dotfile/dotfile"
@@ -17,6 +22,16 @@ import (
 
 const timeoutSeconds = 30
 
+// Version is the version of the dotfile client.
+// It is sent to the server in the User-Agent header.
+const Version = "1.0.6"
+
+// DefaultRetries is the number of times that a failed request is retried.
+const DefaultRetries = 3
+
+// The wait before the first retry, it doubles on each retry after.
+var retryDelay = 500 * time.Millisecond
+
 // Revision is the bytes of a revision and its hash.
 // Note that the bytes would not hash to Hash - its from the original uncompressed content.
 type Revision struct {
@@ -30,28 +45,204 @@ type Client struct {
 	Remote   string
 	Username string
 	Token    string
+	Progress ProgressReporter // Optional.
+	Cache    *Cache           // Optional.
+
+	// The user or organization whose files are read and pushed.
+	// Defaults to Username; members of an organization set it to the organization's name.
+	Owner string
+
+	// Sets who can see pushed files when not empty: public, unlisted, or private.
+	Visibility string
+
+	// Requests that fail to connect or have a 5xx response are retried with exponential backoff.
+	Retries   int
+	UserAgent string
+}
+
+// ProgressReporter receives updates while revisions are transferred.
+// Progress can be called from multiple goroutines.
+type ProgressReporter interface {
+	Start(action string, total int)  // Total is the number of revisions.
+	Progress(hash string, bytes int) // Called after each revision is transferred.
+	Done()
+}
+
+type noProgress struct{}
+
+func (noProgress) Start(string, int)    {}
+func (noProgress) Progress(string, int) {}
+func (noProgress) Done()                {}
+
+func (c *Client) progress() ProgressReporter {
+	if c.Progress == nil {
+		return noProgress{}
+	}
+
+	return c.Progress
 }
 
 // New returns a client that is ready to communicate with a dotfilehub server.
 func New(remote, username, token string) *Client {
 	return &Client{
-		Client: &http.Client{
-			Timeout: time.Second * timeoutSeconds,
-		},
-		Remote:   remote,
-		Username: username,
-		Token:    token,
+		Client:    newHTTPClient(),
+		Remote:    remote,
+		Username:  username,
+		Token:     token,
+		Retries:   DefaultRetries,
+		UserAgent: "dotfile/" + Version,
+	}
+}
+
+// Requests don't have a total timeout because pushes stream their revisions, which can take longer on slow links.
+// Connecting and waiting for the response headers time out instead.
+func newHTTPClient() *http.Client {
+	timeout := time.Second * timeoutSeconds
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: timeout,
+	}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+
+	return &http.Client{Transport: transport}
+}
+
+func (c *Client) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	// Private files can only be read with a token.
+	if c.Token != "" {
+		req.SetBasicAuth(c.Username, c.Token)
+	}
+	return req, nil
+}
+
+// Sends the request that newRequest creates.
+// A new request is created for each attempt so that bodies can be sent again.
+// Requests that aren't idempotent are only retried when they weren't sent.
+func (c *Client) do(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	delay := retryDelay
+
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.Client.Do(req)
+		if attempt == c.Retries || ctx.Err() != nil {
+			return resp, err
+		}
+		if err == nil && (resp.StatusCode < http.StatusInternalServerError || !idempotent(req)) {
+			return resp, nil
+		}
+		if err != nil && !idempotent(req) && !isDialError(err) {
+			return resp, err
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
 	}
 }
 
+// Returns whether req can be sent again after the server might have handled it.
+// Like net/http, requests with an Idempotency-Key header are idempotent.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	}
+
+	_, ok := req.Header["Idempotency-Key"]
+	return ok
+}
+
+// Marks req as idempotent without sending the header.
+func setIdempotent(req *http.Request) {
+	req.Header["Idempotency-Key"] = nil
+}
+
+// Returns whether err happened before the request was sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// Sends a GET request to url.
+// Uses the cached response when the server responds that it is not modified.
+func (c *Client) get(ctx context.Context, url string) (*http.Response, error) {
+	cached := c.Cache.load(url)
+	if cached != nil && cached.Immutable {
+		return cached.response(), nil
+	}
+
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		req, err := c.newRequest(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if cached != nil {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		return cached.response(), nil
+	}
+
+	if err := c.Cache.save(url, resp); err != nil {
+		return nil, errors.Wrapf(err, "reading response from %q", url)
+	}
+
+	return resp, nil
+}
+
+func (c *Client) owner() string {
+	if c.Owner != "" {
+		return c.Owner
+	}
+
+	return c.Username
+}
+
 func (c *Client) userURL() string {
-	return c.Remote + "/api/v1/user/" + c.Username
+	return c.Remote + "/api/v1/user/" + c.owner()
 }
 
 func (c *Client) fileURL(alias string) string {
 	return c.userURL() + "/" + alias
 }
 
+func (c *Client) pushURL(alias string) string {
+	if c.Visibility == "" {
+		return c.fileURL(alias)
+	}
+
+	return c.fileURL(alias) + "?visibility=" + url.QueryEscape(c.Visibility)
+}
+
 func (c *Client) rawFileURL(alias string) string {
 	return c.fileURL(alias) + "/raw"
 }
@@ -60,36 +251,120 @@ func (c *Client) revisionURL(alias, hash string) string {
 	return c.fileURL(alias) + "/" + hash
 }
 
+func (c *Client) revisionsURL(alias string) string {
+	return c.fileURL(alias) + "/revisions"
+}
+
 // List lists the files that the remote user has saved.
 func (c *Client) List(path bool) ([]string, error) {
+	return c.ListContext(context.Background(), path)
+}
+
+// ListContext is like List but uses ctx for its requests.
+func (c *Client) ListContext(ctx context.Context, path bool) ([]string, error) {
+	return c.listFiles(ctx, "", path, "getting file list")
+}
+
+// Starred lists the files that the owner starred as "username/alias".
+// Adds the path to each file when path is true.
+func (c *Client) Starred(path bool) ([]string, error) {
+	return c.StarredContext(context.Background(), path)
+}
+
+// StarredContext is like Starred but uses ctx for its requests.
+func (c *Client) StarredContext(ctx context.Context, path bool) ([]string, error) {
+	return c.listFiles(ctx, "starred=true", path, "getting starred files")
+}
+
+func (c *Client) listFiles(ctx context.Context, query string, path bool, action string) ([]string, error) {
 	var result []string
 
-	url := c.userURL()
 	if path {
-		url += "?path=true"
+		if query != "" {
+			query += "&"
+		}
+		query += "path=true"
 	}
-	resp, err := c.Client.Get(url)
+
+	url := c.userURL()
+	if query != "" {
+		url += "?" + query
+	}
+	resp, err := c.get(ctx, url)
 	if err != nil {
-		return nil, errors.Wrapf(err, "getting file list from %q for %q", c.Remote, c.Username)
+		return nil, errors.Wrapf(err, "%s from %q for %q", action, c.Remote, c.owner())
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("getting file list: %s", readBodyErrorMessage(resp))
+		return nil, responseError(resp, action)
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, errors.Wrap(err, "decoding file list")
+		return nil, errors.Wrapf(err, "decoding response for %s", action)
+	}
+
+	return result, nil
+}
+
+// SearchResult is a public file that matched a search.
+type SearchResult struct {
+	Username  string
+	Alias     string
+	Path      string
+	UpdatedAt time.Time `json:"updated_at"`
+	Stars     int
+	Forks     int
+	Snippet   string // Matched content, empty unless the remote has full text search.
+}
+
+// SearchResults is a page of search results.
+type SearchResults struct {
+	Page    int
+	Pages   int
+	Total   int // The total amount of results on all pages.
+	Results []SearchResult
+}
+
+// Search searches the public files on remote.
+// The query supports the same filters as the remote's search page.
+func (c *Client) Search(query string, page int) (*SearchResults, error) {
+	return c.SearchContext(context.Background(), query, page)
+}
+
+// SearchContext is like Search but uses ctx for its requests.
+func (c *Client) SearchContext(ctx context.Context, query string, page int) (*SearchResults, error) {
+	result := new(SearchResults)
+
+	values := url.Values{"q": {query}, "p": {fmt.Sprint(page)}}
+	resp, err := c.get(ctx, c.Remote+"/api/v1/search?"+values.Encode())
+	if err != nil {
+		return nil, errors.Wrapf(err, "searching %q", c.Remote)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, "searching")
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, errors.Wrap(err, "decoding search results")
 	}
 
 	return result, nil
 }
 
 // TrackingDataBytes returns the tracking data for alias in bytes.
+// Returns nil when alias does not exist on remote.
 func (c *Client) TrackingDataBytes(alias string) ([]byte, error) {
-	resp, err := c.Client.Get(c.fileURL(alias))
+	return c.TrackingDataBytesContext(context.Background(), alias)
+}
+
+// TrackingDataBytesContext is like TrackingDataBytes but uses ctx for its requests.
+func (c *Client) TrackingDataBytesContext(ctx context.Context, alias string) ([]byte, error) {
+	resp, err := c.get(ctx, c.fileURL(alias))
 	if err != nil {
-		return nil, errors.Wrapf(err, "getting remote tracked file from %q for %q %q", c.Remote, c.Username, alias)
+		return nil, errors.Wrapf(err, "getting remote tracked file from %q for %q %q", c.Remote, c.owner(), alias)
 	}
 	defer resp.Body.Close()
 
@@ -97,15 +372,21 @@ func (c *Client) TrackingDataBytes(alias string) ([]byte, error) {
 		return nil, nil
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("fetching remote tracked file %q: %s", alias, readBodyErrorMessage(resp))
+		return nil, responseError(resp, fmt.Sprintf("fetching remote tracked file %q", alias))
 	}
 
 	return io.ReadAll(resp.Body)
 }
 
 // TrackingData returns the file tracking data for alias on remote.
+// Returns nil when alias does not exist on remote.
 func (c *Client) TrackingData(alias string) (*dotfile.TrackingData, error) {
-	data, err := c.TrackingDataBytes(alias)
+	return c.TrackingDataContext(context.Background(), alias)
+}
+
+// TrackingDataContext is like TrackingData but uses ctx for its requests.
+func (c *Client) TrackingDataContext(ctx context.Context, alias string) (*dotfile.TrackingData, error) {
+	data, err := c.TrackingDataBytesContext(ctx, alias)
 	if err != nil {
 		return nil, err
 	}
@@ -121,48 +402,178 @@ func (c *Client) TrackingData(alias string) (*dotfile.TrackingData, error) {
 	return result, nil
 }
 
-func (c *Client) revision(alias, hash string) ([]byte, error) {
-	url := c.revisionURL(alias, hash)
-	resp, err := c.Client.Get(url)
+func (c *Client) revision(ctx context.Context, alias, hash string) ([]byte, error) {
+	resp, err := c.get(ctx, c.revisionURL(alias, hash))
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrapf(err, "getting revision %q from %q for %q %q", hash, c.Remote, c.owner(), alias)
 	}
 	defer resp.Body.Close()
 
-	fmt.Println("GET", url)
-
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("fetching file content: %s", readBodyErrorMessage(resp))
+		return nil, responseError(resp, fmt.Sprintf("fetching revision %q", hash))
 	}
 
-	return io.ReadAll(resp.Body)
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading revision %q", hash)
+	}
+
+	c.progress().Progress(hash, len(content))
+	return content, nil
 }
 
 // Content fetches the current content of alias.
 func (c *Client) Content(alias string) ([]byte, error) {
-	resp, err := c.Client.Get(c.rawFileURL(alias))
+	return c.ContentContext(context.Background(), alias)
+}
+
+// ContentContext is like Content but uses ctx for its requests.
+func (c *Client) ContentContext(ctx context.Context, alias string) ([]byte, error) {
+	resp, err := c.get(ctx, c.rawFileURL(alias))
 	if err != nil {
-		return nil, errors.Wrapf(err, "getting raw content from %q for %q %q", c.Remote, c.Username, alias)
+		return nil, errors.Wrapf(err, "getting raw content from %q for %q %q", c.Remote, c.owner(), alias)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("fetching file content: %s", readBodyErrorMessage(resp))
+		return nil, responseError(resp, "fetching file content")
 	}
 
 	return io.ReadAll(resp.Body)
 }
 
+// Limits for downloading revisions.
+const (
+	downloadBatchCount  = 100 // The most revisions that the server sends in one response.
+	downloadConcurrency = 8   // The most revisions fetched at once from servers without batch downloads.
+)
+
 // Revisions fetches all of the revisions for alias in the hashes argument.
+// Revisions are downloaded in batches, servers that don't support batches are sent a request per hash.
 // Returns an error if any fetches fail or are non 200.
 func (c *Client) Revisions(alias string, hashes []string) ([]*Revision, error) {
-	g := new(errgroup.Group)
+	return c.RevisionsContext(context.Background(), alias, hashes)
+}
+
+// RevisionsContext is like Revisions but uses ctx for its requests.
+func (c *Client) RevisionsContext(ctx context.Context, alias string, hashes []string) ([]*Revision, error) {
+	results := make([]*Revision, 0, len(hashes))
+
+	if len(hashes) == 0 {
+		return results, nil
+	}
+
+	c.progress().Start("downloading "+alias, len(hashes))
+	defer c.progress().Done()
+
+	for start := 0; start < len(hashes); start += downloadBatchCount {
+		end := start + downloadBatchCount
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+
+		batch, err := c.revisionBatch(ctx, alias, hashes[start:end])
+		if errors.Is(err, errBatchUnsupported) {
+			rest, err := c.revisionsEach(ctx, alias, hashes[start:])
+			if err != nil {
+				return nil, err
+			}
+			return append(results, rest...), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, batch...)
+	}
+
+	return results, nil
+}
+
+// Returned when the server does not have the batch revisions endpoint.
+var errBatchUnsupported = errors.New("batch revisions are not supported")
+
+// Fetches revisions in a single request.
+func (c *Client) revisionBatch(ctx context.Context, alias string, hashes []string) ([]*Revision, error) {
+	body, err := json.Marshal(map[string][]string{"hashes": hashes})
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding revisions request")
+	}
+
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		req, err := c.newRequest(ctx, http.MethodPost, c.revisionsURL(alias), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		// The request only reads revisions.
+		setIdempotent(req)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting revisions from %q for %q %q", c.Remote, c.owner(), alias)
+	}
+	defer resp.Body.Close()
+
+	// Older servers route the request to a single revision that doesn't exist.
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, errBatchUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp, "fetching revisions")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, errBatchUnsupported
+	}
+
+	revisions := make(map[string][]byte)
+	mr := multipart.NewReader(resp.Body, params["boundary"])
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading revisions response")
+		}
+
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading revision %q", part.FileName())
+		}
+
+		revisions[part.FileName()] = content
+		c.progress().Progress(part.FileName(), len(content))
+	}
+
+	results := make([]*Revision, len(hashes))
+	for i, hash := range hashes {
+		content, ok := revisions[hash]
+		if !ok {
+			return nil, errors.Wrapf(ErrNotFound, "fetching revision %q", hash)
+		}
+
+		results[i] = &Revision{Hash: hash, Bytes: content}
+	}
+
+	return results, nil
+}
+
+// Fetches revisions with a request per hash.
+// Remaining fetches are canceled after the first error.
+func (c *Client) revisionsEach(ctx context.Context, alias string, hashes []string) ([]*Revision, error) {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(downloadConcurrency)
 	results := make([]*Revision, len(hashes))
 
 	for i, hash := range hashes {
 		i, hash := i, hash // https://golang.org/doc/faq#closures_and_goroutines
 		g.Go(func() error {
-			r, err := c.revision(alias, hash)
+			r, err := c.revision(ctx, alias, hash)
 			if err != nil {
 				return err
 			}
@@ -182,30 +593,74 @@ func (c *Client) Revisions(alias string, hashes []string) ([]*Revision, error) {
 	return results, nil
 }
 
-// UploadRevisions uploads revisions to remote using a multipart POST request.
-// The first part is the fileData JSON the rest are form files with the revision bytes.
+// Limits for the revisions that are uploaded in a single request.
+// The server saves each batch separately so that an interrupted push only resends missing revisions.
+const (
+	uploadBatchCount = 20
+	uploadBatchBytes = 4 << 20
+)
+
+// UploadResponse is the body that the server responds with after a push.
+type UploadResponse struct {
+	Stored []string `json:"stored"` // Hashes that the server has saved.
+}
+
+// UploadRevisions uploads revisions to remote using multipart POST requests.
+// Revisions are sent in batches, each request has the fileData JSON as the first part and the rest are form files with the revision bytes.
 func (c *Client) UploadRevisions(alias string, data *dotfile.TrackingData, revisions []*Revision) error {
-	var body bytes.Buffer
+	return c.UploadRevisionsContext(context.Background(), alias, data, revisions)
+}
 
-	if len(revisions) == 0 {
-		fmt.Println("Upto date.")
+// UploadRevisionsContext is like UploadRevisions but uses ctx for its requests.
+func (c *Client) UploadRevisionsContext(ctx context.Context, alias string, data *dotfile.TrackingData, revisions []*Revision) error {
+	// The file data is still sent to update the visibility.
+	if len(revisions) == 0 && c.Visibility == "" {
 		return nil
 	}
 
-	url := c.fileURL(alias)
+	c.progress().Start("pushing "+alias, len(revisions))
+	defer c.progress().Done()
+
+	for _, batch := range uploadBatches(revisions) {
+		if err := c.uploadBatch(ctx, alias, data, batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
-	writer := multipart.NewWriter(&body)
+func uploadBatches(revisions []*Revision) [][]*Revision {
+	var (
+		batches [][]*Revision
+		batch   []*Revision
+		size    int
+	)
 
+	for _, r := range revisions {
+		if len(batch) > 0 && (len(batch) == uploadBatchCount || size+len(r.Bytes) > uploadBatchBytes) {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+
+		batch = append(batch, r)
+		size += len(r.Bytes)
+	}
+
+	return append(batches, batch)
+}
+
+// Writes the multipart body for an upload.
+func writeUploadBody(writer *multipart.Writer, data *dotfile.TrackingData, revisions []*Revision) error {
 	jsonPart, err := writer.CreatePart(textproto.MIMEHeader{
 		"Content-Type": {"application/json"},
 	})
-
 	if err != nil {
 		return errors.Wrap(err, "creating JSON part")
 	}
 
 	if err = json.NewEncoder(jsonPart).Encode(data); err != nil {
-		return errors.Wrap(err, "encoding json part: %v")
+		return errors.Wrap(err, "encoding json part")
 	}
 
 	for _, r := range revisions {
@@ -214,40 +669,210 @@ func (c *Client) UploadRevisions(alias string, data *dotfile.TrackingData, revis
 			return errors.Wrap(err, "creating revision part")
 		}
 
-		n, err := revisionPart.Write(r.Bytes)
+		if _, err := revisionPart.Write(r.Bytes); err != nil {
+			return errors.Wrapf(err, "writing revision %q", r.Hash)
+		}
+	}
+
+	return writer.Close()
+}
+
+// Streams a batch of revisions to the server.
+// Returns an error when the server does not acknowledge every revision.
+func (c *Client) uploadBatch(ctx context.Context, alias string, data *dotfile.TrackingData, revisions []*Revision) error {
+	var uploaded UploadResponse
+
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		body, pw := io.Pipe()
+		writer := multipart.NewWriter(pw)
+
+		go func() {
+			pw.CloseWithError(writeUploadBody(writer, data, revisions))
+		}()
+
+		req, err := c.newRequest(ctx, http.MethodPost, c.pushURL(alias), body)
 		if err != nil {
-			return err
+			body.Close()
+			return nil, err
 		}
 
-		fmt.Printf("pushing %s (%d bytes)\n", r.Hash, n)
+		// Revisions are content addressed so a batch can be uploaded again.
+		setIdempotent(req)
+		req.Header.Set("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+		return req, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "uploading revisions to %q for %q %q", c.Remote, c.owner(), alias)
 	}
+	defer resp.Body.Close()
 
-	contentType := fmt.Sprintf("multipart/mixed; boundary=%s", writer.Boundary())
-	if err = writer.Close(); err != nil {
-		return err
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, "uploading file revisions")
 	}
 
-	req, err := http.NewRequest("POST", url, &body)
-	if err != nil {
-		return err
+	// Servers before batched uploads respond with an empty body after saving every revision.
+	err = json.NewDecoder(resp.Body).Decode(&uploaded)
+	if err == io.EOF {
+		for _, r := range revisions {
+			uploaded.Stored = append(uploaded.Stored, r.Hash)
+		}
+	} else if err != nil {
+		return errors.Wrap(err, "decoding upload response")
+	}
+
+	stored := make(map[string]bool)
+	for _, hash := range uploaded.Stored {
+		stored[hash] = true
+	}
+
+	for _, r := range revisions {
+		if !stored[r.Hash] {
+			return fmt.Errorf("server did not store revision %q", r.Hash)
+		}
+
+		c.progress().Progress(r.Hash, len(r.Bytes))
 	}
 
-	req.Header.Set("Content-Type", contentType)
-	req.SetBasicAuth(c.Username, c.Token)
+	return nil
+}
+
+// Sends an authenticated request with a JSON body.
+// The response is decoded into result when it is not nil.
+func (c *Client) sendJSON(ctx context.Context, method, url, action string, body, result interface{}) error {
+	var content []byte
+
+	if body != nil {
+		var err error
+		if content, err = json.Marshal(body); err != nil {
+			return errors.Wrapf(err, "encoding body for %s", action)
+		}
+	}
+
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		req, err := c.newRequest(ctx, method, url, bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := c.Client.Do(req)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
-		return errors.Wrapf(err, "uploading revisions to %q for %q %q", c.Remote, c.Username, alias)
+		return errors.Wrapf(err, "%s on %q for %q", action, c.Remote, c.owner())
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("uploading file revisions: %s", readBodyErrorMessage(resp))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return responseError(resp, action)
+	}
+	if result == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return errors.Wrapf(err, "decoding response for %s", action)
 	}
 
 	return nil
 }
 
+// UpdateFile changes the alias and path of a file on remote.
+// Empty values are not changed.
+// Returns the updated file data.
+func (c *Client) UpdateFile(alias, newAlias, newPath string) (*dotfile.TrackingData, error) {
+	return c.UpdateFileContext(context.Background(), alias, newAlias, newPath)
+}
+
+// UpdateFileContext is like UpdateFile but uses ctx for its requests.
+func (c *Client) UpdateFileContext(ctx context.Context, alias, newAlias, newPath string) (*dotfile.TrackingData, error) {
+	result := new(dotfile.TrackingData)
+
+	body := map[string]string{"alias": newAlias, "path": newPath}
+	if err := c.sendJSON(ctx, http.MethodPatch, c.fileURL(alias), fmt.Sprintf("updating %q", alias), body, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteFile deletes a file and all of its commits on remote.
+func (c *Client) DeleteFile(alias string) error {
+	return c.DeleteFileContext(context.Background(), alias)
+}
+
+// DeleteFileContext is like DeleteFile but uses ctx for its requests.
+func (c *Client) DeleteFileContext(ctx context.Context, alias string) error {
+	return c.sendJSON(ctx, http.MethodDelete, c.fileURL(alias), fmt.Sprintf("deleting %q", alias), nil, nil)
+}
+
+// ClearCommits deletes all of the commits of a file on remote except the current.
+// Returns the updated file data.
+func (c *Client) ClearCommits(alias string) (*dotfile.TrackingData, error) {
+	return c.ClearCommitsContext(context.Background(), alias)
+}
+
+// ClearCommitsContext is like ClearCommits but uses ctx for its requests.
+func (c *Client) ClearCommitsContext(ctx context.Context, alias string) (*dotfile.TrackingData, error) {
+	result := new(dotfile.TrackingData)
+
+	if err := c.sendJSON(ctx, http.MethodDelete, c.fileURL(alias)+"/commits", fmt.Sprintf("clearing commits for %q", alias), nil, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SetRevision sets the current revision of a file on remote to one of its commits.
+// Returns the updated file data.
+func (c *Client) SetRevision(alias, hash string) (*dotfile.TrackingData, error) {
+	return c.SetRevisionContext(context.Background(), alias, hash)
+}
+
+// SetRevisionContext is like SetRevision but uses ctx for its requests.
+func (c *Client) SetRevisionContext(ctx context.Context, alias, hash string) (*dotfile.TrackingData, error) {
+	result := new(dotfile.TrackingData)
+
+	body := map[string]string{"hash": hash}
+	if err := c.sendJSON(ctx, http.MethodPut, c.fileURL(alias)+"/revision", fmt.Sprintf("setting %q to %q", alias, hash), body, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Share is a link that grants read access to a file on remote.
+type Share struct {
+	URL       string
+	ExpiresAt *time.Time // Nil when the link doesn't expire.
+	History   bool       // Whether the link includes the file's commits.
+}
+
+// CreateShare creates a share link for a file on remote.
+// The link expires after expiresIn unless it is zero.
+func (c *Client) CreateShare(alias string, expiresIn time.Duration, history bool) (*Share, error) {
+	return c.CreateShareContext(context.Background(), alias, expiresIn, history)
+}
+
+// CreateShareContext is like CreateShare but uses ctx for its requests.
+func (c *Client) CreateShareContext(ctx context.Context, alias string, expiresIn time.Duration, history bool) (*Share, error) {
+	var result struct {
+		Path      string     `json:"path"`
+		ExpiresAt *time.Time `json:"expires_at"`
+		History   bool       `json:"history"`
+	}
+
+	body := map[string]interface{}{"expires_in": int64(expiresIn.Seconds()), "history": history}
+	if err := c.sendJSON(ctx, http.MethodPost, c.fileURL(alias)+"/shares", fmt.Sprintf("sharing %q", alias), body, &result); err != nil {
+		return nil, err
+	}
+
+	return &Share{
+		URL:       c.Remote + result.Path,
+		ExpiresAt: result.ExpiresAt,
+		History:   result.History,
+	}, nil
+}
+
 // Expects that server sets the response body with plain text on non 200's.
 func readBodyErrorMessage(resp *http.Response) string {
 	content, err := io.ReadAll(resp.Body)
@@ -1,12 +1,21 @@
 package dotfileclient
 
 import (
+	"context"
 	"fmt"
-	"github.com/knoebber/dotfile/dotfile"
-	"github.com/stretchr/testify/assert"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 )
 
 const testTrackingData = `
@@ -28,6 +37,11 @@ const testTrackingData = `
 }
 `
 
+func TestMain(m *testing.M) {
+	retryDelay = time.Millisecond
+	os.Exit(m.Run())
+}
+
 func setupTest(code int, response string) (*httptest.Server, *Client) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(code)
@@ -38,6 +52,17 @@ func setupTest(code int, response string) (*httptest.Server, *Client) {
 	return ts, client
 }
 
+func TestNew(t *testing.T) {
+	client := New("", "", "")
+	assert.Zero(t, client.Client.Timeout, "pushes are not cut off while streaming")
+
+	transport, ok := client.Client.Transport.(*http.Transport)
+	if assert.True(t, ok) {
+		assert.Equal(t, time.Second*timeoutSeconds, transport.TLSHandshakeTimeout)
+		assert.Equal(t, time.Second*timeoutSeconds, transport.ResponseHeaderTimeout)
+	}
+}
+
 func TestClient_List(t *testing.T) {
 	t.Run("http error", func(t *testing.T) {
 		client := New("no host", "test", "test")
@@ -70,6 +95,58 @@ func TestClient_List(t *testing.T) {
 	})
 }
 
+func TestClient_Starred(t *testing.T) {
+	t.Run("not 200 error", func(t *testing.T) {
+		ts, client := setupTest(http.StatusNotFound, "")
+		defer ts.Close()
+
+		_, err := client.Starred(false)
+		assert.Error(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		var query string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			fmt.Fprintln(w, `["alice/bashrc ~/.bashrc"]`)
+		}))
+		defer ts.Close()
+
+		result, err := New(ts.URL, "test", "test").Starred(true)
+		assert.NoError(t, err)
+		assert.Equal(t, "starred=true&path=true", query)
+		assert.Equal(t, []string{"alice/bashrc ~/.bashrc"}, result)
+	})
+}
+
+func TestClient_Search(t *testing.T) {
+	t.Run("not 200 error", func(t *testing.T) {
+		ts, client := setupTest(http.StatusBadRequest, "bad sort")
+		defer ts.Close()
+
+		_, err := client.Search("sort:likes", 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		var query string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			fmt.Fprintln(w, `{"page":2,"pages":2,"total":3,"results":[{"username":"alice","alias":"bashrc","path":"~/.bashrc","stars":1}]}`)
+		}))
+		defer ts.Close()
+
+		result, err := New(ts.URL, "test", "test").Search("bash user:alice", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "p=2&q=bash+user%3Aalice", query)
+		assert.Equal(t, 3, result.Total)
+		if assert.Len(t, result.Results, 1) {
+			assert.Equal(t, "alice", result.Results[0].Username)
+			assert.Equal(t, 1, result.Results[0].Stars)
+		}
+	})
+}
+
 func TestClient_TrackingData(t *testing.T) {
 	t.Run("http error", func(t *testing.T) {
 		client := New("no host", "test", "test")
@@ -142,6 +219,81 @@ func TestClient_Revisions(t *testing.T) {
 		_, err := client.Revisions("test", []string{"a", "b"})
 		assert.NoError(t, err)
 	})
+
+	batchServer := func(hashes ...string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				t.Errorf("expected a single batch request, got %s %s", r.Method, r.URL)
+				return
+			}
+
+			writer := multipart.NewWriter(w)
+			w.Header().Set("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+			for _, hash := range hashes {
+				part, _ := writer.CreateFormFile("revision", hash)
+				fmt.Fprint(part, "content "+hash)
+			}
+			writer.Close()
+		}))
+	}
+
+	t.Run("batch ok", func(t *testing.T) {
+		ts := batchServer("b", "a")
+		defer ts.Close()
+
+		res, err := New(ts.URL, "test", "test").Revisions("test", []string{"a", "b"})
+		assert.NoError(t, err)
+		assert.Equal(t, []*Revision{
+			{Hash: "a", Bytes: []byte("content a")},
+			{Hash: "b", Bytes: []byte("content b")},
+		}, res)
+	})
+
+	t.Run("batch missing hash", func(t *testing.T) {
+		ts := batchServer("a")
+		defer ts.Close()
+
+		_, err := New(ts.URL, "test", "test").Revisions("test", []string{"a", "b"})
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("batch is retried", func(t *testing.T) {
+		var posts int32
+		batch := batchServer("a")
+		defer batch.Close()
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&posts, 1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			batch.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer ts.Close()
+
+		res, err := New(ts.URL, "test", "test").Revisions("test", []string{"a"})
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, int32(2), posts)
+	})
+
+	t.Run("falls back to a request per hash", func(t *testing.T) {
+		var gets int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				http.NotFound(w, r)
+				return
+			}
+			atomic.AddInt32(&gets, 1)
+			fmt.Fprint(w, "content")
+		}))
+		defer ts.Close()
+
+		res, err := New(ts.URL, "test", "test").Revisions("test", []string{"a", "b", "c"})
+		assert.NoError(t, err)
+		assert.Len(t, res, 3)
+		assert.Equal(t, int32(3), gets)
+	})
 }
 
 func TestClient_Content(t *testing.T) {
@@ -190,4 +342,272 @@ func TestClient_UploadRevisions(t *testing.T) {
 		defer ts.Close()
 		assert.NoError(t, client.UploadRevisions("", new(dotfile.TrackingData), []*Revision{{}}))
 	})
+
+	t.Run("ok when stored", func(t *testing.T) {
+		ts, client := setupTest(http.StatusOK, `{"stored": ["a"]}`)
+		defer ts.Close()
+		assert.NoError(t, client.UploadRevisions("", new(dotfile.TrackingData), []*Revision{{Hash: "a"}}))
+	})
+
+	t.Run("error when not stored", func(t *testing.T) {
+		ts, client := setupTest(http.StatusOK, `{"stored": ["a"]}`)
+		defer ts.Close()
+		assert.Error(t, client.UploadRevisions("", new(dotfile.TrackingData), []*Revision{{Hash: "a"}, {Hash: "b"}}))
+	})
+
+	t.Run("sends visibility without revisions", func(t *testing.T) {
+		var visibility string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			visibility = r.URL.Query().Get("visibility")
+		}))
+		defer ts.Close()
+
+		client := New(ts.URL, "test", "test")
+		client.Visibility = "private"
+		assert.NoError(t, client.UploadRevisions("alias", new(dotfile.TrackingData), nil))
+		assert.Equal(t, "private", visibility)
+	})
+}
+
+func TestUploadBatches(t *testing.T) {
+	t.Run("splits on count", func(t *testing.T) {
+		revisions := make([]*Revision, uploadBatchCount+1)
+		for i := range revisions {
+			revisions[i] = new(Revision)
+		}
+
+		batches := uploadBatches(revisions)
+		assert.Len(t, batches, 2)
+		assert.Len(t, batches[0], uploadBatchCount)
+		assert.Len(t, batches[1], 1)
+	})
+
+	t.Run("splits on bytes", func(t *testing.T) {
+		batches := uploadBatches([]*Revision{
+			{Bytes: make([]byte, uploadBatchBytes)},
+			{Bytes: make([]byte, 1)},
+			{Bytes: make([]byte, 1)},
+		})
+		assert.Len(t, batches, 2)
+		assert.Len(t, batches[0], 1)
+		assert.Len(t, batches[1], 2)
+	})
+}
+
+func TestClient_retries(t *testing.T) {
+	t.Run("retries server errors", func(t *testing.T) {
+		attempts := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			fmt.Fprintln(w, `["file1"]`)
+		}))
+		defer ts.Close()
+
+		result, err := New(ts.URL, "test", "test").List(false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"file1"}, result)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("stops after retries", func(t *testing.T) {
+		attempts := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+
+		_, err := New(ts.URL, "test", "test").List(false)
+		assert.Error(t, err)
+		assert.Equal(t, DefaultRetries+1, attempts)
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		attempts := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			http.Error(w, "bad token", http.StatusUnauthorized)
+		}))
+		defer ts.Close()
+
+		_, err := New(ts.URL, "test", "test").Content("test")
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("retries uploads", func(t *testing.T) {
+		attempts := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintln(w, `{"stored": ["a"]}`)
+		}))
+		defer ts.Close()
+
+		client := New(ts.URL, "test", "test")
+		assert.NoError(t, client.UploadRevisions("test", new(dotfile.TrackingData), []*Revision{{Hash: "a"}}))
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("does not retry requests that are not idempotent", func(t *testing.T) {
+		attempts := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		client := New(ts.URL, "test", "test")
+		resp, err := client.do(context.Background(), func() (*http.Request, error) {
+			return client.newRequest(context.Background(), http.MethodPost, ts.URL, nil)
+		})
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("retries requests that were not sent", func(t *testing.T) {
+		ts := httptest.NewServer(http.NotFoundHandler())
+		ts.Close()
+
+		attempts := 0
+		client := New(ts.URL, "test", "test")
+		_, err := client.do(context.Background(), func() (*http.Request, error) {
+			attempts++
+			return client.newRequest(context.Background(), http.MethodPost, ts.URL, nil)
+		})
+		assert.Error(t, err)
+		assert.Equal(t, DefaultRetries+1, attempts)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ts, client := setupTest(http.StatusOK, `["file1"]`)
+		defer ts.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := client.ListContext(ctx, false)
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
+
+func TestClient_userAgent(t *testing.T) {
+	var userAgent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		fmt.Fprintln(w, "[]")
+	}))
+	defer ts.Close()
+
+	_, err := New(ts.URL, "test", "test").List(false)
+	assert.NoError(t, err)
+	assert.Equal(t, "dotfile/"+Version, userAgent)
+}
+
+func TestClient_readAuth(t *testing.T) {
+	var authorized bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, authorized = r.BasicAuth()
+		fmt.Fprintln(w, "[]")
+	}))
+	defer ts.Close()
+
+	_, err := New(ts.URL, "test", "").List(false)
+	assert.NoError(t, err)
+	assert.False(t, authorized)
+
+	_, err = New(ts.URL, "test", "token").List(false)
+	assert.NoError(t, err)
+	assert.True(t, authorized, "reads send the token so that private files are visible")
+}
+
+func TestClient_owner(t *testing.T) {
+	var path, username string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		username, _, _ = r.BasicAuth()
+		fmt.Fprintln(w, "[]")
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "test", "token")
+	client.Owner = "acme"
+
+	_, err := client.List(false)
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/v1/user/acme", path)
+	assert.Equal(t, "test", username, "members authenticate as themselves")
+}
+
+func TestClient_fileManagement(t *testing.T) {
+	var method, path, body string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(content)
+
+		if username, token, _ := r.BasicAuth(); username != "test" || token != "token" {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodDelete && !strings.HasSuffix(r.URL.Path, "/commits") {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprint(w, testTrackingData)
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "test", "token")
+
+	t.Run("update file", func(t *testing.T) {
+		data, err := client.UpdateFile("bashrc", "bash", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "~/.bash_ps1", data.Path)
+		assert.Equal(t, http.MethodPatch, method)
+		assert.Equal(t, "/api/v1/user/test/bashrc", path)
+		assert.JSONEq(t, `{"alias": "bash", "path": ""}`, body)
+	})
+
+	t.Run("delete file", func(t *testing.T) {
+		assert.NoError(t, client.DeleteFile("bashrc"))
+		assert.Equal(t, http.MethodDelete, method)
+		assert.Equal(t, "/api/v1/user/test/bashrc", path)
+	})
+
+	t.Run("clear commits", func(t *testing.T) {
+		_, err := client.ClearCommits("bashrc")
+		assert.NoError(t, err)
+		assert.Equal(t, "/api/v1/user/test/bashrc/commits", path)
+	})
+
+	t.Run("set revision", func(t *testing.T) {
+		_, err := client.SetRevision("bashrc", "abc")
+		assert.NoError(t, err)
+		assert.Equal(t, http.MethodPut, method)
+		assert.Equal(t, "/api/v1/user/test/bashrc/revision", path)
+		assert.JSONEq(t, `{"hash": "abc"}`, body)
+	})
+
+	t.Run("create share", func(t *testing.T) {
+		_, err := client.CreateShare("bashrc", 7*24*time.Hour, true)
+		assert.NoError(t, err)
+		assert.Equal(t, http.MethodPost, method)
+		assert.Equal(t, "/api/v1/user/test/bashrc/shares", path)
+		assert.JSONEq(t, `{"expires_in": 604800, "history": true}`, body)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		err := New(ts.URL, "test", "wrong").DeleteFile("bashrc")
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+	})
 }
@@ -0,0 +1,51 @@
+package dotfileclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Errors that match a response error with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// ResponseError is returned when the server responds with an unexpected status.
+type ResponseError struct {
+	Action     string // What the client was doing, for example "getting file list".
+	StatusCode int
+	Message    string // The plain text body that the server responded with.
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Action, e.Message)
+}
+
+// Is reports whether target is the sentinel error for the response status.
+func (e *ResponseError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return target == ErrUnauthorized
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	}
+
+	return false
+}
+
+func responseError(resp *http.Response, action string) error {
+	return &ResponseError{
+		Action:     action,
+		StatusCode: resp.StatusCode,
+		Message:    readBodyErrorMessage(resp),
+	}
+}
@@ -0,0 +1,32 @@
+package dotfileclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseError_Is(t *testing.T) {
+	for status, sentinel := range map[int]error{
+		http.StatusNotFound:        ErrNotFound,
+		http.StatusUnauthorized:    ErrUnauthorized,
+		http.StatusForbidden:       ErrUnauthorized,
+		http.StatusConflict:        ErrConflict,
+		http.StatusTooManyRequests: ErrRateLimited,
+	} {
+		err := errors.Wrap(&ResponseError{StatusCode: status}, "wrapped")
+		assert.True(t, errors.Is(err, sentinel), status)
+	}
+
+	t.Run("no match", func(t *testing.T) {
+		err := &ResponseError{StatusCode: http.StatusInternalServerError}
+		assert.False(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("error message", func(t *testing.T) {
+		err := &ResponseError{Action: "getting file list", Message: "user not found"}
+		assert.Equal(t, "getting file list: user not found", err.Error())
+	})
+}
@@ -0,0 +1,286 @@
+// Package highlight renders syntax highlighted HTML for dotfiles.
+//
+// Highlighting works line by line so that output can be split into lines.
+// Tokens are wrapped in span tags with the classes hl-comment, hl-string, hl-keyword and hl-number.
+package highlight
+
+import (
+	"html"
+	"html/template"
+	"strings"
+)
+
+// Class names of highlighted tokens.
+const (
+	classComment = "hl-comment"
+	classString  = "hl-string"
+	classKeyword = "hl-keyword"
+	classNumber  = "hl-number"
+)
+
+type syntax struct {
+	// Comments that start at the beginning of a line or after a space.
+	// Comments that are longer than one character can start anywhere.
+	lineComments []string
+
+	// Comments that must be the first thing on a line, like quotes in vim.
+	lineStartComment string
+
+	// The start and end of comments that can span lines.
+	blockComment [2]string
+
+	quotes     string // Characters that start and end strings.
+	identChars string // Characters besides letters, numbers and underscores that are in identifiers.
+	keywords   map[string]bool
+}
+
+func words(s string) map[string]bool {
+	result := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		result[w] = true
+	}
+
+	return result
+}
+
+var syntaxes = map[string]*syntax{
+	Elisp: {
+		lineComments: []string{";"},
+		quotes:       `"`,
+		identChars:   "-*",
+		keywords: words(`
+and cond defalias defcustom defface defmacro defun defvar dolist if lambda let let* nil or progn
+provide require setq setq-default t unless use-package when while`),
+	},
+	Go: {
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		keywords: words(`
+break case chan const continue default defer else fallthrough false for func go goto if import
+interface map nil package range return select struct switch true type var`),
+	},
+	INI: {
+		lineComments: []string{"#", ";"},
+		quotes:       `"'`,
+		keywords:     words("false no off on true yes"),
+	},
+	JavaScript: {
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		keywords: words(`
+async await break case catch class const continue default delete do else export extends false
+finally for function if import in instanceof let new null of return switch this throw true try
+typeof undefined var void while yield`),
+	},
+	JSON: {
+		quotes:   `"`,
+		keywords: words("false null true"),
+	},
+	Lua: {
+		lineComments: []string{"--"},
+		blockComment: [2]string{"--[[", "]]"},
+		quotes:       `"'`,
+		keywords: words(`
+and break do else elseif end false for function goto if in local nil not or repeat return then
+true until while`),
+	},
+	Python: {
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords: words(`
+False None True and as assert async await break class continue def del elif else except finally
+for from global if import in is lambda nonlocal not or pass raise return try while with yield`),
+	},
+	Ruby: {
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords: words(`
+begin break case class def do else elsif end ensure false for if in module next nil not require
+rescue return self then true unless until when while yield`),
+	},
+	Shell: {
+		lineComments: []string{"#"},
+		quotes:       "\"'`",
+		keywords: words(`
+alias bind case declare do done elif else esac eval export fi for function if in local readonly
+return select set shift source then unalias unset until while`),
+	},
+	TOML: {
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords:     words("false true"),
+	},
+	Vim: {
+		lineStartComment: `"`,
+		quotes:           `'"`,
+		keywords: words(`
+augroup autocmd call colorscheme else elseif endfor endfunction endif endwhile execute filetype
+for function if imap inoremap let map nmap nnoremap noremap return set setlocal source syntax
+tnoremap unlet vmap vnoremap while xnoremap`),
+	},
+	YAML: {
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords:     words("false no null off on true yes ~"),
+	},
+}
+
+// HTML escapes content and highlights it as language.
+// Content is only escaped when the language is unknown.
+func HTML(language, content string) template.HTML {
+	var (
+		b       strings.Builder
+		inBlock bool
+	)
+
+	s, ok := syntaxes[language]
+	if !ok {
+		return template.HTML(html.EscapeString(content))
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		if i > 0 {
+			_ = b.WriteByte('\n')
+		}
+		s.writeLine(&b, line, &inBlock)
+	}
+
+	return template.HTML(b.String())
+}
+
+// Line escapes a single line and highlights it as language.
+// It's for highlighting lines without the lines around them, like in a diff.
+func Line(language, line string) template.HTML {
+	var (
+		b       strings.Builder
+		inBlock bool
+	)
+
+	s, ok := syntaxes[language]
+	if !ok {
+		return template.HTML(html.EscapeString(line))
+	}
+
+	s.writeLine(&b, line, &inBlock)
+	return template.HTML(b.String())
+}
+
+// Writes a highlighted line to b.
+// inBlock is whether the line starts inside a block comment; it's set to whether the next line does.
+func (s *syntax) writeLine(b *strings.Builder, line string, inBlock *bool) {
+	var i, plain int
+
+	token := func(class string, end int) {
+		_, _ = b.WriteString(html.EscapeString(line[plain:i]))
+		_, _ = b.WriteString(`<span class="` + class + `">`)
+		_, _ = b.WriteString(html.EscapeString(line[i:end]))
+		_, _ = b.WriteString("</span>")
+		i, plain = end, end
+	}
+
+	for i < len(line) {
+		rest := line[i:]
+
+		if *inBlock {
+			if end := strings.Index(rest, s.blockComment[1]); end >= 0 {
+				*inBlock = false
+				token(classComment, i+end+len(s.blockComment[1]))
+			} else {
+				token(classComment, len(line))
+			}
+			continue
+		}
+		if start := s.blockComment[0]; start != "" && strings.HasPrefix(rest, start) {
+			*inBlock = true
+			if end := strings.Index(rest[len(start):], s.blockComment[1]); end >= 0 {
+				*inBlock = false
+				token(classComment, i+len(start)+end+len(s.blockComment[1]))
+			} else {
+				token(classComment, len(line))
+			}
+			continue
+		}
+		if s.isComment(line, i) {
+			token(classComment, len(line))
+			continue
+		}
+
+		c := line[i]
+		switch {
+		case strings.IndexByte(s.quotes, c) >= 0:
+			token(classString, stringEnd(line, i))
+		case isDigit(c) && (i == 0 || !s.isIdent(line[i-1])):
+			end := i
+			for end < len(line) && (s.isIdent(line[end]) || line[end] == '.') {
+				end++
+			}
+			token(classNumber, end)
+		case s.isIdent(c):
+			end := i
+			for end < len(line) && s.isIdent(line[end]) {
+				end++
+			}
+			if s.keywords[line[i:end]] {
+				token(classKeyword, end)
+			} else {
+				i = end
+			}
+		case s.keywords[string(c)]:
+			token(classKeyword, i+1)
+		default:
+			i++
+		}
+	}
+
+	_, _ = b.WriteString(html.EscapeString(line[plain:]))
+}
+
+// Returns whether a comment starts at line[i].
+func (s *syntax) isComment(line string, i int) bool {
+	rest := line[i:]
+
+	if s.lineStartComment != "" &&
+		strings.HasPrefix(rest, s.lineStartComment) &&
+		strings.TrimSpace(line[:i]) == "" {
+		return true
+	}
+
+	for _, comment := range s.lineComments {
+		if strings.HasPrefix(rest, comment) &&
+			(len(comment) > 1 || i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the index after the quote that closes the string that starts at line[start].
+// Strings that aren't closed end with the line.
+func stringEnd(line string, start int) int {
+	quote := line[start]
+
+	for i := start + 1; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+		} else if line[i] == quote {
+			return i + 1
+		}
+	}
+
+	return len(line)
+}
+
+func (s *syntax) isIdent(c byte) bool {
+	return c == '_' ||
+		isDigit(c) ||
+		('a' <= c && c <= 'z') ||
+		('A' <= c && c <= 'Z') ||
+		strings.IndexByte(s.identChars, c) >= 0
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
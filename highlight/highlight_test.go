@@ -0,0 +1,42 @@
+package highlight
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML(t *testing.T) {
+	t.Run("unknown language is escaped", func(t *testing.T) {
+		assert.Equal(t, template.HTML("&lt;b&gt; # 1"), HTML("", "<b> # 1"))
+	})
+
+	t.Run("shell", func(t *testing.T) {
+		assert.Equal(t,
+			template.HTML(`<span class="hl-keyword">export</span> EDITOR=<span class="hl-string">&#34;vim&#34;</span> <span class="hl-comment"># &lt;3</span>`+"\n"+
+				`echo ${#PATH} <span class="hl-number">10</span>`),
+			HTML(Shell, "export EDITOR=\"vim\" # <3\necho ${#PATH} 10"))
+	})
+
+	t.Run("vim comments only start lines", func(t *testing.T) {
+		assert.Equal(t,
+			template.HTML(`  <span class="hl-comment">&#34; colors</span>`+"\n"+
+				`<span class="hl-keyword">let</span> g:name = <span class="hl-string">&#34;x&#34;</span>`),
+			HTML(Vim, "  \" colors\nlet g:name = \"x\""))
+	})
+
+	t.Run("block comments span lines", func(t *testing.T) {
+		assert.Equal(t,
+			template.HTML(`<span class="hl-comment">/* a</span>`+"\n"+
+				`<span class="hl-comment">b */</span> x <span class="hl-string">&#39;\&#39;&#39;</span>`),
+			HTML(JavaScript, "/* a\nb */ x '\\''"))
+	})
+}
+
+func TestLine(t *testing.T) {
+	assert.Equal(t,
+		template.HTML(`<span class="hl-keyword">setq-default</span> indent-tabs-mode <span class="hl-keyword">nil</span>`),
+		Line(Elisp, "setq-default indent-tabs-mode nil"))
+	assert.Equal(t, template.HTML("b */ x"), Line(Go, "b */ x"))
+}
@@ -0,0 +1,120 @@
+package highlight
+
+import (
+	"path"
+	"strings"
+)
+
+// The languages that can be highlighted.
+const (
+	Elisp      = "elisp"
+	Go         = "go"
+	INI        = "ini"
+	JavaScript = "javascript"
+	JSON       = "json"
+	Lua        = "lua"
+	Python     = "python"
+	Ruby       = "ruby"
+	Shell      = "shell"
+	TOML       = "toml"
+	Vim        = "vim"
+	YAML       = "yaml"
+)
+
+// Files that are matched by their name.
+var fileNames = map[string]string{
+	".bash_aliases": Shell,
+	".bash_logout":  Shell,
+	".bash_profile": Shell,
+	".bashrc":       Shell,
+	".emacs":        Elisp,
+	".gitconfig":    INI,
+	".gvimrc":       Vim,
+	".profile":      Shell,
+	".tmux.conf":    Shell,
+	".vimrc":        Vim,
+	".xinitrc":      Shell,
+	".xprofile":     Shell,
+	".zlogin":       Shell,
+	".zprofile":     Shell,
+	".zshenv":       Shell,
+	".zshrc":        Shell,
+	"_vimrc":        Vim,
+	"bashrc":        Shell,
+	"init.el":       Elisp,
+	"vimrc":         Vim,
+	"zshrc":         Shell,
+}
+
+// Files that are matched by their extension.
+var extensions = map[string]string{
+	".bash": Shell,
+	".cfg":  INI,
+	".conf": INI,
+	".el":   Elisp,
+	".fish": Shell,
+	".go":   Go,
+	".ini":  INI,
+	".js":   JavaScript,
+	".json": JSON,
+	".lua":  Lua,
+	".py":   Python,
+	".rb":   Ruby,
+	".sh":   Shell,
+	".toml": TOML,
+	".vim":  Vim,
+	".yaml": YAML,
+	".yml":  YAML,
+	".zsh":  Shell,
+}
+
+// Interpreters that are matched by a shebang.
+var interpreters = map[string]string{
+	"bash":    Shell,
+	"dash":    Shell,
+	"fish":    Shell,
+	"ksh":     Shell,
+	"lua":     Lua,
+	"node":    JavaScript,
+	"python":  Python,
+	"python3": Python,
+	"ruby":    Ruby,
+	"sh":      Shell,
+	"zsh":     Shell,
+}
+
+// Language detects the language of a file from its path.
+// Falls back to the shebang on the first line of content.
+// Returns an empty string when the language is unknown.
+func Language(filePath, content string) string {
+	name := path.Base(filePath)
+
+	if language, ok := fileNames[strings.ToLower(name)]; ok {
+		return language
+	}
+	if language, ok := extensions[strings.ToLower(path.Ext(name))]; ok {
+		return language
+	}
+
+	return shebangLanguage(content)
+}
+
+// Returns the language of a shebang such as "#!/bin/sh" or "#!/usr/bin/env python3".
+func shebangLanguage(content string) string {
+	if !strings.HasPrefix(content, "#!") {
+		return ""
+	}
+
+	line, _, _ := strings.Cut(content[2:], "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" && len(fields) > 1 {
+		interpreter = fields[1]
+	}
+
+	return interpreters[interpreter]
+}
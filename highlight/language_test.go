@@ -0,0 +1,27 @@
+package highlight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		path, content, expected string
+	}{
+		{"~/.vimrc", "", Vim},
+		{"~/.zshrc", "", Shell},
+		{"~/.config/alacritty/alacritty.yml", "", YAML},
+		{"~/.emacs.d/init.el", "", Elisp},
+		{"~/.config/nvim/INIT.VIM", "", Vim},
+		{"~/bin/backup", "#!/bin/sh\necho hi", Shell},
+		{"~/bin/serve", "#!/usr/bin/env python3\n", Python},
+		{"~/bin/unknown", "#!/usr/bin/env\n", ""},
+		{"~/.config/i3/config", "set $mod Mod4", ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Language(test.path, test.content), test.path)
+	}
+}
@@ -4,29 +4,399 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/knoebber/dotfile/dotfile"
 	"github.com/knoebber/usererror"
 	"github.com/pkg/errors"
 )
 
+// DefaultRemoteName is the name of the remote that is created from the single remote config keys.
+const DefaultRemoteName = "origin"
+
+// Line ending policies for tracked files.
+const (
+	LineEndingsLF   = "lf"
+	LineEndingsCRLF = "crlf"
+)
+
+// Environment variables that override values in the config file.
+// Overrides are applied to the default remote and are never saved.
+const (
+	EnvDefaultRemote = "DOTFILE_DEFAULT_REMOTE"
+	EnvRemoteURL     = "DOTFILE_REMOTE_URL"
+	EnvUsername      = "DOTFILE_USERNAME"
+	EnvToken         = "DOTFILE_TOKEN"
+	EnvTokenCommand  = "DOTFILE_TOKEN_COMMAND"
+	EnvEncryptionKey = "DOTFILE_ENCRYPTION_KEY"
+)
+
 // Config contains local user settings for dotfile.
 type Config struct {
+	DefaultRemote        string                   `json:"default_remote"`
+	Remotes              map[string]*RemoteConfig `json:"remotes"`
+	Files                map[string]*FileConfig   `json:"files,omitempty"`
+	EncryptionKeyCommand string                   `json:"encryption_key_command,omitempty"`
+}
+
+// RemoteConfig contains the settings for communicating with a dotfile server.
+type RemoteConfig struct {
+	Name         string `json:"-"`
+	URL          string `json:"url"`
+	Username     string `json:"username"`
+	Token        string `json:"token"`
+	TokenCommand string `json:"token_command,omitempty"` // Prints the token when token is empty.
+}
+
+// FileConfig contains settings for a single alias.
+type FileConfig struct {
+	Remote      string `json:"remote,omitempty"`       // The only remote that the file is allowed to sync with.
+	RemoteAlias string `json:"remote_alias,omitempty"` // The alias of the file on remotes.
+	LineEndings string `json:"line_endings,omitempty"` // Converts line endings when set.
+	Encrypt     bool   `json:"encrypt,omitempty"`      // Encrypts revisions before they are pushed.
+}
+
+// Config files from before named remotes existed have a single remote at the top level.
+type legacyConfig struct {
 	Remote   string `json:"remote"`
 	Username string `json:"username"`
 	Token    string `json:"token"`
 }
 
 func (c *Config) String() string {
-	return fmt.Sprintf("remote: %q\nusername: %q\ntoken: %q",
-		c.Remote,
-		c.Username,
-		c.Token,
-	)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "default_remote: %q", c.DefaultRemote)
+
+	if c.EncryptionKeyCommand != "" {
+		fmt.Fprintf(&b, "\nencryption_key_command: %q", c.EncryptionKeyCommand)
+	}
+
+	for _, name := range c.RemoteNames() {
+		r := c.Remotes[name]
+		fmt.Fprintf(&b, "\nremote %q: url=%q username=%q token=%q", name, r.URL, r.Username, r.Token)
+		if r.TokenCommand != "" {
+			fmt.Fprintf(&b, " token_command=%q", r.TokenCommand)
+		}
+	}
+
+	for _, alias := range sortedKeys(c.Files) {
+		f := c.Files[alias]
+		fmt.Fprintf(&b, "\nfile %q: remote=%q remote_alias=%q line_endings=%q encrypt=%t",
+			alias, f.Remote, f.RemoteAlias, f.LineEndings, f.Encrypt)
+	}
+
+	return b.String()
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// RemoteNames returns the names of the configured remotes in sorted order.
+func (c *Config) RemoteNames() []string {
+	return sortedKeys(c.Remotes)
+}
+
+// Remote returns the remote with name.
+// Returns the default remote when name is empty.
+func (c *Config) Remote(name string) (*RemoteConfig, error) {
+	if name == "" {
+		name = c.DefaultRemote
+	}
+	if name == "" && len(c.Remotes) == 1 {
+		name = c.RemoteNames()[0]
+	}
+	if name == "" {
+		return nil, usererror.New("No default remote is set (use dotfile remote default <name>)")
+	}
+
+	r, ok := c.Remotes[name]
+	if !ok {
+		return nil, usererror.Format("Remote %q does not exist", name)
+	}
+
+	r.Name = name
+	return r, nil
+}
+
+// RemoteForAlias returns the remote that alias is allowed to sync with.
+// Returns an error when alias is assigned to a remote other than name.
+func (c *Config) RemoteForAlias(alias, name string) (*RemoteConfig, error) {
+	fc, ok := c.Files[alias]
+	if !ok || fc.Remote == "" {
+		return c.Remote(name)
+	}
+
+	if name != "" && name != fc.Remote {
+		return nil, usererror.Format("%q is assigned to remote %q", alias, fc.Remote)
+	}
+
+	return c.Remote(fc.Remote)
+}
+
+// AddRemote adds a new named remote.
+func (c *Config) AddRemote(name, url, username, token string) error {
+	if err := dotfile.CheckAlias(name); err != nil {
+		return err
+	}
+	if _, ok := c.Remotes[name]; ok {
+		return usererror.Format("Remote %q already exists", name)
+	}
+	if c.Remotes == nil {
+		c.Remotes = make(map[string]*RemoteConfig)
+	}
+
+	c.Remotes[name] = &RemoteConfig{
+		URL:      strings.TrimSuffix(url, "/"),
+		Username: username,
+		Token:    token,
+	}
+
+	if c.DefaultRemote == "" {
+		c.DefaultRemote = name
+	}
+	return nil
+}
+
+// RemoveRemote removes a remote and unassigns files that were using it.
+func (c *Config) RemoveRemote(name string) error {
+	if _, ok := c.Remotes[name]; !ok {
+		return usererror.Format("Remote %q does not exist", name)
+	}
+
+	delete(c.Remotes, name)
+	if c.DefaultRemote == name {
+		c.DefaultRemote = ""
+	}
+
+	for _, fc := range c.Files {
+		if fc.Remote == name {
+			fc.Remote = ""
+		}
+	}
+	c.removeEmptyFiles()
+	return nil
+}
+
+// SetDefaultRemote sets the remote that is used when a command doesn't name one.
+func (c *Config) SetDefaultRemote(name string) error {
+	if _, ok := c.Remotes[name]; !ok {
+		return usererror.Format("Remote %q does not exist", name)
+	}
+
+	c.DefaultRemote = name
+	return nil
+}
+
+// AssignRemote restricts alias to only sync with the remote name.
+// Removes the assignment when name is empty.
+func (c *Config) AssignRemote(alias, name string) error {
+	if name != "" {
+		if _, ok := c.Remotes[name]; !ok {
+			return usererror.Format("Remote %q does not exist", name)
+		}
+	}
+
+	c.file(alias).Remote = name
+	c.removeEmptyFiles()
+	return nil
+}
+
+// Returns the file config for alias, creating it when it doesn't exist.
+func (c *Config) file(alias string) *FileConfig {
+	if c.Files == nil {
+		c.Files = make(map[string]*FileConfig)
+	}
+	if _, ok := c.Files[alias]; !ok {
+		c.Files[alias] = new(FileConfig)
+	}
+
+	return c.Files[alias]
+}
+
+// File returns the settings for alias.
+// Returns empty settings when alias has none.
+func (c *Config) File(alias string) FileConfig {
+	if fc, ok := c.Files[alias]; ok {
+		return *fc
+	}
+
+	return FileConfig{}
+}
+
+// RenameFile moves the settings for alias to newAlias.
+func (c *Config) RenameFile(alias, newAlias string) {
+	if fc, ok := c.Files[alias]; ok {
+		delete(c.Files, alias)
+		c.Files[newAlias] = fc
+	}
+}
+
+// LocalAlias returns the local alias of a file that is named remoteAlias on remotes.
+func (c *Config) LocalAlias(remoteAlias string) string {
+	for alias, fc := range c.Files {
+		if fc.RemoteAlias == remoteAlias {
+			return alias
+		}
+	}
+
+	return remoteAlias
+}
+
+// EncryptionKey returns the passphrase used for files that have encryption enabled.
+func (c *Config) EncryptionKey() ([]byte, error) {
+	if key := os.Getenv(EnvEncryptionKey); key != "" {
+		return []byte(key), nil
+	}
+	if c.EncryptionKeyCommand == "" {
+		return nil, usererror.Format("Encryption is enabled but neither %s nor encryption_key_command is set", EnvEncryptionKey)
+	}
+
+	key, err := runSecretCommand(c.EncryptionKeyCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(key), nil
+}
+
+// ResolveToken returns the token for the remote.
+// Runs the token command when the token is not set.
+func (r *RemoteConfig) ResolveToken() (string, error) {
+	if r.Token != "" || r.TokenCommand == "" {
+		return r.Token, nil
+	}
+
+	return runSecretCommand(r.TokenCommand)
+}
+
+// Runs command with the shell and returns its trimmed output.
+// Stdin and stderr are passed through so that the command can prompt.
+func runSecretCommand(command string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "running %q", command)
+	}
+
+	result := strings.TrimSpace(string(out))
+	if result == "" {
+		return "", usererror.Format("Command %q printed nothing", command)
+	}
+
+	return result, nil
+}
+
+func (c *Config) removeEmptyFiles() {
+	for alias, fc := range c.Files {
+		if *fc == (FileConfig{}) {
+			delete(c.Files, alias)
+		}
+	}
+}
+
+// Sets a value on the default remote.
+// Creates the default remote when there are no remotes.
+func (c *Config) setDefaultRemoteValue(key, value string) error {
+	if len(c.Remotes) == 0 {
+		if err := c.AddRemote(DefaultRemoteName, "", "", ""); err != nil {
+			return err
+		}
+	}
+
+	r, err := c.Remote("")
+	if err != nil {
+		return err
+	}
+
+	switch key {
+	case "remote":
+		r.URL = strings.TrimSuffix(value, "/")
+	case "username":
+		r.Username = value
+	case "token":
+		r.Token = value
+	case "token_command":
+		r.TokenCommand = value
+	}
+	return nil
+}
+
+// Applies environment variable overrides.
+func (c *Config) applyEnv() error {
+	if name := os.Getenv(EnvDefaultRemote); name != "" {
+		c.DefaultRemote = name
+	}
+
+	for _, override := range []struct{ env, key string }{
+		{EnvRemoteURL, "remote"},
+		{EnvUsername, "username"},
+		{EnvTokenCommand, "token_command"},
+		{EnvToken, "token"},
+	} {
+		value := os.Getenv(override.env)
+		if value == "" {
+			continue
+		}
+		if err := c.setDefaultRemoteValue(override.key, value); err != nil {
+			return err
+		}
+		// A saved token would be used before the command.
+		if override.key == "token_command" {
+			if err := c.setDefaultRemoteValue("token", ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Moves single remote settings into the remotes map.
+func (c *Config) migrate(legacy *legacyConfig) {
+	if len(c.Remotes) > 0 || *legacy == (legacyConfig{}) {
+		return
+	}
+
+	c.DefaultRemote = DefaultRemoteName
+	c.Remotes = map[string]*RemoteConfig{
+		DefaultRemoteName: {
+			URL:      strings.TrimSuffix(legacy.Remote, "/"),
+			Username: legacy.Username,
+			Token:    legacy.Token,
+		},
+	}
+}
+
+func (c *Config) save(path string) error {
+	bytes, err := json.MarshalIndent(c, "", jsonIndent)
+	if err != nil {
+		return errors.Wrap(err, "marshalling config")
+	}
+
+	if err = os.WriteFile(path, bytes, 0644); err != nil {
+		return errors.Wrap(err, "saving config file")
+	}
+
+	return nil
 }
 
 func createDefaultConfig(path string) ([]byte, error) {
-	var newCfg Config
+	newCfg := &Config{Remotes: make(map[string]*RemoteConfig)}
 
 	bytes, err := json.MarshalIndent(newCfg, "", jsonIndent)
 	if err != nil {
@@ -69,10 +439,36 @@ func DefaultConfigPath() (string, error) {
 	return filepath.Join(dotfileDir, "dotfile.json"), nil
 }
 
-// ReadConfig reads the user's config.
+// DefaultConfig returns the config that is used before a config file is created.
+// Environment variable overrides are applied.
+func DefaultConfig() (*Config, error) {
+	cfg := &Config{Remotes: make(map[string]*RemoteConfig)}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// ReadConfig reads the user's config and applies environment variable overrides.
 // Creates a default file when it doesn't yet exist.
 func ReadConfig(path string) (*Config, error) {
+	cfg, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func readConfigFile(path string) (*Config, error) {
 	cfg := new(Config)
+	legacy := new(legacyConfig)
 
 	bytes, err := configBytes(path)
 	if err != nil {
@@ -82,36 +478,193 @@ func ReadConfig(path string) (*Config, error) {
 	if err = json.Unmarshal(bytes, cfg); err != nil {
 		return nil, errors.Wrap(err, "unmarshalling config")
 	}
+	if err = json.Unmarshal(bytes, legacy); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling legacy config")
+	}
 
+	cfg.migrate(legacy)
 	return cfg, nil
 }
 
+// UpdateConfig reads the user's config, applies update, and saves the result.
+// Environment variable overrides are not saved.
+func UpdateConfig(path string, update func(*Config) error) error {
+	cfg, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := update(cfg); err != nil {
+		return err
+	}
+
+	return cfg.save(path)
+}
+
 // SetConfig sets a value in the dotfile config json file.
+// See Config.Set for valid keys.
 func SetConfig(path, key, value string) error {
-	cfg := make(map[string]*string)
+	return UpdateConfig(path, func(cfg *Config) error {
+		return cfg.Set(key, value)
+	})
+}
 
-	bytes, err := configBytes(path)
-	if err != nil {
-		return err
+// Get returns the value at key.
+// See Config.Set for valid keys.
+func (c *Config) Get(key string) (string, error) {
+	parts := strings.Split(key, ".")
+
+	switch {
+	case key == "default_remote":
+		return c.DefaultRemote, nil
+	case key == "encryption_key_command":
+		return c.EncryptionKeyCommand, nil
+	case isDefaultRemoteKey(key):
+		r, err := c.Remote("")
+		if err != nil {
+			return "", err
+		}
+		return r.get(key)
+	case len(parts) == 3 && parts[0] == "remotes":
+		r, err := c.Remote(parts[1])
+		if err != nil {
+			return "", err
+		}
+		return r.get(parts[2])
+	case len(parts) == 3 && parts[0] == "files":
+		fc := c.File(parts[1])
+		return fc.get(parts[2])
 	}
 
-	if err = json.Unmarshal(bytes, &cfg); err != nil {
-		return errors.Wrapf(err, "unmarshaling user config to map")
+	return "", invalidKey(key)
+}
+
+// Set sets the value at key.
+//
+// Valid keys:
+//
+//	default_remote
+//	encryption_key_command
+//	remote, username, token, token_command - sets values on the default remote
+//	remotes.<name>.<url|username|token|token_command>
+//	files.<alias>.<remote|remote_alias|line_endings|encrypt>
+func (c *Config) Set(key, value string) error {
+	parts := strings.Split(key, ".")
+
+	switch {
+	case key == "default_remote":
+		return c.SetDefaultRemote(value)
+	case key == "encryption_key_command":
+		c.EncryptionKeyCommand = value
+		return nil
+	case isDefaultRemoteKey(key):
+		return c.setDefaultRemoteValue(key, value)
+	case len(parts) == 3 && parts[0] == "remotes":
+		if _, ok := c.Remotes[parts[1]]; !ok {
+			if err := c.AddRemote(parts[1], "", "", ""); err != nil {
+				return err
+			}
+		}
+		return c.Remotes[parts[1]].set(parts[2], value)
+	case len(parts) == 3 && parts[0] == "files":
+		if parts[2] == "remote" {
+			return c.AssignRemote(parts[1], value)
+		}
+		if err := c.file(parts[1]).set(parts[2], value); err != nil {
+			c.removeEmptyFiles()
+			return err
+		}
+		c.removeEmptyFiles()
+		return nil
 	}
 
-	if _, ok := cfg[key]; !ok {
-		return usererror.Format("%q is not a valid config key", key)
+	return invalidKey(key)
+}
+
+// Keys from before named remotes that read and set values on the default remote.
+func isDefaultRemoteKey(key string) bool {
+	switch key {
+	case "remote", "username", "token", "token_command":
+		return true
 	}
 
-	cfg[key] = &value
+	return false
+}
 
-	bytes, err = json.MarshalIndent(cfg, "", jsonIndent)
-	if err != nil {
-		return errors.Wrap(err, "marshalling updated config map")
+func invalidKey(key string) error {
+	return usererror.Format("%q is not a valid config key", key)
+}
+
+func (r *RemoteConfig) get(key string) (string, error) {
+	switch key {
+	case "url", "remote":
+		return r.URL, nil
+	case "username":
+		return r.Username, nil
+	case "token":
+		return r.Token, nil
+	case "token_command":
+		return r.TokenCommand, nil
 	}
 
-	if err = os.WriteFile(path, bytes, 0644); err != nil {
-		return errors.Wrap(err, "saving updated config file")
+	return "", invalidKey(key)
+}
+
+func (r *RemoteConfig) set(key, value string) error {
+	switch key {
+	case "url":
+		r.URL = strings.TrimSuffix(value, "/")
+	case "username":
+		r.Username = value
+	case "token":
+		r.Token = value
+	case "token_command":
+		r.TokenCommand = value
+	default:
+		return invalidKey(key)
+	}
+
+	return nil
+}
+
+func (f *FileConfig) get(key string) (string, error) {
+	switch key {
+	case "remote":
+		return f.Remote, nil
+	case "remote_alias":
+		return f.RemoteAlias, nil
+	case "line_endings":
+		return f.LineEndings, nil
+	case "encrypt":
+		return strconv.FormatBool(f.Encrypt), nil
+	}
+
+	return "", invalidKey(key)
+}
+
+func (f *FileConfig) set(key, value string) (err error) {
+	switch key {
+	case "remote_alias":
+		if value != "" {
+			if err := dotfile.CheckAlias(value); err != nil {
+				return err
+			}
+		}
+		f.RemoteAlias = value
+	case "line_endings":
+		if value != "" && value != LineEndingsLF && value != LineEndingsCRLF {
+			return usererror.Format("line_endings must be %q or %q", LineEndingsLF, LineEndingsCRLF)
+		}
+		f.LineEndings = value
+	case "encrypt":
+		if value == "" {
+			value = "false"
+		}
+		if f.Encrypt, err = strconv.ParseBool(value); err != nil {
+			return usererror.New("encrypt must be true or false")
+		}
+	default:
+		return invalidKey(key)
 	}
 
 	return nil
@@ -38,7 +38,27 @@ func TestReadConfig(t *testing.T) {
 		config, err := ReadConfig(testConfigPath)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, config.String())
-		assert.Equal(t, config.Username, "test")
+		remote, err := config.Remote("")
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultRemoteName, remote.Name)
+		assert.Equal(t, "test", remote.Username)
+	})
+
+	t.Run("migrates single remote config", func(t *testing.T) {
+		legacy := `{"remote": "https://dotfilehub.com/", "username": "test", "token": "abc"}`
+		if err := os.WriteFile(testConfigPath, []byte(legacy), 0644); err != nil {
+			t.Fatalf("setting up %s: %v", testConfigPath, err)
+		}
+
+		config, err := ReadConfig(testConfigPath)
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultRemoteName, config.DefaultRemote)
+
+		remote, err := config.Remote("")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://dotfilehub.com", remote.URL)
+		assert.Equal(t, "test", remote.Username)
+		assert.Equal(t, "abc", remote.Token)
 	})
 }
 
@@ -62,3 +82,239 @@ func TestSetConfig(t *testing.T) {
 		assert.Error(t, SetConfig(testConfigPath, "nokey", ""))
 	})
 }
+
+func TestConfigRemotes(t *testing.T) {
+	config := new(Config)
+
+	t.Run("error when there are no remotes", func(t *testing.T) {
+		_, err := config.Remote("")
+		assert.Error(t, err)
+	})
+
+	t.Run("first remote is default", func(t *testing.T) {
+		assert.NoError(t, config.AddRemote("work", "https://dotfile.example.com", "work-user", "a"))
+		assert.NoError(t, config.AddRemote("public", "https://dotfilehub.com", "public-user", "b"))
+		assert.Equal(t, "work", config.DefaultRemote)
+		assert.Equal(t, []string{"public", "work"}, config.RemoteNames())
+	})
+
+	t.Run("error when remote exists", func(t *testing.T) {
+		assert.Error(t, config.AddRemote("work", "", "", ""))
+	})
+
+	t.Run("error when name is invalid", func(t *testing.T) {
+		assert.Error(t, config.AddRemote("bad name", "", "", ""))
+	})
+
+	t.Run("remote by name", func(t *testing.T) {
+		remote, err := config.Remote("public")
+		assert.NoError(t, err)
+		assert.Equal(t, "public-user", remote.Username)
+
+		_, err = config.Remote("missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("assigned file can only use its remote", func(t *testing.T) {
+		assert.NoError(t, config.AssignRemote("bashrc", "work"))
+
+		remote, err := config.RemoteForAlias("bashrc", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "work", remote.Name)
+
+		_, err = config.RemoteForAlias("bashrc", "public")
+		assert.Error(t, err)
+
+		remote, err = config.RemoteForAlias("vimrc", "public")
+		assert.NoError(t, err)
+		assert.Equal(t, "public", remote.Name)
+	})
+
+	t.Run("removing remote unassigns files", func(t *testing.T) {
+		assert.NoError(t, config.RemoveRemote("work"))
+		assert.Empty(t, config.DefaultRemote)
+		assert.Empty(t, config.Files)
+		assert.Error(t, config.RemoveRemote("work"))
+	})
+
+	t.Run("set default remote", func(t *testing.T) {
+		assert.Error(t, config.SetDefaultRemote("work"))
+		assert.NoError(t, config.SetDefaultRemote("public"))
+		assert.Equal(t, "public", config.DefaultRemote)
+	})
+}
+
+func TestConfig_Set(t *testing.T) {
+	config := new(Config)
+
+	t.Run("error when key is invalid", func(t *testing.T) {
+		for _, key := range []string{"url", "remotes.origin", "remotes.origin.nokey", "files.bashrc.nokey", "nokey"} {
+			assert.Error(t, config.Set(key, "value"), key)
+		}
+	})
+
+	t.Run("error when value is invalid", func(t *testing.T) {
+		assert.Error(t, config.Set("files.bashrc.line_endings", "cr"))
+		assert.Error(t, config.Set("files.bashrc.encrypt", "maybe"))
+		assert.Error(t, config.Set("files.bashrc.remote_alias", "Bad Alias"))
+		assert.Empty(t, config.Files)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		for _, kv := range [][2]string{
+			{"remotes.work.url", "https://dotfile.example.com/"},
+			{"remotes.work.username", "test"},
+			{"remotes.work.token_command", "pass show dotfile"},
+			{"files.bashrc.remote", "work"},
+			{"files.bashrc.remote_alias", "work-bashrc"},
+			{"files.bashrc.line_endings", "crlf"},
+			{"files.bashrc.encrypt", "true"},
+			{"encryption_key_command", "pass show dotfile-key"},
+			{"default_remote", "work"},
+		} {
+			assert.NoError(t, config.Set(kv[0], kv[1]), kv[0])
+		}
+
+		for key, expected := range map[string]string{
+			"remote":                    "https://dotfile.example.com",
+			"username":                  "test",
+			"token":                     "",
+			"token_command":             "pass show dotfile",
+			"remotes.work.url":          "https://dotfile.example.com",
+			"files.bashrc.remote_alias": "work-bashrc",
+			"files.bashrc.encrypt":      "true",
+			"files.vimrc.encrypt":       "false",
+			"encryption_key_command":    "pass show dotfile-key",
+		} {
+			value, err := config.Get(key)
+			assert.NoError(t, err, key)
+			assert.Equal(t, expected, value, key)
+		}
+
+		assert.Equal(t, "bashrc", config.LocalAlias("work-bashrc"))
+		assert.Equal(t, "vimrc", config.LocalAlias("vimrc"))
+	})
+
+	t.Run("rename file", func(t *testing.T) {
+		config.RenameFile("bashrc", "bash")
+		assert.Equal(t, "work", config.File("bash").Remote)
+		assert.Empty(t, config.File("bashrc").Remote)
+	})
+}
+
+func TestReadConfig_env(t *testing.T) {
+	_ = os.Mkdir(testDir, 0755)
+	_ = os.Remove(testConfigPath)
+	defer clearTestStorage()
+
+	assert.NoError(t, SetConfig(testConfigPath, "username", "saved"))
+
+	t.Setenv(EnvRemoteURL, "https://dotfilehub.com")
+	t.Setenv(EnvUsername, "ci")
+	t.Setenv(EnvToken, "secret")
+
+	config, err := ReadConfig(testConfigPath)
+	assert.NoError(t, err)
+
+	remote, err := config.Remote("")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://dotfilehub.com", remote.URL)
+	assert.Equal(t, "ci", remote.Username)
+	assert.Equal(t, "secret", remote.Token)
+
+	t.Run("overrides are not saved", func(t *testing.T) {
+		assert.NoError(t, SetConfig(testConfigPath, "token_command", "echo token"))
+
+		config, err := readConfigFile(testConfigPath)
+		assert.NoError(t, err)
+		assert.Equal(t, "saved", config.Remotes[DefaultRemoteName].Username)
+		assert.Empty(t, config.Remotes[DefaultRemoteName].Token)
+	})
+}
+
+func TestReadConfig_envTokenCommand(t *testing.T) {
+	_ = os.Mkdir(testDir, 0755)
+	_ = os.Remove(testConfigPath)
+	defer clearTestStorage()
+
+	assert.NoError(t, SetConfig(testConfigPath, "token", "saved"))
+	t.Setenv(EnvTokenCommand, "echo command")
+
+	config, err := ReadConfig(testConfigPath)
+	assert.NoError(t, err)
+
+	remote, err := config.Remote("")
+	assert.NoError(t, err)
+
+	token, err := remote.ResolveToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "command", token, "the saved token doesn't hide the override")
+
+	t.Run("token override is preferred", func(t *testing.T) {
+		t.Setenv(EnvToken, "secret")
+
+		config, err := ReadConfig(testConfigPath)
+		assert.NoError(t, err)
+
+		remote, err := config.Remote("")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", remote.Token)
+	})
+}
+
+func TestDefaultConfig(t *testing.T) {
+	t.Setenv(EnvRemoteURL, "https://dotfilehub.com/")
+	t.Setenv(EnvUsername, "ci")
+
+	config, err := DefaultConfig()
+	assert.NoError(t, err)
+
+	remote, err := config.Remote("")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://dotfilehub.com", remote.URL)
+	assert.Equal(t, "ci", remote.Username)
+}
+
+func TestRemoteConfig_ResolveToken(t *testing.T) {
+	t.Run("token is preferred", func(t *testing.T) {
+		token, err := (&RemoteConfig{Token: "saved", TokenCommand: "echo command"}).ResolveToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "saved", token)
+	})
+
+	t.Run("runs token command", func(t *testing.T) {
+		token, err := (&RemoteConfig{TokenCommand: "echo command"}).ResolveToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "command", token)
+	})
+
+	t.Run("error when token command fails", func(t *testing.T) {
+		_, err := (&RemoteConfig{TokenCommand: "exit 1"}).ResolveToken()
+		assert.Error(t, err)
+	})
+
+	t.Run("error when token command prints nothing", func(t *testing.T) {
+		_, err := (&RemoteConfig{TokenCommand: "true"}).ResolveToken()
+		assert.Error(t, err)
+	})
+}
+
+func TestConfig_EncryptionKey(t *testing.T) {
+	t.Run("error when key is not set", func(t *testing.T) {
+		_, err := new(Config).EncryptionKey()
+		assert.Error(t, err)
+	})
+
+	t.Run("runs key command", func(t *testing.T) {
+		key, err := (&Config{EncryptionKeyCommand: "echo secret"}).EncryptionKey()
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(key))
+	})
+
+	t.Run("env is preferred", func(t *testing.T) {
+		t.Setenv(EnvEncryptionKey, "env-secret")
+		key, err := (&Config{EncryptionKeyCommand: "echo secret"}).EncryptionKey()
+		assert.NoError(t, err)
+		assert.Equal(t, "env-secret", string(key))
+	})
+}
@@ -0,0 +1,164 @@
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/knoebber/dotfile/dotfileclient"
+	"github.com/pkg/errors"
+)
+
+// RemoteState is the last known state of a file on a remote.
+// States are saved in the file's directory keyed by remote name.
+//
+// Example: ~/.local/share/dotfile/emacs/remotes.json
+type RemoteState struct {
+	Revision  string           `json:"revision"`
+	Commits   []dotfile.Commit `json:"commits"`
+	UpdatedAt int64            `json:"updated_at"` // Unix timestamp.
+}
+
+func (s *Storage) remotesPath() string {
+	return filepath.Join(s.Dir, s.Alias, "remotes.json")
+}
+
+// RemoteStates returns the last known state of the file on each remote.
+func (s *Storage) RemoteStates() (map[string]*RemoteState, error) {
+	states := make(map[string]*RemoteState)
+
+	content, err := os.ReadFile(s.remotesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "reading remote states")
+	}
+
+	if err := json.Unmarshal(content, &states); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling remote states")
+	}
+
+	return states, nil
+}
+
+// Saves data as the state of the file on the remote that storage is using.
+// Does nothing when RemoteName is not set.
+func (s *Storage) saveRemoteState(data *dotfile.TrackingData) error {
+	if s.RemoteName == "" {
+		return nil
+	}
+
+	states, err := s.RemoteStates()
+	if err != nil {
+		return err
+	}
+
+	states[s.RemoteName] = &RemoteState{
+		Revision:  data.Revision,
+		Commits:   data.Commits,
+		UpdatedAt: time.Now().Unix(),
+	}
+
+	content, err := json.MarshalIndent(states, "", jsonIndent)
+	if err != nil {
+		return errors.Wrap(err, "marshalling remote states")
+	}
+
+	if err := createDir(filepath.Join(s.Dir, s.Alias)); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(s.remotesPath(), content, 0644); err != nil {
+		return errors.Wrap(err, "saving remote states")
+	}
+
+	return nil
+}
+
+// Returns the hashes that don't have a revision file in storage.
+func (s *Storage) missingRevisions(hashes []string) []string {
+	var missing []string
+
+	for _, hash := range hashes {
+		if !exists(filepath.Join(s.Dir, s.Alias, hash)) {
+			missing = append(missing, hash)
+		}
+	}
+
+	return missing
+}
+
+// Downloads revisions from remote and saves them in storage.
+func (s *Storage) downloadRevisions(client *dotfileclient.Client, hashes []string) error {
+	revisions, err := client.Revisions(s.remoteAlias(), s.missingRevisions(hashes))
+	if err != nil {
+		return err
+	}
+
+	for _, revision := range revisions {
+		content, err := s.pulledRevision(revision)
+		if err != nil {
+			return err
+		}
+		if err = writeCommit(content, s.Dir, s.Alias, revision.Hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Fetch downloads new revisions from a dotfile server and records the state of the file on the remote.
+// The tracked file and its commits are not changed.
+func (s *Storage) Fetch(client *dotfileclient.Client) error {
+	if s.FileData == nil {
+		return ErrNoData
+	}
+	if s.RemoteName == "" {
+		return errors.New("cannot fetch: remote name is empty")
+	}
+
+	remoteData, err := client.TrackingData(s.remoteAlias())
+	if err != nil {
+		return err
+	}
+	if remoteData == nil {
+		return fmt.Errorf("%q not found on remote %q", s.remoteAlias(), client.Remote)
+	}
+
+	_, behind := AheadBehind(s.FileData.Commits, remoteData.Commits)
+	fmt.Printf("fetched %d new revisions for %s from %s\n", len(behind), s.Alias, s.RemoteName)
+
+	if err := s.downloadRevisions(client, behind); err != nil {
+		return err
+	}
+
+	return s.saveRemoteState(remoteData)
+}
+
+// AheadBehind compares local commits to remote commits.
+// Returns the hashes that are only in local and the hashes that are only in remote.
+func AheadBehind(local, remote []dotfile.Commit) (ahead, behind []string) {
+	localHashes := make(map[string]bool)
+	remoteHashes := make(map[string]bool)
+
+	for _, c := range local {
+		localHashes[c.Hash] = true
+	}
+	for _, c := range remote {
+		remoteHashes[c.Hash] = true
+		if !localHashes[c.Hash] {
+			behind = append(behind, c.Hash)
+		}
+	}
+	for _, c := range local {
+		if !remoteHashes[c.Hash] {
+			ahead = append(ahead, c.Hash)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,58 @@
+package local
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_Fetch(t *testing.T) {
+	const remoteContent = testContent + "Remote line.\n"
+
+	t.Run("error when data not loaded", func(t *testing.T) {
+		assert.Error(t, testStorage().Fetch(nil))
+	})
+
+	t.Run("error when remote name is empty", func(t *testing.T) {
+		s := setupTestFile(t)
+		assert.Error(t, s.Fetch(testRemote(t, s, remoteContent)))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		s := setupTestFile(t)
+		s.RemoteName = "origin"
+		client := testRemote(t, s, remoteContent)
+		updateTestFile(t)
+
+		assert.NoError(t, s.Fetch(client))
+		assert.Len(t, s.FileData.Commits, 1)
+
+		content, err := s.DirtyContent()
+		assert.NoError(t, err)
+		assert.Equal(t, testUpdatedContent, string(content))
+
+		states, err := s.RemoteStates()
+		assert.NoError(t, err)
+		assert.Len(t, states["origin"].Commits, 2)
+		assert.FileExists(t, filepath.Join(testDir, testAlias, states["origin"].Revision))
+
+		ahead, behind := AheadBehind(s.FileData.Commits, states["origin"].Commits)
+		assert.Empty(t, ahead)
+		assert.Equal(t, []string{states["origin"].Revision}, behind)
+
+		// The fetched revision can be diffed offline.
+		_, err = dotfile.Diff(s, states["origin"].Revision, "", dotfile.DiffOptions{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestAheadBehind(t *testing.T) {
+	local := []dotfile.Commit{{Hash: "a"}, {Hash: "b"}, {Hash: "c"}}
+	remote := []dotfile.Commit{{Hash: "a"}, {Hash: "d"}}
+
+	ahead, behind := AheadBehind(local, remote)
+	assert.Equal(t, []string{"b", "c"}, ahead)
+	assert.Equal(t, []string{"d"}, behind)
+}
@@ -20,6 +20,13 @@ const (
 	testUpdatedContent = testContent + "Some new content.\nNew lines!\n"
 )
 
+// Removes the test storage after the tests so that runs don't leave files in the tree.
+func TestMain(m *testing.M) {
+	code := m.Run()
+	clearTestStorage()
+	os.Exit(code)
+}
+
 func initTestData(t *testing.T) {
 	_ = os.Mkdir(testDir, 0755)
 	writeTestFile(t, []byte(testContent))
@@ -23,7 +23,13 @@ func failIf(t *testing.T, err error, context ...string) {
 }
 
 func TestDotfilehubIntegration(t *testing.T) {
+	wd, err := os.Getwd()
+	failIf(t, err)
 	failIf(t, os.Chdir("../server"), "changing directory so that assets work in integration test")
+	defer func() {
+		clearTestStorage()
+		failIf(t, os.Chdir(wd), "restoring working directory")
+	}()
 
 	setupTestFile(t)
 	dotfilehub, err := server.New(server.Config{
@@ -61,7 +67,7 @@ func TestDotfilehubIntegration(t *testing.T) {
 	failIf(t, temp.Create(db.Connection), "creating temp file")
 	failIf(t, db.InitOrCommit(user.ID, testAlias, "new content on server"), "committing to file on server")
 
-	failIf(t, s.Pull(client))
+	failIf(t, s.Pull(client, PullAbort))
 	content, err := s.DirtyContent()
 	failIf(t, err)
 
@@ -0,0 +1,144 @@
+package local
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/knoebber/dotfile/dotfileclient"
+	"github.com/pkg/errors"
+)
+
+// Methods for changing a file locally and on a remote together.
+// Local changes are undone when the remote change fails.
+
+// RenameRemote changes the alias of the file locally and on remote.
+// The remote file is renamed to newAlias.
+func (s *Storage) RenameRemote(client *dotfileclient.Client, newAlias string) error {
+	oldAlias, remoteAlias := s.Alias, s.remoteAlias()
+
+	if err := s.Rename(newAlias); err != nil {
+		return err
+	}
+
+	data, err := client.UpdateFile(remoteAlias, newAlias, "")
+	if err != nil {
+		return rollback(err, func() error { return s.Rename(oldAlias) })
+	}
+
+	s.RemoteAlias = ""
+	return s.saveRemoteState(data)
+}
+
+// MoveRemote moves the file and changes its path on remote.
+// The path is changed for every host that doesn't have its own path.
+func (s *Storage) MoveRemote(client *dotfileclient.Client, newPath string, parentDirs bool) error {
+	if s.FileData == nil {
+		return ErrNoData
+	}
+
+	oldPath, err := s.Path()
+	if err != nil {
+		return err
+	}
+
+	host, err := s.host()
+	if err != nil {
+		return err
+	}
+
+	oldData := *s.FileData
+	oldData.Hosts = make(map[string]string)
+	for h, path := range s.FileData.Hosts {
+		oldData.Hosts[h] = path
+	}
+
+	if err := s.Move(newPath, parentDirs, false); err != nil {
+		return err
+	}
+
+	undo := func() error {
+		if err := os.Rename(newPath, oldPath); err != nil {
+			return err
+		}
+
+		s.FileData = &oldData
+		return s.save()
+	}
+
+	// The new path is shared, so it replaces this host's own path.
+	if path, ok := s.FileData.Hosts[host]; ok {
+		s.FileData.Path = path
+		delete(s.FileData.Hosts, host)
+
+		if err := s.save(); err != nil {
+			return rollback(err, undo)
+		}
+	}
+
+	data, err := client.UpdateFile(s.remoteAlias(), "", s.FileData.Path)
+	if err != nil {
+		return rollback(err, undo)
+	}
+
+	return s.saveRemoteState(data)
+}
+
+// CheckoutRemote sets the file to the revision at hash locally and on remote.
+func (s *Storage) CheckoutRemote(client *dotfileclient.Client, hash string) error {
+	if s.FileData == nil {
+		return ErrNoData
+	}
+
+	oldRevision := s.FileData.Revision
+	oldContent, err := s.DirtyContent()
+	if err != nil {
+		return err
+	}
+
+	if err := dotfile.Checkout(s, hash); err != nil {
+		return err
+	}
+
+	data, err := client.SetRevision(s.remoteAlias(), hash)
+	if err != nil {
+		return rollback(err, func() error {
+			return s.Revert(bytes.NewBuffer(oldContent), oldRevision)
+		})
+	}
+
+	return s.saveRemoteState(data)
+}
+
+// ForgetRemote removes the file's tracking data locally and deletes the file on remote.
+// When commitsOnly is set only the commits except the current are removed.
+//
+// Removed data can't be restored so the remote is changed first.
+func (s *Storage) ForgetRemote(client *dotfileclient.Client, commitsOnly bool) error {
+	if !commitsOnly {
+		if err := client.DeleteFile(s.remoteAlias()); err != nil {
+			return err
+		}
+
+		return s.Forget()
+	}
+
+	data, err := client.ClearCommits(s.remoteAlias())
+	if err != nil {
+		return err
+	}
+	if err := s.RemoveCommits(); err != nil {
+		return err
+	}
+
+	return s.saveRemoteState(data)
+}
+
+// Returns err after undoing a local change.
+func rollback(err error, undo func() error) error {
+	if undoErr := undo(); undoErr != nil {
+		return errors.Wrapf(err, "failed to undo local change (%s)", undoErr)
+	}
+
+	return err
+}
@@ -0,0 +1,133 @@
+package local
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/knoebber/dotfile/dotfileclient"
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns a client for a remote that responds to file management requests with status.
+// Successful responses echo the file data of s.
+func testManagementRemote(t *testing.T, s *Storage, status int) *dotfileclient.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			http.Error(w, "remote error", status)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(s.FileData)
+	}))
+	t.Cleanup(server.Close)
+
+	client := dotfileclient.New(server.URL, "user", "token")
+	client.Retries = 0
+	return client
+}
+
+func TestStorage_RenameRemote(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		s := setupTestFile(t)
+		s.RemoteName = "origin"
+
+		assert.NoError(t, s.RenameRemote(testManagementRemote(t, s, http.StatusOK), "renamed"))
+		assert.Equal(t, "renamed", s.Alias)
+		assert.FileExists(t, filepath.Join(testDir, "renamed", "remotes.json"))
+	})
+
+	t.Run("rolls back when remote fails", func(t *testing.T) {
+		s := setupTestFile(t)
+
+		assert.Error(t, s.RenameRemote(testManagementRemote(t, s, http.StatusBadRequest), "renamed"))
+		assert.Equal(t, testAlias, s.Alias)
+		assert.FileExists(t, filepath.Join(testDir, testAlias+".json"))
+		assert.NoFileExists(t, filepath.Join(testDir, "renamed.json"))
+	})
+}
+
+func TestStorage_MoveRemote(t *testing.T) {
+	moved := testDir + "moved.txt"
+
+	t.Run("ok", func(t *testing.T) {
+		s := setupTestFile(t)
+		s.Host = "laptop"
+		s.FileData.SetHostPath("laptop", s.FileData.Path)
+
+		assert.NoError(t, s.MoveRemote(testManagementRemote(t, s, http.StatusOK), moved, false))
+		assert.Equal(t, "moved.txt", filepath.Base(s.FileData.Path))
+		assert.Empty(t, s.FileData.Hosts)
+	})
+
+	t.Run("rolls back when remote fails", func(t *testing.T) {
+		s := setupTestFile(t)
+		oldPath := s.FileData.Path
+
+		assert.Error(t, s.MoveRemote(testManagementRemote(t, s, http.StatusBadRequest), moved, false))
+		assert.Equal(t, oldPath, s.FileData.Path)
+		assert.FileExists(t, testTrackedFile)
+		assert.NoFileExists(t, moved)
+
+		assert.NoError(t, s.SetTrackingData())
+		assert.Equal(t, oldPath, s.FileData.Path)
+	})
+}
+
+func TestStorage_CheckoutRemote(t *testing.T) {
+	setup := func(t *testing.T) (s *Storage, initial, current string) {
+		s = setupTestFile(t)
+		initial = s.FileData.Revision
+
+		updateTestFile(t)
+		assert.NoError(t, dotfile.NewCommit(s, testMessage))
+		return s, initial, s.FileData.Revision
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		s, initial, _ := setup(t)
+
+		assert.NoError(t, s.CheckoutRemote(testManagementRemote(t, s, http.StatusOK), initial))
+		assert.Equal(t, initial, s.FileData.Revision)
+	})
+
+	t.Run("rolls back when remote fails", func(t *testing.T) {
+		s, initial, current := setup(t)
+		writeTestFile(t, []byte("uncommitted"))
+
+		assert.Error(t, s.CheckoutRemote(testManagementRemote(t, s, http.StatusNotFound), initial))
+		assert.Equal(t, current, s.FileData.Revision)
+
+		content, err := os.ReadFile(testTrackedFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "uncommitted", string(content))
+	})
+}
+
+func TestStorage_ForgetRemote(t *testing.T) {
+	t.Run("keeps local data when remote fails", func(t *testing.T) {
+		s := setupTestFile(t)
+
+		assert.Error(t, s.ForgetRemote(testManagementRemote(t, s, http.StatusBadRequest), false))
+		assert.FileExists(t, filepath.Join(testDir, testAlias+".json"))
+	})
+
+	t.Run("forget", func(t *testing.T) {
+		s := setupTestFile(t)
+
+		assert.NoError(t, s.ForgetRemote(testManagementRemote(t, s, http.StatusOK), false))
+		assert.NoFileExists(t, filepath.Join(testDir, testAlias+".json"))
+	})
+
+	t.Run("commits", func(t *testing.T) {
+		s := setupTestFile(t)
+		updateTestFile(t)
+		assert.NoError(t, dotfile.NewCommit(s, testMessage))
+
+		assert.NoError(t, s.ForgetRemote(testManagementRemote(t, s, http.StatusOK), true))
+		assert.Len(t, s.FileData.Commits, 1)
+	})
+}
@@ -0,0 +1,207 @@
+package local
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/knoebber/usererror"
+	"github.com/pkg/errors"
+)
+
+// ErrNoStash is returned when a file has nothing stashed.
+var ErrNoStash = usererror.New("No stashed changes")
+
+// StashEntry is uncommitted content that was set aside.
+// Stashed content is saved in the same format as revisions.
+//
+// Example: the most recent stash for alias "emacs" where Storage.dir is ~/.local/share/dotfile.
+//
+// ~/.local/share/dotfile/emacs/stash.json
+// ~/.local/share/dotfile/emacs/stash/ae8cb9b6d5a4ba86d55a7e0d6d5f9a6f98bb46b9
+type StashEntry struct {
+	Hash      string `json:"hash"`     // The hash of the stashed content.
+	Revision  string `json:"revision"` // The revision that the content was changed from.
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"` // Unix timestamp.
+}
+
+func (s *Storage) stashJSONPath() string {
+	return filepath.Join(s.Dir, s.Alias, "stash.json")
+}
+
+func (s *Storage) stashDir() string {
+	return filepath.Join(s.Dir, s.Alias, "stash")
+}
+
+// Stashes returns the stashed changes for the file, the most recent is last.
+func (s *Storage) Stashes() ([]StashEntry, error) {
+	var stashes []StashEntry
+
+	content, err := os.ReadFile(s.stashJSONPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "reading stashes")
+	}
+
+	if err := json.Unmarshal(content, &stashes); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling stashes")
+	}
+
+	return stashes, nil
+}
+
+func (s *Storage) saveStashes(stashes []StashEntry) error {
+	if len(stashes) == 0 {
+		if err := os.Remove(s.stashJSONPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.Wrap(err, "removing stashes")
+		}
+		return nil
+	}
+
+	content, err := json.MarshalIndent(stashes, "", jsonIndent)
+	if err != nil {
+		return errors.Wrap(err, "marshalling stashes")
+	}
+
+	if err := os.WriteFile(s.stashJSONPath(), content, 0644); err != nil {
+		return errors.Wrap(err, "saving stashes")
+	}
+
+	return nil
+}
+
+// Stash sets aside the uncommitted changes and reverts the file to its current revision.
+func (s *Storage) Stash(message string) error {
+	if s.FileData == nil {
+		return ErrNoData
+	}
+
+	return s.stash(message, s.FileData.Revision)
+}
+
+// Stashes the changes that were made from revision and checks out the file's current revision.
+func (s *Storage) stash(message, revision string) error {
+	clean, err := dotfile.IsClean(s, revision)
+	if err != nil {
+		return err
+	}
+	if clean {
+		return usererror.New("No changes to stash")
+	}
+
+	content, err := s.DirtyContent()
+	if err != nil {
+		return err
+	}
+
+	compressed, err := dotfile.Compress(content)
+	if err != nil {
+		return err
+	}
+
+	stashes, err := s.Stashes()
+	if err != nil {
+		return err
+	}
+
+	entry := StashEntry{
+		Hash:      fmt.Sprintf("%x", sha1.Sum(content)),
+		Revision:  revision,
+		Message:   message,
+		Timestamp: time.Now().Unix(),
+	}
+
+	if err := writeCommit(compressed.Bytes(), filepath.Join(s.Dir, s.Alias), "stash", entry.Hash); err != nil {
+		return err
+	}
+
+	if err := s.saveStashes(append(stashes, entry)); err != nil {
+		return err
+	}
+
+	return dotfile.Checkout(s, s.FileData.Revision)
+}
+
+// PopStash applies the most recent stash to the file and removes it.
+// The stash is merged with changes made since it was created.
+// Returns an error and keeps the stash when the merge has conflicts.
+func (s *Storage) PopStash() error {
+	if s.FileData == nil {
+		return ErrNoData
+	}
+
+	stashes, err := s.Stashes()
+	if err != nil {
+		return err
+	}
+	if len(stashes) == 0 {
+		return ErrNoStash
+	}
+
+	entry := stashes[len(stashes)-1]
+	stashPath := filepath.Join(s.stashDir(), entry.Hash)
+
+	compressed, err := os.ReadFile(stashPath)
+	if err != nil {
+		return errors.Wrapf(err, "reading stash %q", entry.Hash)
+	}
+
+	stashed, err := dotfile.Uncompress(compressed)
+	if err != nil {
+		return err
+	}
+
+	conflicts, err := s.mergeWorkingFile(entry.Revision, stashed.Bytes(), "stash")
+	if err != nil {
+		return err
+	}
+	if conflicts > 0 {
+		return conflictError(s.Alias, conflicts)
+	}
+
+	stashes = stashes[:len(stashes)-1]
+
+	// Stashes with the same content share a file.
+	for _, other := range stashes {
+		if other.Hash == entry.Hash {
+			return s.saveStashes(stashes)
+		}
+	}
+
+	if err := os.Remove(stashPath); err != nil {
+		return errors.Wrapf(err, "removing stash %q", entry.Hash)
+	}
+
+	return s.saveStashes(stashes)
+}
+
+// Merges content that was changed from the revision at base into the working file.
+// Returns the number of conflicts.
+func (s *Storage) mergeWorkingFile(base string, content []byte, label string) (int, error) {
+	baseContent, err := dotfile.UncompressRevision(s, base)
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := s.DirtyContent()
+	if err != nil {
+		return 0, err
+	}
+
+	merged, conflicts := dotfile.Merge(baseContent.Bytes(), current, content, "working file", label)
+	if err := s.writeWorkingFile(merged); err != nil {
+		return 0, err
+	}
+
+	return conflicts, nil
+}
+
+func conflictError(alias string, conflicts int) error {
+	return usererror.Format("%q has %d merge conflicts (resolve the conflict markers in the file)", alias, conflicts)
+}
@@ -0,0 +1,68 @@
+package local
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_Stash(t *testing.T) {
+	s := setupTestFile(t)
+
+	t.Run("error when there are no changes", func(t *testing.T) {
+		assert.Error(t, s.Stash(""))
+	})
+
+	t.Run("error when there is nothing to pop", func(t *testing.T) {
+		assert.ErrorIs(t, s.PopStash(), ErrNoStash)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		updateTestFile(t)
+		assert.NoError(t, s.Stash("work in progress"))
+
+		content, err := s.DirtyContent()
+		assert.NoError(t, err)
+		assert.Equal(t, testContent, string(content))
+
+		stashes, err := s.Stashes()
+		assert.NoError(t, err)
+		assert.Len(t, stashes, 1)
+		assert.Equal(t, "work in progress", stashes[0].Message)
+		assert.Equal(t, s.FileData.Revision, stashes[0].Revision)
+
+		assert.NoError(t, s.PopStash())
+
+		content, err = s.DirtyContent()
+		assert.NoError(t, err)
+		assert.Equal(t, testUpdatedContent, string(content))
+
+		stashes, err = s.Stashes()
+		assert.NoError(t, err)
+		assert.Empty(t, stashes)
+	})
+
+	t.Run("pop merges with new changes", func(t *testing.T) {
+		writeTestFile(t, []byte(testContent+"Stashed.\n"))
+		assert.NoError(t, s.Stash(""))
+
+		writeTestFile(t, []byte("First.\n"+testContent))
+		assert.NoError(t, s.PopStash())
+
+		content, err := s.DirtyContent()
+		assert.NoError(t, err)
+		assert.Equal(t, "First.\n"+testContent+"Stashed.\n", string(content))
+	})
+
+	t.Run("conflicts keep the stash", func(t *testing.T) {
+		writeTestFile(t, []byte("Stashed.\n"))
+		assert.NoError(t, s.Stash(""))
+
+		writeTestFile(t, []byte("Changed.\n"))
+		assert.Error(t, s.PopStash())
+
+		stashes, err := s.Stashes()
+		assert.NoError(t, err)
+		assert.Len(t, stashes, 1)
+	})
+}
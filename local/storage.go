@@ -23,9 +23,47 @@ var (
 
 // Storage provides methods for manipulating tracked files on the file system.
 type Storage struct {
-	Alias    string                // The name of the file that is being tracked.
-	Dir      string                // The path to the folder where data will be stored.
-	FileData *dotfile.TrackingData // The current file that storage is tracking.
+	Alias       string                // The name of the file that is being tracked.
+	Dir         string                // The path to the folder where data will be stored.
+	FileData    *dotfile.TrackingData // The current file that storage is tracking.
+	RemoteAlias string                // The name of the file on remotes. Defaults to Alias.
+	LineEndings string                // Line endings to write the file with. Commits always use "\n".
+	Passphrase  []byte                // Encrypts pushed revisions and decrypts pulled revisions.
+	Encrypt     bool                  // Whether pushed revisions must be encrypted.
+	Host        string                // The name of the machine for path overrides. Defaults to os.Hostname.
+	RemoteName  string                // The name of the remote for remote tracking state.
+}
+
+func (s *Storage) host() (string, error) {
+	if s.Host != "" {
+		return s.Host, nil
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return "", errors.Wrap(err, "getting hostname")
+	}
+
+	return host, nil
+}
+
+// Records localPath as the path on this host when it differs from the merged path.
+func (s *Storage) keepHostPath(localPath string) error {
+	host, err := s.host()
+	if err != nil {
+		return err
+	}
+
+	s.FileData.SetHostPath(host, localPath)
+	return nil
+}
+
+func (s *Storage) remoteAlias() string {
+	if s.RemoteAlias != "" {
+		return s.RemoteAlias
+	}
+
+	return s.Alias
 }
 
 func (s *Storage) jsonPath() string {
@@ -134,6 +172,10 @@ func (s *Storage) DirtyContent() ([]byte, error) {
 		return nil, errors.Wrapf(err, "reading %q", s.Alias)
 	}
 
+	if s.LineEndings != "" {
+		result = bytes.ReplaceAll(result, []byte("\r\n"), []byte("\n"))
+	}
+
 	return result, nil
 }
 
@@ -156,6 +198,16 @@ func (s *Storage) SaveCommit(buff *bytes.Buffer, c *dotfile.Commit) error {
 
 // Revert writes files with buff and sets it current revision to hash.
 func (s *Storage) Revert(buff *bytes.Buffer, hash string) error {
+	if err := s.writeWorkingFile(buff.Bytes()); err != nil {
+		return errors.Wrapf(err, "reverting file %q", s.Alias)
+	}
+
+	s.FileData.Revision = hash
+	return s.save()
+}
+
+// Writes content to the tracked file with the configured line endings.
+func (s *Storage) writeWorkingFile(content []byte) error {
 	path, err := s.Path()
 	if err != nil {
 		return err
@@ -165,28 +217,34 @@ func (s *Storage) Revert(buff *bytes.Buffer, hash string) error {
 		return err
 	}
 
-	err = os.WriteFile(path, buff.Bytes(), 0644)
-	if err != nil {
-		return errors.Wrapf(err, "reverting file %q", s.Alias)
+	if s.LineEndings == LineEndingsCRLF {
+		content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+		content = bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
 	}
 
-	s.FileData.Revision = hash
-	return s.save()
+	return os.WriteFile(path, content, 0644)
 }
 
-// Path gets the full path to the file.
+// Path gets the full path to the file on this host.
 // Utilizes $HOME to convert paths with ~ to absolute.
 func (s *Storage) Path() (string, error) {
 	if s.FileData == nil {
 		return "", ErrNoData
 	}
-	if s.FileData.Path == "" {
+
+	host, err := s.host()
+	if err != nil {
+		return "", err
+	}
+
+	path := s.FileData.HostPath(host)
+	if path == "" {
 		return "", errors.New("file data is missing path")
 	}
 
 	// If the saved path is absolute return it.
-	if filepath.IsAbs(s.FileData.Path) {
-		return s.FileData.Path, nil
+	if filepath.IsAbs(path) {
+		return path, nil
 	}
 
 	home, err := os.UserHomeDir()
@@ -194,7 +252,7 @@ func (s *Storage) Path() (string, error) {
 		return "", err
 	}
 
-	return strings.Replace(s.FileData.Path, "~", home, 1), nil
+	return strings.Replace(path, "~", home, 1), nil
 }
 
 // Push pushes a file's commits to a remote dotfile server.
@@ -206,7 +264,11 @@ func (s *Storage) Push(client *dotfileclient.Client) error {
 		return ErrNoData
 	}
 
-	remoteData, err := client.TrackingData(s.Alias)
+	if s.Encrypt && len(s.Passphrase) == 0 {
+		return usererror.Format("Encryption is enabled for %q but the encryption key is empty", s.Alias)
+	}
+
+	remoteData, err := client.TrackingData(s.remoteAlias())
 	if err != nil {
 		return err
 	}
@@ -217,15 +279,27 @@ func (s *Storage) Push(client *dotfileclient.Client) error {
 			newHashes = append(newHashes, c.Hash)
 		}
 	} else {
+		host, err := s.host()
+		if err != nil {
+			return err
+		}
+		localPath := s.FileData.HostPath(host)
+
 		s.FileData, newHashes, err = dotfile.MergeTrackingData(remoteData, s.FileData)
 		if err != nil {
 			return err
 		}
+
+		// The remote path stays the same, this host keeps its own path.
+		s.FileData.Path = remoteData.Path
+		if err := s.keepHostPath(localPath); err != nil {
+			return err
+		}
 	}
 	revisions := make([]*dotfileclient.Revision, len(newHashes))
 
 	for i, hash := range newHashes {
-		revision, err := s.Revision(hash)
+		revision, err := s.pushRevision(hash)
 		if err != nil {
 			return err
 		}
@@ -236,18 +310,102 @@ func (s *Storage) Push(client *dotfileclient.Client) error {
 		}
 	}
 
-	if err := client.UploadRevisions(s.Alias, s.FileData, revisions); err != nil {
+	// Host paths only matter locally.
+	pushed := *s.FileData
+	pushed.Hosts = nil
+
+	if len(revisions) == 0 {
+		fmt.Println("Up to date.")
+	}
+
+	if err := client.UploadRevisions(s.remoteAlias(), &pushed, revisions); err != nil {
 		return err
 	}
 
-	return nil
+	return s.saveRemoteState(&pushed)
+}
+
+// Returns the compressed revision at hash, encrypting its content when Encrypt is set.
+func (s *Storage) pushRevision(hash string) ([]byte, error) {
+	revision, err := s.Revision(hash)
+	if err != nil || !s.Encrypt {
+		return revision, err
+	}
+
+	content, err := dotfile.Uncompress(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := dotfile.Encrypt(s.Passphrase, content.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	compressed, err := dotfile.Compress(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	return compressed.Bytes(), nil
+}
+
+// Decrypts a pulled revision when its content is encrypted.
+func (s *Storage) pulledRevision(revision *dotfileclient.Revision) ([]byte, error) {
+	content, err := dotfile.Uncompress(revision.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if !dotfile.IsEncrypted(content.Bytes()) {
+		return revision.Bytes, nil
+	}
+	if len(s.Passphrase) == 0 {
+		return nil, usererror.Format("Revision %q is encrypted and the encryption key is empty", revision.Hash)
+	}
+
+	decrypted, err := dotfile.Decrypt(s.Passphrase, content.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	compressed, err := dotfile.Compress(decrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	return compressed.Bytes(), nil
+}
+
+// PullStrategy controls what pull does when a file has uncommitted changes.
+type PullStrategy string
+
+// Pull strategies.
+const (
+	PullAbort  PullStrategy = ""       // Returns an error.
+	PullOurs   PullStrategy = "ours"   // Keeps the file as is.
+	PullTheirs PullStrategy = "theirs" // Discards uncommitted changes.
+	PullMerge  PullStrategy = "merge"  // Merges the pulled revision into the uncommitted changes.
+	PullStash  PullStrategy = "stash"  // Stashes uncommitted changes and pops them after the pulled revision is checked out.
+)
+
+// PullStrategies are the valid values for PullStrategy.
+var PullStrategies = []string{
+	string(PullOurs),
+	string(PullTheirs),
+	string(PullMerge),
+	string(PullStash),
 }
 
 // Pull retrieves a file's commits from a dotfile server.
 // Updates the local file with the new content from remote.
 // FileData does not need to be set; its possible to pull a file that does not yet exist.
-func (s *Storage) Pull(client *dotfileclient.Client) error {
-	var newHashes []string
+// Strategy is used when the file has uncommitted changes.
+func (s *Storage) Pull(client *dotfileclient.Client, strategy PullStrategy) error {
+	var (
+		newHashes    []string
+		dirty        bool
+		baseRevision string
+	)
 
 	hasSavedData := s.hasSavedData()
 
@@ -261,17 +419,35 @@ func (s *Storage) Pull(client *dotfileclient.Client) error {
 			return err
 		}
 
-		if !clean {
-			return usererror.New("file has uncommitted changes")
+		dirty = !clean
+		baseRevision = s.FileData.Revision
+	}
+
+	if dirty {
+		switch strategy {
+		case PullAbort:
+			return usererror.New("file has uncommitted changes (commit them or use --strategy)")
+		case PullOurs, PullTheirs, PullMerge, PullStash:
+		default:
+			return usererror.Format("%q is not a valid pull strategy", strategy)
 		}
 	}
 
-	remoteData, err := client.TrackingData(s.Alias)
+	remoteData, err := client.TrackingData(s.remoteAlias())
 	if err != nil {
 		return err
 	}
 	if remoteData == nil {
-		return fmt.Errorf("%q not found on remote %q", s.Alias, client.Remote)
+		return fmt.Errorf("%q not found on remote %q", s.remoteAlias(), client.Remote)
+	}
+
+	var localPath string
+	if hasSavedData {
+		host, err := s.host()
+		if err != nil {
+			return err
+		}
+		localPath = s.FileData.HostPath(host)
 	}
 
 	s.FileData, newHashes, err = dotfile.MergeTrackingData(s.FileData, remoteData)
@@ -279,6 +455,12 @@ func (s *Storage) Pull(client *dotfileclient.Client) error {
 		return err
 	}
 
+	if hasSavedData {
+		if err := s.keepHostPath(localPath); err != nil {
+			return err
+		}
+	}
+
 	path, err := s.Path()
 	if err != nil {
 		return err
@@ -292,22 +474,51 @@ func (s *Storage) Pull(client *dotfileclient.Client) error {
 
 	fmt.Printf("pulling %d new revisions for %s\n", len(newHashes), s.FileData.Path)
 
-	revisions, err := client.Revisions(s.Alias, newHashes)
-	if err != nil {
+	// Revisions that were fetched are already saved.
+	if err := s.downloadRevisions(client, newHashes); err != nil {
+		return err
+	}
+	if err := s.saveRemoteState(remoteData); err != nil {
 		return err
 	}
 
-	for _, revision := range revisions {
-		if err = writeCommit(revision.Bytes, s.Dir, s.Alias, revision.Hash); err != nil {
+	if !dirty || strategy == PullTheirs {
+		return dotfile.Checkout(s, s.FileData.Revision)
+	}
+
+	switch strategy {
+	case PullOurs:
+		return s.save()
+	case PullStash:
+		// Stash after the pulled revisions are saved so that the changes stay in the file when pulling fails.
+		if err := s.stash("Stashed by pull", baseRevision); err != nil {
 			return err
 		}
+		return s.PopStash()
+	}
+
+	pulled, err := dotfile.UncompressRevision(s, s.FileData.Revision)
+	if err != nil {
+		return err
 	}
 
-	return dotfile.Checkout(s, s.FileData.Revision)
+	conflicts, err := s.mergeWorkingFile(baseRevision, pulled.Bytes(), "pulled")
+	if err != nil {
+		return err
+	}
+	if err := s.save(); err != nil {
+		return err
+	}
+	if conflicts > 0 {
+		return conflictError(s.Alias, conflicts)
+	}
+
+	return nil
 }
 
 // Move moves the file currently tracked by storage.
-func (s *Storage) Move(newPath string, parentDirs bool) error {
+// The new path only applies to this host when hostOnly is true or this host already has its own path.
+func (s *Storage) Move(newPath string, parentDirs, hostOnly bool) error {
 	currentPath, err := s.Path()
 	if err != nil {
 		return err
@@ -323,11 +534,22 @@ func (s *Storage) Move(newPath string, parentDirs bool) error {
 		return err
 	}
 
-	s.FileData.Path, err = convertPath(newPath)
+	converted, err := convertPath(newPath)
 	if err != nil {
 		return err
 	}
 
+	host, err := s.host()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := s.FileData.Hosts[host]; ok || hostOnly {
+		s.FileData.SetHostPath(host, converted)
+	} else {
+		s.FileData.Path = converted
+	}
+
 	return s.save()
 }
 
@@ -2,9 +2,14 @@ package local
 
 import (
 	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -117,6 +122,50 @@ func TestStorage_Revert(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, testUpdatedHash, s.FileData.Revision)
 	})
+
+	t.Run("writes crlf line endings", func(t *testing.T) {
+		s := setupTestFile(t)
+		s.LineEndings = LineEndingsCRLF
+
+		assert.NoError(t, s.Revert(bytes.NewBufferString("a\nb\r\n"), testUpdatedHash))
+
+		written, err := os.ReadFile(testTrackedFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "a\r\nb\r\n", string(written))
+
+		content, err := s.DirtyContent()
+		assert.NoError(t, err)
+		assert.Equal(t, "a\nb\n", string(content))
+	})
+}
+
+func TestStorage_encryptedRevisions(t *testing.T) {
+	s := setupTestFile(t)
+	s.Encrypt = true
+	s.Passphrase = []byte("secret")
+
+	pushed, err := s.pushRevision(s.FileData.Revision)
+	assert.NoError(t, err)
+
+	content, err := dotfile.Uncompress(pushed)
+	assert.NoError(t, err)
+	assert.True(t, dotfile.IsEncrypted(content.Bytes()))
+
+	revision := &dotfileclient.Revision{Hash: s.FileData.Revision, Bytes: pushed}
+
+	t.Run("error when passphrase is empty", func(t *testing.T) {
+		_, err := (&Storage{}).pulledRevision(revision)
+		assert.Error(t, err)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		pulled, err := s.pulledRevision(revision)
+		assert.NoError(t, err)
+
+		content, err := dotfile.Uncompress(pulled)
+		assert.NoError(t, err)
+		assert.Equal(t, testContent, content.String())
+	})
 }
 
 func TestStorage_SaveCommit(t *testing.T) {
@@ -159,6 +208,22 @@ func TestStorage_SaveCommit(t *testing.T) {
 }
 
 func TestStorage_Path(t *testing.T) {
+	t.Run("host path", func(t *testing.T) {
+		s := &Storage{Host: "laptop", FileData: &dotfile.TrackingData{
+			Path:  "/home/user/.config/Code/User/settings.json",
+			Hosts: map[string]string{"laptop": "/Users/user/Library/Application Support/Code/User/settings.json"},
+		}}
+
+		path, err := s.Path()
+		assert.NoError(t, err)
+		assert.Equal(t, s.FileData.Hosts["laptop"], path)
+
+		s.Host = "desktop"
+		path, err = s.Path()
+		assert.NoError(t, err)
+		assert.Equal(t, s.FileData.Path, path)
+	})
+
 	t.Run("error when filedata is nil", func(t *testing.T) {
 		s := testStorage()
 		_, err := s.Path()
@@ -193,6 +258,12 @@ func TestStorage_Path(t *testing.T) {
 }
 
 func TestStorage_Push(t *testing.T) {
+	t.Run("error when encryption key is empty", func(t *testing.T) {
+		s := setupTestFile(t)
+		s.Encrypt = true
+		assert.Error(t, s.Push(dotfileclient.New("", "", "")))
+	})
+
 	t.Run("error when data not loaded", func(t *testing.T) {
 		s := testStorage()
 		assert.Error(t, s.Push(nil))
@@ -214,42 +285,163 @@ func TestStorage_Pull(t *testing.T) {
 		if err := os.WriteFile(testDir+testAlias+".json", []byte("invalid json"), 0644); err != nil {
 			t.Fatalf("writing test json")
 		}
-		assert.Error(t, s.Pull(client))
+		assert.Error(t, s.Pull(client, PullAbort))
 	})
 
 	t.Run("uncommitted changes", func(t *testing.T) {
 		setupTestFile(t)
 		updateTestFile(t)
-		err := s.Pull(client)
+		err := s.Pull(client, PullAbort)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "uncommitted")
 	})
 
 	t.Run("client error", func(t *testing.T) {
 		setupTestFile(t)
-		err := s.Pull(client)
+		err := s.Pull(client, PullAbort)
 		assert.Error(t, err)
 	})
 }
 
+// Creates a client for a server that has one more commit than the test file.
+func testRemote(t *testing.T, s *Storage, content string) *dotfileclient.Client {
+	hash := fmt.Sprintf("%x", sha1.Sum([]byte(content)))
+	compressed, err := dotfile.Compress([]byte(content))
+	if err != nil {
+		t.Fatalf("compressing remote content: %v", err)
+	}
+
+	remoteData := &dotfile.TrackingData{
+		Path:     s.FileData.Path,
+		Revision: hash,
+		Commits:  append(s.FileData.Commits, dotfile.Commit{Hash: hash, Timestamp: time.Now().Unix()}),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/"+hash) {
+			_, _ = w.Write(compressed.Bytes())
+			return
+		}
+		_ = json.NewEncoder(w).Encode(remoteData)
+	}))
+	t.Cleanup(server.Close)
+
+	return dotfileclient.New(server.URL, "user", "")
+}
+
+func TestStorage_Pull_strategies(t *testing.T) {
+	const (
+		localContent  = "Local line.\n" + testContent
+		remoteContent = testContent + "Remote line.\n"
+	)
+
+	for strategy, expected := range map[PullStrategy]string{
+		PullOurs:   localContent,
+		PullTheirs: remoteContent,
+		PullMerge:  "Local line.\n" + testContent + "Remote line.\n",
+		PullStash:  "Local line.\n" + testContent + "Remote line.\n",
+	} {
+		t.Run(string(strategy), func(t *testing.T) {
+			s := setupTestFile(t)
+			client := testRemote(t, s, remoteContent)
+			writeTestFile(t, []byte(localContent))
+
+			assert.NoError(t, s.Pull(client, strategy))
+			assert.Len(t, s.FileData.Commits, 2)
+
+			content, err := s.DirtyContent()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, string(content))
+		})
+	}
+
+	t.Run("error when strategy is invalid", func(t *testing.T) {
+		s := setupTestFile(t)
+		client := testRemote(t, s, remoteContent)
+		writeTestFile(t, []byte(localContent))
+
+		assert.Error(t, s.Pull(client, "bad"))
+	})
+
+	t.Run("stash keeps changes when pulling fails", func(t *testing.T) {
+		s := setupTestFile(t)
+		remoteData := &dotfile.TrackingData{
+			Path:     s.FileData.Path,
+			Revision: "missing",
+			Commits:  append(s.FileData.Commits, dotfile.Commit{Hash: "missing"}),
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/missing") {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(remoteData)
+		}))
+		defer server.Close()
+		writeTestFile(t, []byte(localContent))
+
+		assert.Error(t, s.Pull(dotfileclient.New(server.URL, "user", ""), PullStash))
+
+		content, err := s.DirtyContent()
+		assert.NoError(t, err)
+		assert.Equal(t, localContent, string(content))
+
+		stashes, err := s.Stashes()
+		assert.NoError(t, err)
+		assert.Empty(t, stashes)
+	})
+
+	t.Run("merge conflict", func(t *testing.T) {
+		s := setupTestFile(t)
+		client := testRemote(t, s, "Remote stuff.\n")
+		writeTestFile(t, []byte("Local stuff.\n"))
+
+		assert.Error(t, s.Pull(client, PullMerge))
+
+		content, err := s.DirtyContent()
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "<<<<<<< working file\nLocal stuff.\n")
+		assert.Contains(t, string(content), "Remote stuff.\n>>>>>>> pulled\n")
+	})
+}
+
 func TestStorage_Move(t *testing.T) {
 	s := testStorage()
 	setupTestFile(t)
 
 	t.Run("error when no data", func(t *testing.T) {
-		assert.Error(t, s.Move(testTrackedFile, false))
+		assert.Error(t, s.Move(testTrackedFile, false, false))
 	})
 
 	assert.NoError(t, s.SetTrackingData())
 
 	t.Run("parent dirs works", func(t *testing.T) {
 		nested := testDir + "new/file.txt"
-		assert.Error(t, s.Move(nested, false))
-		assert.NoError(t, s.Move(nested, true))
+		assert.Error(t, s.Move(nested, false, false))
+		assert.NoError(t, s.Move(nested, true, false))
+	})
+
+	t.Run("host only", func(t *testing.T) {
+		s.Host = "laptop"
+		defer func() { s.Host = "" }()
+
+		canonical := s.FileData.Path
+		moved := testDir + "laptop.txt"
+		assert.NoError(t, s.Move(moved, false, true))
+		assert.Equal(t, canonical, s.FileData.Path)
+
+		path, err := s.Path()
+		assert.NoError(t, err)
+		assert.Equal(t, "laptop.txt", filepath.Base(path))
+
+		// Moving again updates the host path.
+		assert.NoError(t, s.Move(testDir+"laptop2.txt", false, false))
+		assert.Equal(t, canonical, s.FileData.Path)
+		assert.Equal(t, "laptop2.txt", filepath.Base(s.FileData.Hosts["laptop"]))
 	})
 
 	t.Run("error when path can't be made", func(t *testing.T) {
-		assert.Error(t, s.Move("/not/real.txt", true))
+		assert.Error(t, s.Move("/not/real.txt", true, false))
 	})
 }
 
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/knoebber/dotfile/db"
+	"github.com/pkg/errors"
+)
+
+// Returns the ID of the user that sent the request or 0 when it is anonymous.
+// API clients identify with basic auth and a token that has the read scope.
+// Browsers identify with their session cookie.
+func requestUserID(r *http.Request) int64 {
+	if username, token, ok := r.BasicAuth(); ok {
+		userID, err := db.UserLoginAPI(db.Connection, username, token, db.TokenScopeRead, r.RemoteAddr)
+		if err != nil {
+			log.Printf("treating request as anonymous: %s", err)
+			return 0
+		}
+
+		return userID
+	}
+
+	cookie, err := r.Cookie(sessionCookie)
+	if errors.Is(err, http.ErrNoCookie) {
+		return 0
+	}
+
+	s, err := db.Session(db.Connection, cookie.Value)
+	if err != nil {
+		log.Printf("treating request as anonymous: %s", err)
+		return 0
+	}
+
+	return s.UserID
+}
+
+// Checks that the user that sent the request can see the file in the route.
+func fileAccess(r *http.Request) (db.FileVisibility, error) {
+	vars := mux.Vars(r)
+	return db.FileAccess(db.Connection, vars["username"], vars["alias"], requestUserID(r))
+}
+
+// Checks that the logged in user can see the page's file.
+// Loads the file's stars for the file header.
+func pageFileAccess(p *Page) error {
+	username, alias := p.Vars["username"], p.Vars["alias"]
+
+	visibility, err := db.FileAccess(db.Connection, username, alias, p.userID())
+	if err != nil {
+		return err
+	}
+
+	stars, starred, err := db.FileStars(db.Connection, username, alias, p.userID())
+	if err != nil {
+		return err
+	}
+
+	p.Data["visibility"] = visibility
+	p.Data["stars"] = stars
+	p.Data["starred"] = starred
+	return nil
+}
+
+// Private responses must not be saved by shared caches.
+func fileCacheControl(visibility db.FileVisibility, cacheControl string) string {
+	if visibility != db.FileVisibilityPrivate {
+		return cacheControl
+	}
+
+	return "private, " + strings.TrimPrefix(cacheControl, "public, ")
+}
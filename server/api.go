@@ -10,6 +10,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/knoebber/dotfile/db"
@@ -22,23 +23,39 @@ import (
 func handleFileJSON(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
+	visibility, err := fileAccess(r)
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+
 	fileData, err := db.FileData(db.Connection, vars["username"], vars["alias"])
 	if err != nil {
 		apiError(w, err)
 		return
 	}
 
+	if notModified(w, r, fileDataETag(fileData), fileCacheControl(visibility, revalidateCacheControl)) {
+		return
+	}
+
 	setJSON(w, fileData)
 }
 
 // Sets a list of aliases that username owns to the response body.
+// Files that aren't public are only listed for their owner.
 func handleFileListJSON(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
 	username := vars["username"]
 	addPath := r.URL.Query().Get("path") == "true"
 
-	files, err := db.FilesByUsername(db.Connection, username, nil)
+	if r.URL.Query().Get("starred") == "true" {
+		handleStarredListJSON(w, r, username, addPath)
+		return
+	}
+
+	files, err := db.FilesByUsername(db.Connection, username, requestUserID(r), nil)
 	if err != nil {
 		apiError(w, err)
 		return
@@ -55,14 +72,95 @@ func handleFileListJSON(w http.ResponseWriter, r *http.Request) {
 	setJSON(w, result)
 }
 
+// Lists the files that username starred as "owner/alias".
+func handleStarredListJSON(w http.ResponseWriter, r *http.Request, username string, addPath bool) {
+	if err := db.ValidateUserExists(db.Connection, username); err != nil {
+		apiError(w, err)
+		return
+	}
+
+	files, err := db.StarredFiles(db.Connection, username, requestUserID(r), nil)
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+
+	result := make([]string, len(files))
+	for i, f := range files {
+		result[i] = f.Username + "/" + f.Alias
+		if addPath {
+			result[i] += " " + f.Path
+		}
+	}
+
+	setJSON(w, result)
+}
+
+// Removes the highlight markers from search snippets.
+var snippetMarkers = strings.NewReplacer(db.SnippetMatchStart, "", db.SnippetMatchEnd, "")
+
+type searchResultJSON struct {
+	Username  string    `json:"username"`
+	Alias     string    `json:"alias"`
+	Path      string    `json:"path"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Stars     int       `json:"stars"`
+	Forks     int       `json:"forks"`
+	Snippet   string    `json:"snippet,omitempty"`
+}
+
+// Searches public files with the same query and pagination as the index page.
+func handleSearchJSON(w http.ResponseWriter, r *http.Request) {
+	controls := &db.PageControls{Values: r.URL.Query()}
+	if err := controls.Set(); err != nil {
+		apiError(w, err)
+		return
+	}
+
+	files, err := db.SearchFileResults(db.Connection, controls, nil)
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+
+	results := make([]searchResultJSON, len(files))
+	for i, f := range files {
+		results[i] = searchResultJSON{
+			Username:  f.Username,
+			Alias:     f.Alias,
+			Path:      f.Path,
+			UpdatedAt: f.UpdatedAt,
+			Stars:     f.Stars,
+			Forks:     f.Forks,
+			Snippet:   snippetMarkers.Replace(f.Snippet),
+		}
+	}
+
+	setJSON(w, map[string]interface{}{
+		"page":    controls.Page(),
+		"pages":   controls.TotalPages(),
+		"total":   controls.TotalRows(),
+		"results": results,
+	})
+}
+
 func handleRawCompressedCommit(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
+	visibility, err := fileAccess(r)
+	if err != nil {
+		rawContentError(w, err)
+		return
+	}
+
 	commit, err := db.Commit(db.Connection, vars["username"], vars["alias"], vars["hash"])
 	if err != nil {
 		rawContentError(w, err)
 		return
 	}
+	if notModified(w, r, commit.Hash, fileCacheControl(visibility, immutableCacheControl)) {
+		return
+	}
 
 	_, err = w.Write(commit.Revision)
 	if err != nil {
@@ -73,14 +171,288 @@ func handleRawCompressedCommit(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-func validateAPIUser(w http.ResponseWriter, r *http.Request) int64 {
+// The most revisions that can be requested at once.
+const maxBatchRevisions = 100
+
+// RevisionsRequest is the body for a batch revision request.
+type RevisionsRequest struct {
+	Hashes []string `json:"hashes"`
+}
+
+// Responds with multiple compressed revisions in a multipart body.
+// Each part is named as the revision's hash.
+// Hashes that the file doesn't have are left out of the response.
+func handleRevisions(w http.ResponseWriter, r *http.Request) {
+	var body RevisionsRequest
+
+	vars := mux.Vars(r)
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apiError(w, usererror.New("Expected a JSON body with a list of hashes"))
+		return
+	}
+	if len(body.Hashes) > maxBatchRevisions {
+		apiError(w, usererror.Format("Requests are limited to %d revisions", maxBatchRevisions))
+		return
+	}
+
+	if _, err := fileAccess(r); err != nil {
+		apiError(w, err)
+		return
+	}
+
+	revisions, err := db.Revisions(db.Connection, vars["username"], vars["alias"], body.Hashes)
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+
+	writer := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+
+	for _, hash := range body.Hashes {
+		revision, ok := revisions[hash]
+		if !ok {
+			continue
+		}
+
+		part, err := writer.CreateFormFile("revision", hash)
+		if err != nil {
+			log.Printf("creating revision part %q: %s", hash, err)
+			return
+		}
+		if _, err := part.Write(revision); err != nil {
+			log.Printf("writing revision part %q: %s", hash, err)
+			return
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		log.Printf("closing revisions response: %s", err)
+	}
+}
+
+// FileUpdate is the body for updating a file's alias or path.
+// Empty fields are not changed.
+type FileUpdate struct {
+	Alias string `json:"alias"`
+	Path  string `json:"path"`
+}
+
+// RevisionUpdate is the body for setting a file to one of its revisions.
+type RevisionUpdate struct {
+	Hash string `json:"hash"`
+}
+
+// ShareRequest is the body for creating a share link.
+// Links without an expiration are valid until they are revoked.
+type ShareRequest struct {
+	ExpiresIn int64 `json:"expires_in"` // Seconds until the link expires.
+	History   bool  `json:"history"`
+}
+
+// ShareResponse describes a new share link.
+type ShareResponse struct {
+	Path      string     `json:"path"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	History   bool       `json:"history"`
+}
+
+// Renames a file or changes its path.
+// Responds with the updated file data.
+func handleUpdateFile(w http.ResponseWriter, r *http.Request) {
+	var update FileUpdate
+
+	username, ok := validateAPIOwner(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		apiError(w, usererror.New("Expected a JSON body with alias or path"))
+		return
+	}
+
+	record, err := db.File(db.Connection, username, mux.Vars(r)["alias"])
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+
+	if update.Alias == "" {
+		update.Alias = record.Alias
+	}
+	if update.Path == "" {
+		update.Path = record.Path
+	}
+
+	if err := record.Update(db.Connection, update.Alias, update.Path); err != nil {
+		apiError(w, err)
+		return
+	}
+
+	fileData, err := db.FileData(db.Connection, username, strings.ToLower(update.Alias))
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+
+	setJSON(w, fileData)
+}
+
+// Deletes a file and all of its commits.
+func handleDeleteFile(w http.ResponseWriter, r *http.Request) {
+	username, ok := validateAPIOwner(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := db.Connection.Begin()
+	if err != nil {
+		apiError(w, errors.Wrap(err, "starting transaction for api delete file"))
+		return
+	}
+
+	if err := db.DeleteFile(tx, username, mux.Vars(r)["alias"]); err != nil {
+		apiError(w, db.Rollback(tx, err))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		apiError(w, errors.Wrap(err, "committing transaction for api delete file"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deletes all of a file's commits except the current.
+// Responds with the updated file data.
+func handleClearCommits(w http.ResponseWriter, r *http.Request) {
+	username, ok := validateAPIOwner(w, r)
+	if !ok {
+		return
+	}
+	alias := mux.Vars(r)["alias"]
+
+	tx, err := db.Connection.Begin()
+	if err != nil {
+		apiError(w, errors.Wrap(err, "starting transaction for api clear commits"))
+		return
+	}
+
+	if err := db.ClearCommits(tx, username, alias); err != nil {
+		apiError(w, db.Rollback(tx, err))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		apiError(w, errors.Wrap(err, "committing transaction for api clear commits"))
+		return
+	}
+
+	fileData, err := db.FileData(db.Connection, username, alias)
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+
+	setJSON(w, fileData)
+}
+
+// Sets a file's current revision to one of its commits.
+// Responds with the updated file data.
+func handleSetRevision(w http.ResponseWriter, r *http.Request) {
+	var update RevisionUpdate
+
+	username, ok := validateAPIOwner(w, r)
+	if !ok {
+		return
+	}
+	alias := mux.Vars(r)["alias"]
+
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		apiError(w, usererror.New("Expected a JSON body with a hash"))
+		return
+	}
+
+	// Checks that the commit exists so that clients get a 404 when it doesn't.
+	if _, err := db.Commit(db.Connection, username, alias, update.Hash); err != nil {
+		apiError(w, err)
+		return
+	}
+
+	if err := db.SetFileToHash(db.Connection, username, alias, update.Hash); err != nil {
+		apiError(w, err)
+		return
+	}
+
+	fileData, err := db.FileData(db.Connection, username, alias)
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+
+	setJSON(w, fileData)
+}
+
+// Creates a share link for a file.
+// Responds with the path of the link; the token is not saved so it can't be retrieved later.
+func handleCreateShare(w http.ResponseWriter, r *http.Request) {
+	var (
+		req       ShareRequest
+		expiresAt *time.Time
+	)
+
+	username, ok := validateAPIOwner(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiError(w, usererror.New("Expected a JSON body with expires_in and history"))
+		return
+	}
+	if req.ExpiresIn < 0 {
+		apiError(w, usererror.New("Expiration must be a positive number of seconds"))
+		return
+	}
+	if req.ExpiresIn > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		expiresAt = &t
+	}
+
+	record, err := db.File(db.Connection, username, mux.Vars(r)["alias"])
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+
+	token, err := db.CreateShare(db.Connection, record.ID, req.History, expiresAt)
+	if err != nil {
+		apiError(w, err)
+		return
+	}
+
+	setJSON(w, ShareResponse{
+		Path:      "/share/" + token,
+		ExpiresAt: expiresAt,
+		History:   req.History,
+	})
+}
+
+// Authenticates the API user and checks that their token grants scope.
+// Responds with 401 on bad credentials and 403 on a missing scope.
+func validateAPIUser(w http.ResponseWriter, r *http.Request, scope db.TokenScope) int64 {
 	username, token, ok := r.BasicAuth()
 	if !ok {
 		authError(w, errors.New("basic auth not provided"))
 		return 0
 	}
 
-	userID, err := db.UserLoginAPI(db.Connection, username, token)
+	userID, err := db.UserLoginAPI(db.Connection, username, token, scope, r.RemoteAddr)
+	if errors.Is(err, db.ErrTokenScope) {
+		setError(w, err, fmt.Sprintf("token does not have the %q scope", scope), http.StatusForbidden)
+		return 0
+	}
 	if err != nil {
 		authError(w, err)
 		return 0
@@ -89,6 +461,41 @@ func validateAPIUser(w http.ResponseWriter, r *http.Request) int64 {
 	return userID
 }
 
+// Validates that the API user owns the resources under the username route variable.
+// Requires the admin-api scope. Returns the owner's username.
+func validateAPIOwner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	_, owner, ok := validateAPIFileOwner(w, r, db.TokenScopeAdmin)
+	return owner, ok
+}
+
+// Validates that the API user can manage the files under the username route variable.
+// Owners and maintainers of an organization manage its files.
+// Returns the ID and username of the files' owner.
+func validateAPIFileOwner(w http.ResponseWriter, r *http.Request, scope db.TokenScope) (int64, string, bool) {
+	userID := validateAPIUser(w, r, scope)
+	if userID < 1 {
+		return 0, "", false
+	}
+
+	username, _, _ := r.BasicAuth()
+	owner := mux.Vars(r)["username"]
+	if strings.EqualFold(username, owner) {
+		return userID, owner, true
+	}
+
+	orgID, err := db.OrgFileManager(db.Connection, owner, userID)
+	if errors.Is(err, db.ErrOrgRole) {
+		permissionDenied(w, username, owner)
+		return 0, "", false
+	}
+	if err != nil {
+		apiError(w, err)
+		return 0, "", false
+	}
+
+	return orgID, owner, true
+}
+
 func multipartReader(w http.ResponseWriter, r *http.Request) *multipart.Reader {
 	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
 	if err != nil {
@@ -121,8 +528,25 @@ func readPushedFileData(p *multipart.Part) (*dotfile.TrackingData, error) {
 	return result, nil
 }
 
+// Saves a revision part from a push.
+// Revisions that were saved by an earlier push are skipped.
 func savePushedRevision(ft *db.FileTransaction, p *multipart.Part, commitMap map[string]*dotfile.Commit) error {
 	hash := p.FileName()
+
+	c, ok := commitMap[hash]
+	if !ok {
+		return fmt.Errorf("pushed revision %q doesn't exist in file data json", hash)
+	}
+
+	exists, err := ft.HasCommit(hash)
+	if err != nil {
+		return err
+	}
+	if exists {
+		log.Printf("skipping %s (already saved)", hash)
+		return p.Close()
+	}
+
 	buff := new(bytes.Buffer)
 
 	n, err := buff.ReadFrom(p)
@@ -134,13 +558,13 @@ func savePushedRevision(ft *db.FileTransaction, p *multipart.Part, commitMap map
 		return errors.Wrap(err, "closing revision part")
 	}
 
-	c, ok := commitMap[hash]
-	if !ok {
-		return fmt.Errorf("pushed revision %q doesn't exist in file data json", hash)
-
+	// The first commit of a new file becomes its current revision.
+	if ft.CurrentCommitID == 0 {
+		err = ft.SaveCommit(buff, c)
+	} else {
+		_, err = ft.InsertCommit(buff, c)
 	}
-
-	if err := ft.SaveCommit(buff, c); err != nil {
+	if err != nil {
 		return err
 	}
 
@@ -148,35 +572,43 @@ func savePushedRevision(ft *db.FileTransaction, p *multipart.Part, commitMap map
 	return nil
 }
 
-func push(mr *multipart.Reader, userID int64, alias string) error {
+// Saves a batch of pushed revisions.
+// Sets the file's visibility when it is not empty.
+// Returns the hashes of the revisions that are stored.
+func push(mr *multipart.Reader, userID int64, alias string, visibility db.FileVisibility) ([]string, error) {
+	stored := []string{}
+
 	jsonPart, err := mr.NextPart()
 	if err != nil {
-		return errors.Wrap(err, "reading json part")
+		return nil, errors.Wrap(err, "reading json part")
 	}
 
 	fileData, err := readPushedFileData(jsonPart)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	tx, err := db.Connection.Begin()
 	if err != nil {
-		return errors.Wrap(err, "starting transaction for handle push")
+		return nil, errors.Wrap(err, "starting transaction for handle push")
 	}
 
 	ft, err := db.NewFileTransaction(tx, userID, alias)
 	if err != nil {
-		return db.Rollback(tx, err)
+		return nil, db.Rollback(tx, err)
 	}
 
+	// Clients can use a different path for the file on their machine.
+	// The remote path is only set when the file is created.
 	if !ft.FileExists {
 		if err := ft.SaveFile(userID, alias, fileData.Path); err != nil {
-			return db.Rollback(tx, err)
+			return nil, db.Rollback(tx, err)
 		}
-	} else {
-		if ft.Path != fileData.Path {
-			uErr := usererror.Format("local path %q does not match remote path %q", ft.Path, fileData.Path)
-			return db.Rollback(tx, uErr)
+	}
+
+	if visibility != "" {
+		if err := ft.SetVisibility(visibility); err != nil {
+			return nil, db.Rollback(tx, err)
 		}
 	}
 
@@ -189,46 +621,68 @@ func push(mr *multipart.Reader, userID int64, alias string) error {
 		}
 
 		if err != nil {
-			return db.Rollback(tx, errors.Wrap(err, "reading revision part"))
+			return nil, db.Rollback(tx, errors.Wrap(err, "reading revision part"))
 		}
 
 		if err = savePushedRevision(ft, revisionPart, commitMap); err != nil {
-			return db.Rollback(tx, err)
+			return nil, db.Rollback(tx, err)
 		}
+
+		stored = append(stored, revisionPart.FileName())
 	}
 
-	if err = ft.SetRevision(fileData.Revision); err != nil {
-		return db.Rollback(tx, err)
+	// The current revision may be in a later batch.
+	exists, err := ft.HasCommit(fileData.Revision)
+	if err != nil {
+		return nil, db.Rollback(tx, err)
+	}
+	if exists {
+		if err = ft.SetRevision(fileData.Revision); err != nil {
+			return nil, db.Rollback(tx, err)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return errors.Wrap(err, "committing handle push transaction")
+		return nil, errors.Wrap(err, "committing handle push transaction")
 	}
 
-	return nil
+	return stored, nil
 }
 
 // Request body is expected to be multipart.
 // The first part is a JSON encoding of dotfile.TrackingData
 // Subsequent parts are new revisions that need to be saved.
 // Each revision part should have be named as its hash.
+// Responds with the hashes that are stored so clients can resume interrupted pushes.
+// The optional visibility query parameter sets who can see the file.
+// Owners and maintainers of an organization push to its files with their own tokens.
 func handlePush(w http.ResponseWriter, r *http.Request) {
 	var mr *multipart.Reader
 
-	userID := validateAPIUser(w, r)
-	if userID < 1 {
+	ownerID, _, ok := validateAPIFileOwner(w, r, db.TokenScopePush)
+	if !ok {
 		return
 	}
 
+	visibility := db.FileVisibility(r.URL.Query().Get("visibility"))
+	if visibility != "" {
+		if err := db.CheckFileVisibility(visibility); err != nil {
+			apiError(w, err)
+			return
+		}
+	}
+
 	if mr = multipartReader(w, r); mr == nil {
 		return
 	}
 
-	if err := push(mr, userID, mux.Vars(r)["alias"]); err != nil {
+	stored, err := push(mr, ownerID, mux.Vars(r)["alias"], visibility)
+	if err != nil {
 		apiError(w, err)
 		return
 	}
 
+	setJSON(w, map[string][]string{"stored": stored})
 }
 
 func setJSON(w http.ResponseWriter, body interface{}) {
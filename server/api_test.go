@@ -1,11 +1,17 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/gorilla/mux"
 	"github.com/knoebber/dotfile/db"
 	"github.com/knoebber/usererror"
 	"github.com/stretchr/testify/assert"
@@ -22,6 +28,18 @@ func TestHandleFileJSON(t *testing.T) {
 		createTestFile(t, createTestUser(t))
 		assertOK(t, router, testFilePath, http.MethodGet)
 	})
+
+	t.Run("304 when not modified", func(t *testing.T) {
+		resp := sendTestRequest(router, testFilePath, http.MethodGet)
+
+		r := httptest.NewRequest(http.MethodGet, testFilePath, nil)
+		r.Header.Set("If-None-Match", resp.Header().Get("ETag"))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
 }
 
 func TestHandleFileListJSON(t *testing.T) {
@@ -39,6 +57,39 @@ func TestHandleFileListJSON(t *testing.T) {
 	})
 }
 
+func TestHandleSearchJSON(t *testing.T) {
+	setupTestDB(t)
+	r := mux.NewRouter()
+	r.HandleFunc("/api/v1/search", handleSearchJSON)
+
+	u := createTestUser(t)
+	f := createTestFile(t, u)
+
+	t.Run("400 on invalid filter", func(t *testing.T) {
+		w := sendTestRequest(r, "/api/v1/search?q=sort:likes", http.MethodGet)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		var result struct {
+			Page    int
+			Pages   int
+			Total   int
+			Results []searchResultJSON
+		}
+
+		w := sendTestRequest(r, "/api/v1/search?l=1&q=user:"+u.Username, http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+		assert.Equal(t, 1, result.Page)
+		assert.Equal(t, 1, result.Pages)
+		assert.Equal(t, 1, result.Total)
+		if assert.Len(t, result.Results, 1) {
+			assert.Equal(t, f.Alias, result.Results[0].Alias)
+		}
+	})
+}
+
 func TestHandleRawCompressedCommit(t *testing.T) {
 	router := setupTestRouter(t, handleFileJSON)
 
@@ -60,7 +111,7 @@ func TestValidateAPIUser(t *testing.T) {
 		r http.Request
 	)
 
-	userID := validateAPIUser(&w, &r)
+	userID := validateAPIUser(&w, &r, db.TokenScopePush)
 	assert.Empty(t, userID)
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
@@ -73,3 +124,156 @@ func TestAPIError(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
+
+func TestHandleRevisions(t *testing.T) {
+	router := setupTestRouter(t, handleRevisions)
+
+	sendRevisionsRequest := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, testFilePath, strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("400 on invalid body", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, sendRevisionsRequest("invalid").Code)
+	})
+
+	t.Run("400 when too many hashes", func(t *testing.T) {
+		hashes := make([]string, maxBatchRevisions+1)
+		body, _ := json.Marshal(RevisionsRequest{Hashes: hashes})
+		assert.Equal(t, http.StatusBadRequest, sendRevisionsRequest(string(body)).Code)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		f := createTestFile(t, createTestUser(t))
+
+		body, _ := json.Marshal(RevisionsRequest{Hashes: []string{f.Hash, "missing"}})
+		w := sendRevisionsRequest(string(body))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		_, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		assert.NoError(t, err)
+
+		mr := multipart.NewReader(w.Body, params["boundary"])
+		part, err := mr.NextPart()
+		assert.NoError(t, err)
+		assert.Equal(t, f.Hash, part.FileName())
+
+		_, err = mr.NextPart()
+		assert.Equal(t, io.EOF, err)
+	})
+}
+
+func TestFileAPI(t *testing.T) {
+	setupTestDB(t)
+	router := mux.NewRouter()
+	apiRoutes(router)
+
+	u := createTestUser(t)
+	f := createTestFile(t, u)
+	filePath := "/api/v1/user/" + u.Username + "/" + f.Alias
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.SetBasicAuth(u.Username, u.CLIToken)
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("401 without auth", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, filePath, nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("403 for another user's file", func(t *testing.T) {
+		w := send(http.MethodDelete, "/api/v1/user/other/"+f.Alias, "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("403 when token is missing admin-api scope", func(t *testing.T) {
+		token, err := db.CreateToken(db.Connection, u.ID, "push only", []db.TokenScope{db.TokenScopePush}, nil)
+		assert.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, filePath, nil)
+		r.SetBasicAuth(u.Username, token)
+		router.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("update path", func(t *testing.T) {
+		w := send(http.MethodPatch, filePath, `{"path": "~/.new_path"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "~/.new_path")
+	})
+
+	t.Run("404 when revision doesn't exist", func(t *testing.T) {
+		w := send(http.MethodPut, filePath+"/revision", `{"hash": "missing"}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("set revision", func(t *testing.T) {
+		w := send(http.MethodPut, filePath+"/revision", `{"hash": "`+f.Hash+`"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("clear commits", func(t *testing.T) {
+		w := send(http.MethodDelete, filePath+"/commits", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, filePath, "").Code)
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, filePath, "").Code)
+	})
+}
+
+func TestPrivateFileAPI(t *testing.T) {
+	setupTestDB(t)
+	router := mux.NewRouter()
+	apiRoutes(router)
+
+	u := createTestUser(t)
+	f := createTestFile(t, u)
+	filePath := "/api/v1/user/" + u.Username + "/" + f.Alias
+	assert.NoError(t, f.SetVisibility(db.Connection, db.FileVisibilityPrivate))
+
+	send := func(path string, auth bool) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if auth {
+			r.SetBasicAuth(u.Username, u.CLIToken)
+		}
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("404 without auth", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, send(filePath, false).Code)
+		assert.Equal(t, http.StatusNotFound, send(filePath+"/raw", false).Code)
+		assert.Equal(t, http.StatusNotFound, send(filePath+"/"+f.Hash, false).Code)
+		assert.NotContains(t, send("/api/v1/user/"+u.Username, false).Body.String(), f.Alias)
+	})
+
+	t.Run("owner can read", func(t *testing.T) {
+		w := send(filePath, true)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+
+		w = send(filePath+"/"+f.Hash, true)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Header().Get("Cache-Control"), "private"))
+
+		assert.Contains(t, send("/api/v1/user/"+u.Username, true).Body.String(), f.Alias)
+	})
+
+	t.Run("400 on invalid push visibility", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, filePath+"?visibility=secret", nil)
+		r.SetBasicAuth(u.Username, u.CLIToken)
+		router.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
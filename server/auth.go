@@ -34,7 +34,7 @@ func handleAccountRecovery(config Config) pageBuilder {
 
 		// Don't let people see if emails exist or not by checking how long the page takes to load.
 		go func() {
-			token, err := db.SetPasswordResetToken(db.Connection, r.Form.Get("email"))
+			token, err := db.SetPasswordResetToken(db.Connection, r.Form.Get("email"), config.ResetExpiry)
 			if err != nil {
 				// Log the error but don't tell the user.
 				log.Print("reset password form: ", err)
@@ -10,6 +10,10 @@ import (
 
 func loadCommits(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	alias := p.Vars["alias"]
+	if err := pageFileAccess(p); err != nil {
+		return p.setError(w, err)
+	}
+
 	commits, err := db.CommitList(db.Connection, p.Vars["username"], alias, p.Timezone())
 	if err != nil {
 		return p.setError(w, err)
@@ -30,6 +34,10 @@ func loadCommit(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	hash := p.Vars["hash"]
 	username := p.Vars["username"]
 
+	if err := pageFileAccess(p); err != nil {
+		return p.setError(w, err)
+	}
+
 	commit, err := db.UncompressCommit(db.Connection, username, alias, hash, p.Timezone())
 	if err != nil {
 		return p.setError(w, err)
@@ -38,8 +46,7 @@ func loadCommit(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	p.Data["hash"] = hash
 	p.Data["message"] = commit.Message
 	p.Data["dateString"] = commit.DateString
-	p.Data["content"] = string(commit.Content)
-	p.Data["path"] = commit.Path
+	setFileContent(r, p, commit.Path, commit.Content)
 	p.Data["current"] = commit.Current
 	p.Data["forkedFromUsername"] = commit.ForkedFromUsername
 
@@ -49,7 +56,7 @@ func loadCommit(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 }
 
 // Handles submitting the restore form on the commits page.
-// This route isn't protected because any user can view any commit.
+// This route isn't protected because any user can view commits of files they can see.
 // Adds permission checks so only an owner can restore their file.
 func restoreFile(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	username := p.Vars["username"]
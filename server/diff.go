@@ -1,59 +1,260 @@
 package server
 
 import (
+	"fmt"
 	"html"
 	"html/template"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/hexops/gotextdiff"
 	"github.com/knoebber/dotfile/db"
 	"github.com/knoebber/dotfile/dotfile"
+	"github.com/knoebber/dotfile/highlight"
 )
 
-// Returns HTML that is ready to be added to a template.
-func getHtmlDiff(content *db.FileContent, on, against string) (template.HTML, error) {
-	var buff strings.Builder
+// The most unchanged lines that can be shown around changes.
+const maxDiffContext = 1000
+
+// How a diff is compared and shown. Set from the query:
+//
+// view: 'split' shows the old and new revisions side by side.
+// context: the amount of unchanged lines around changes.
+// whitespace: 'ignore' ignores changes in whitespace.
+type diffView struct {
+	options dotfile.DiffOptions
+	split   bool
+}
+
+func parseDiffView(r *http.Request) diffView {
+	query := r.URL.Query()
+
+	view := diffView{
+		options: dotfile.DiffOptions{
+			Context:          dotfile.DefaultDiffContext,
+			IgnoreWhitespace: query.Get("whitespace") == "ignore",
+		},
+		split: query.Get("view") == "split",
+	}
+
+	if context, err := strconv.Atoi(query.Get("context")); err == nil && context >= 0 && context <= maxDiffContext {
+		view.options.Context = context
+	}
+
+	return view
+}
 
-	unified, err := dotfile.Diff(content, on, against)
+// Runs a diff and sets its HTML and diffstat to page data.
+// Lines are highlighted as language.
+func loadHtmlDiff(r *http.Request, p *Page, content dotfile.Getter, language, on, against string) error {
+	view := parseDiffView(r)
+	p.Data["split"] = view.split
+	p.Data["context"] = view.options.Context
+	p.Data["ignoreWhitespace"] = view.options.IgnoreWhitespace
+
+	diff, err := dotfile.Diff(content, on, against, view.options)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	p.Data["diffStat"] = diff.Stat()
+	if view.split {
+		p.Data["diff"] = splitDiffHTML(diff, language)
+	} else {
+		p.Data["diff"] = unifiedDiffHTML(diff, language)
 	}
 
-	for _, hunk := range unified.Hunks {
-		if len(unified.Hunks) > 1 {
-			_, _ = buff.WriteString("<hr/><strong>HUNK</strong><hr/>")
+	return nil
+}
+
+// A row of a diff with a line from the old revision, the new revision, or both.
+// Equal lines are the same line on both sides.
+type diffRow struct {
+	old, new         *dotfile.DiffLine
+	oldHTML, newHTML template.HTML
+}
+
+func (r *diffRow) changed() bool {
+	return r.old != r.new
+}
+
+// Pairs the deleted and inserted lines of each change so that they can be compared word by word.
+// Lines that aren't compared are highlighted as language.
+func diffRows(lines []dotfile.DiffLine, language string) []diffRow {
+	var rows []diffRow
+
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == gotextdiff.Equal {
+			text := highlight.Line(language, lines[i].Content)
+			rows = append(rows, diffRow{old: &lines[i], new: &lines[i], oldHTML: text, newHTML: text})
+			i++
+			continue
 		}
-		for _, line := range hunk.Lines {
-			text := html.EscapeString(line.Content)
-			switch line.Kind {
-			case gotextdiff.Insert:
-				_, _ = buff.WriteString("<ins>")
-				_, _ = buff.WriteString(text)
-				_, _ = buff.WriteString("</ins>")
-			case gotextdiff.Delete:
-				_, _ = buff.WriteString("<del>")
-				_, _ = buff.WriteString(text)
-				_, _ = buff.WriteString("</del>")
-			case gotextdiff.Equal:
-				_, _ = buff.WriteString("<span>")
-				_, _ = buff.WriteString(text)
-				_, _ = buff.WriteString("</span>")
+
+		var deleted, inserted []*dotfile.DiffLine
+		for ; i < len(lines) && lines[i].Kind != gotextdiff.Equal; i++ {
+			if lines[i].Kind == gotextdiff.Delete {
+				deleted = append(deleted, &lines[i])
+			} else {
+				inserted = append(inserted, &lines[i])
+			}
+		}
+
+		for j := 0; j < len(deleted) || j < len(inserted); j++ {
+			var row diffRow
+
+			if j < len(deleted) && j < len(inserted) {
+				row.old, row.new = deleted[j], inserted[j]
+				oldSegments, newSegments := dotfile.WordDiff(row.old.Content, row.new.Content)
+				row.oldHTML, row.newHTML = segmentsHTML(oldSegments), segmentsHTML(newSegments)
+			} else if j < len(deleted) {
+				row.old = deleted[j]
+				row.oldHTML = highlight.Line(language, row.old.Content)
+			} else {
+				row.new = inserted[j]
+				row.newHTML = highlight.Line(language, row.new.Content)
 			}
+
+			rows = append(rows, row)
 		}
 	}
-	return template.HTML(buff.String()), nil
+
+	return rows
+}
+
+// Escapes segments and marks the words that changed.
+func segmentsHTML(segments []dotfile.DiffSegment) template.HTML {
+	var buff strings.Builder
+
+	for _, s := range segments {
+		if s.Changed {
+			_, _ = buff.WriteString("<mark>")
+			_, _ = buff.WriteString(html.EscapeString(s.Text))
+			_, _ = buff.WriteString("</mark>")
+		} else {
+			_, _ = buff.WriteString(html.EscapeString(s.Text))
+		}
+	}
+
+	return template.HTML(buff.String())
+}
+
+// Returns a diff with the deleted lines of each change above the inserted lines.
+func unifiedDiffHTML(diff *dotfile.FileDiff, language string) template.HTML {
+	var buff strings.Builder
+
+	writeLine := func(oldLine, newLine int, tag string, text template.HTML) {
+		writeDiffLineNumbers(&buff, oldLine, newLine)
+		_, _ = fmt.Fprintf(&buff, "<%s>%s</%s>\n", tag, text, tag)
+	}
+
+	_, _ = buff.WriteString(`<pre class="file-content"><code>`)
+	for _, hunk := range diff.Hunks {
+		_, _ = fmt.Fprintf(&buff, "<span class=\"hunk-header\">%s</span>\n", hunk.Header())
+
+		rows := diffRows(hunk.Lines, language)
+		for i := 0; i < len(rows); {
+			if !rows[i].changed() {
+				writeLine(rows[i].old.OldLine, rows[i].new.NewLine, "span", rows[i].newHTML)
+				i++
+				continue
+			}
+
+			end := i
+			for end < len(rows) && rows[end].changed() {
+				end++
+			}
+			for _, row := range rows[i:end] {
+				if row.old != nil {
+					writeLine(row.old.OldLine, 0, "del", row.oldHTML)
+				}
+			}
+			for _, row := range rows[i:end] {
+				if row.new != nil {
+					writeLine(0, row.new.NewLine, "ins", row.newHTML)
+				}
+			}
+			i = end
+		}
+	}
+	_, _ = buff.WriteString("</code></pre>")
+
+	return template.HTML(buff.String())
+}
+
+// Returns a table with the old revision on the left and the new revision on the right.
+func splitDiffHTML(diff *dotfile.FileDiff, language string) template.HTML {
+	var buff strings.Builder
+
+	_, _ = buff.WriteString(`<div class="table-wrapper"><table class="diff-split"><tbody>`)
+	for _, hunk := range diff.Hunks {
+		_, _ = fmt.Fprintf(&buff, `<tr class="hunk-header"><td colspan="4">%s</td></tr>`, hunk.Header())
+
+		for _, row := range diffRows(hunk.Lines, language) {
+			_, _ = buff.WriteString("<tr>")
+
+			switch {
+			case row.old == nil:
+				_, _ = buff.WriteString(`<td class="line-number"></td><td></td>`)
+			case row.changed():
+				_, _ = fmt.Fprintf(&buff, `<td class="line-number">%d</td><td><del>%s</del></td>`, row.old.OldLine, row.oldHTML)
+			default:
+				_, _ = fmt.Fprintf(&buff, `<td class="line-number">%d</td><td><span>%s</span></td>`, row.old.OldLine, row.oldHTML)
+			}
+
+			switch {
+			case row.new == nil:
+				_, _ = buff.WriteString(`<td class="line-number"></td><td></td>`)
+			case row.changed():
+				_, _ = fmt.Fprintf(&buff, `<td class="line-number"><a id="L%d" href="#L%d">%d</a></td><td><ins>%s</ins></td>`,
+					row.new.NewLine, row.new.NewLine, row.new.NewLine, row.newHTML)
+			default:
+				_, _ = fmt.Fprintf(&buff, `<td class="line-number"><a id="L%d" href="#L%d">%d</a></td><td><span>%s</span></td>`,
+					row.new.NewLine, row.new.NewLine, row.new.NewLine, row.newHTML)
+			}
+
+			_, _ = buff.WriteString("</tr>")
+		}
+	}
+	_, _ = buff.WriteString("</tbody></table></div>")
+
+	return template.HTML(buff.String())
+}
+
+// Writes the line numbers of a diff line. Zero is left blank.
+// Lines in the new revision have an anchor like #L10.
+func writeDiffLineNumbers(buff *strings.Builder, oldLine, newLine int) {
+	if oldLine == 0 {
+		_, _ = buff.WriteString(`<span class="line-number"></span>`)
+	} else {
+		_, _ = fmt.Fprintf(buff, `<span class="line-number">%d</span>`, oldLine)
+	}
+
+	if newLine == 0 {
+		_, _ = buff.WriteString(`<span class="line-number"></span>`)
+	} else {
+		_, _ = fmt.Fprintf(buff, `<a class="line-number" id="L%d" href="#L%d">%d</a>`, newLine, newLine, newLine)
+	}
 }
 
 // Loads a diff: ?on VS ?against.
 func loadDiff(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
-	// TODO add line numbers to diff template
 	alias := p.Vars["alias"]
 	username := p.Vars["username"]
 
 	on := r.URL.Query().Get("on")
 	against := r.URL.Query().Get("against")
 
+	if err := pageFileAccess(p); err != nil {
+		return p.setError(w, err)
+	}
+
+	file, err := db.File(db.Connection, username, alias)
+	if err != nil {
+		return p.setError(w, err)
+	}
+
 	commits, err := db.CommitList(db.Connection, username, alias, p.Timezone())
 	if err != nil {
 		return p.setError(w, err)
@@ -75,19 +276,18 @@ func loadDiff(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 
 	p.Data["commits"] = commits
 	p.Data["alias"] = alias
+	p.Data["path"] = file.Path
 	p.Data["against"] = against
 	p.Data["on"] = on
 
 	if on == "" || against == "" {
 		return
 	}
-	diff, err := getHtmlDiff(&db.FileContent{Connection: db.Connection, Username: username, Alias: alias}, on, against)
-
-	if err != nil {
+	content := &db.FileContent{Connection: db.Connection, Username: username, Alias: alias}
+	if err := loadHtmlDiff(r, p, content, highlight.Language(file.Path, ""), on, against); err != nil {
 		return p.setError(w, err)
 	}
 
-	p.Data["diff"] = diff
 	return
 }
 
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/knoebber/dotfile/highlight"
+	"github.com/stretchr/testify/assert"
+)
+
+// Revisions are keyed by hash.
+type testGetter map[string]string
+
+func (g testGetter) DirtyContent() ([]byte, error) {
+	return nil, nil
+}
+
+func (g testGetter) Revision(hash string) ([]byte, error) {
+	compressed, err := dotfile.Compress([]byte(g[hash]))
+	if err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+func TestParseDiffView(t *testing.T) {
+	view := parseDiffView(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, dotfile.DefaultDiffContext, view.options.Context)
+	assert.False(t, view.options.IgnoreWhitespace)
+	assert.False(t, view.split)
+
+	view = parseDiffView(httptest.NewRequest(http.MethodGet, "/?view=split&context=0&whitespace=ignore", nil))
+	assert.Zero(t, view.options.Context)
+	assert.True(t, view.options.IgnoreWhitespace)
+	assert.True(t, view.split)
+
+	view = parseDiffView(httptest.NewRequest(http.MethodGet, "/?context=-1", nil))
+	assert.Equal(t, dotfile.DefaultDiffContext, view.options.Context)
+}
+
+func TestLoadHtmlDiff(t *testing.T) {
+	content := testGetter{
+		"a": "# one\ntwo\nthree\n",
+		"b": "# one\n2\nthree\n",
+	}
+
+	t.Run("unified", func(t *testing.T) {
+		p := &Page{Data: make(map[string]interface{})}
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		assert.NoError(t, loadHtmlDiff(r, p, content, highlight.Shell, "a", "b"))
+		assert.Equal(t, "1 file changed, 1 insertion(+), 1 deletion(-)", p.Data["diffStat"])
+
+		diff := string(p.Data["diff"].(template.HTML))
+		assert.Contains(t, diff, `<span class="hunk-header">@@ -1,3 +1,3 @@</span>`)
+		assert.Contains(t, diff,
+			`<span class="line-number">1</span><a class="line-number" id="L1" href="#L1">1</a><span><span class="hl-comment"># one</span></span>`+"\n")
+		assert.Contains(t, diff,
+			`<span class="line-number">2</span><span class="line-number"></span><del><mark>two</mark></del>`+"\n")
+		assert.Contains(t, diff,
+			`<span class="line-number"></span><a class="line-number" id="L2" href="#L2">2</a><ins><mark>2</mark></ins>`+"\n")
+	})
+
+	t.Run("split", func(t *testing.T) {
+		p := &Page{Data: make(map[string]interface{})}
+		r := httptest.NewRequest(http.MethodGet, "/?view=split&context=0", nil)
+
+		assert.NoError(t, loadHtmlDiff(r, p, content, highlight.Shell, "a", "b"))
+
+		diff := string(p.Data["diff"].(template.HTML))
+		assert.Contains(t, diff, `<tr class="hunk-header"><td colspan="4">@@ -2,1 +2,1 @@</td></tr>`)
+		assert.Contains(t, diff,
+			`<tr><td class="line-number">2</td><td><del><mark>two</mark></del></td>`+
+				`<td class="line-number"><a id="L2" href="#L2">2</a></td><td><ins><mark>2</mark></ins></td></tr>`)
+		assert.NotContains(t, diff, "one")
+	})
+
+	t.Run("line numbers after an earlier hunk", func(t *testing.T) {
+		var lines []string
+		for i := 1; i <= 20; i++ {
+			lines = append(lines, fmt.Sprint("line", i))
+		}
+		old := strings.Join(lines, "\n") + "\n"
+		lines[17] = "changed"
+		content := testGetter{"a": old, "b": "new\n" + strings.Join(lines, "\n") + "\n"}
+
+		p := &Page{Data: make(map[string]interface{})}
+		assert.NoError(t, loadHtmlDiff(httptest.NewRequest(http.MethodGet, "/", nil), p, content, "", "a", "b"))
+
+		diff := string(p.Data["diff"].(template.HTML))
+		assert.Contains(t, diff, `<span class="line-number">18</span><span class="line-number"></span><del><mark>line18</mark></del>`)
+		assert.Contains(t, diff, `<a class="line-number" id="L19" href="#L19">19</a><ins><mark>changed</mark></ins>`)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		p := &Page{Data: make(map[string]interface{})}
+		err := loadHtmlDiff(httptest.NewRequest(http.MethodGet, "/", nil), p, content, "", "a", "a")
+		assert.ErrorIs(t, err, dotfile.ErrNoChanges)
+	})
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/knoebber/dotfile/dotfile"
+)
+
+// Revisions are content addressed so their responses never change.
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// Responses that change when a file is updated must be revalidated before they are reused.
+const revalidateCacheControl = "no-cache"
+
+// Sets a strong ETag for the response.
+// Responds with 304 Not Modified when the request's If-None-Match has the ETag.
+// Returns true when the response is complete.
+func notModified(w http.ResponseWriter, r *http.Request, tag, cacheControl string) bool {
+	etag := `"` + tag + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// File data changes when commits are added or cleared and when its path is updated.
+func fileDataETag(data *dotfile.TrackingData) string {
+	return fmt.Sprintf("%s-%d-%x", data.Revision, len(data.Commits), sha1.Sum([]byte(data.Path)))
+}
+
+// If-None-Match uses weak comparison so a W/ prefix is ignored.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotModified(t *testing.T) {
+	t.Run("sets headers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		assert.False(t, notModified(w, r, "abc", immutableCacheControl))
+		assert.Equal(t, `"abc"`, w.Header().Get("ETag"))
+		assert.Equal(t, immutableCacheControl, w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("304 when etag matches", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("If-None-Match", `"xyz", W/"abc"`)
+
+		assert.True(t, notModified(w, r, "abc", revalidateCacheControl))
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("If-None-Match", `"xyz"`)
+
+		assert.False(t, notModified(w, r, "abc", revalidateCacheControl))
+	})
+}
+
+func TestFileDataETag(t *testing.T) {
+	data := &dotfile.TrackingData{
+		Path:     "~/.bashrc",
+		Revision: "abc",
+		Commits:  []dotfile.Commit{{Hash: "abc"}},
+	}
+	etag := fileDataETag(data)
+
+	data.Path = "~/.config/bashrc"
+	assert.NotEqual(t, etag, fileDataETag(data), "changes with the path")
+
+	data.Path = "~/.bashrc"
+	assert.Equal(t, etag, fileDataETag(data))
+
+	data.Commits = nil
+	assert.NotEqual(t, etag, fileDataETag(data), "changes when commits are cleared")
+}
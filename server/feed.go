@@ -6,11 +6,19 @@ import (
 	"time"
 
 	"github.com/gorilla/feeds"
+	"github.com/gorilla/mux"
 	"github.com/knoebber/dotfile/db"
 )
 
 const feedSize = 50
 
+// Feed formats that are supported by writeFeed.
+const (
+	feedFormatRSS  = "rss"
+	feedFormatAtom = "atom"
+	feedFormatJSON = "json"
+)
+
 func createRSSFeed(config Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		now := time.Now()
@@ -44,9 +52,166 @@ func createRSSFeed(config Config) http.HandlerFunc {
 			}
 		}
 
-		if err := feed.WriteRss(w); err != nil {
-			setError(w, err, "failed to write RSS feed", http.StatusInternalServerError)
+		writeFeed(w, feed, feedFormatRSS)
+	}
+}
+
+// Serves the activity of the users that username follows.
+// The feed is private so it requires username's feed token.
+func followingFeedHandler(config Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		username := vars["username"]
+
+		userID, err := db.FeedTokenUserID(db.Connection, username, r.URL.Query().Get("token"))
+		if db.NotFound(err) {
+			setError(w, err, "feed not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			setError(w, err, "failed to create feed", http.StatusInternalServerError)
+			return
+		}
+
+		activity, err := db.FollowingActivity(db.Connection, userID, feedSize, nil)
+		if err != nil {
+			setError(w, err, "failed to create feed", http.StatusInternalServerError)
+			return
+		}
+
+		url := config.URL(r)
+		feed := &feeds.Feed{
+			Title:       "Dotfilehub - " + username + " following",
+			Link:        &feeds.Link{Href: url + "/following"},
+			Description: "Commits from users that " + username + " follows",
+			Created:     time.Now(),
+			Items:       activityFeedItems(url, activity),
+		}
+
+		w.Header().Set("Cache-Control", "private, no-cache")
+		writeFeed(w, feed, vars["format"])
+	}
+}
+
+// Serves the commits on a user's public files.
+func userFeedHandler(config Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		username := vars["username"]
+
+		if err := db.ValidateUserExists(db.Connection, username); db.NotFound(err) {
+			setError(w, err, "user not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			setError(w, err, "failed to create feed", http.StatusInternalServerError)
+			return
+		}
+
+		activity, err := db.UserActivity(db.Connection, username, feedSize, nil)
+		if err != nil {
+			setError(w, err, "failed to create feed", http.StatusInternalServerError)
+			return
+		}
+
+		url := config.URL(r)
+		feed := &feeds.Feed{
+			Title:       "Dotfilehub - " + username,
+			Link:        &feeds.Link{Href: url + "/" + username},
+			Description: "Recent commits by " + username,
+			Created:     time.Now(),
+			Items:       activityFeedItems(url, activity),
+		}
+
+		writeFeed(w, feed, vars["format"])
+	}
+}
+
+// Serves the commits of a file.
+func fileFeedHandler(config Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		username := vars["username"]
+		alias := vars["alias"]
+
+		visibility, err := fileAccess(r)
+		if db.NotFound(err) {
+			setError(w, err, "file not found", http.StatusNotFound)
 			return
+		} else if err != nil {
+			setError(w, err, "failed to create feed", http.StatusInternalServerError)
+			return
+		}
+
+		activity, err := db.FileActivity(db.Connection, username, alias, feedSize, nil)
+		if err != nil {
+			setError(w, err, "failed to create feed", http.StatusInternalServerError)
+			return
+		}
+
+		url := config.URL(r)
+		fileLink := fmt.Sprintf("%s/%s/%s", url, username, alias)
+		feed := &feeds.Feed{
+			Title:       fmt.Sprintf("Dotfilehub - %s/%s", username, alias),
+			Link:        &feeds.Link{Href: fileLink},
+			Description: fmt.Sprintf("Commits to %s/%s", username, alias),
+			Created:     time.Now(),
+			Items:       activityFeedItems(url, activity),
+		}
+
+		if visibility == db.FileVisibilityPrivate {
+			w.Header().Set("Cache-Control", "private, no-cache")
 		}
+		writeFeed(w, feed, vars["format"])
+	}
+}
+
+// Creates an item for each commit.
+// Items link to the commit's diff, or to the commit when it created the file.
+func activityFeedItems(url string, activity []db.Activity) []*feeds.Item {
+	items := make([]*feeds.Item, len(activity))
+
+	for i, a := range activity {
+		commitLink := fmt.Sprintf("%s/%s/%s/%s", url, a.Username, a.Alias, a.Hash)
+		link := commitLink
+		if !a.NewFile {
+			link = fmt.Sprintf("%s/%s/%s/diff?against=%s", url, a.Username, a.Alias, a.Hash)
+		}
+
+		title := fmt.Sprintf("%s/%s: %s", a.Username, a.Alias, a.Message)
+		if a.NewFile {
+			title = fmt.Sprintf("New file %s/%s", a.Username, a.Alias)
+		} else if a.Message == "" {
+			title = fmt.Sprintf("%s/%s updated", a.Username, a.Alias)
+		}
+
+		items[i] = &feeds.Item{
+			Id:          commitLink,
+			Title:       title,
+			Link:        &feeds.Link{Href: link},
+			Description: a.Path,
+			Content:     a.Message,
+			Author:      &feeds.Author{Name: a.Username},
+			Created:     a.Timestamp,
+		}
+	}
+
+	return items
+}
+
+func writeFeed(w http.ResponseWriter, feed *feeds.Feed, format string) {
+	var err error
+
+	switch format {
+	case feedFormatAtom:
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		err = feed.WriteAtom(w)
+	case feedFormatJSON:
+		w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+		err = feed.WriteJSON(w)
+	default:
+		err = feed.WriteRss(w)
+	}
+
+	if err != nil {
+		setError(w, err, "failed to write feed", http.StatusInternalServerError)
 	}
 }
@@ -3,6 +3,10 @@ package server
 import (
 	"net/http"
 	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/knoebber/dotfile/db"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestFileFeed(t *testing.T) {
@@ -13,3 +17,85 @@ func TestFileFeed(t *testing.T) {
 		assertOK(t, router, testFilePath, http.MethodGet)
 	})
 }
+
+func TestFollowingFeed(t *testing.T) {
+	setupTestDB(t)
+	router := mux.NewRouter()
+	router.HandleFunc("/{username}/following.{format:rss|atom}", followingFeedHandler(Config{}))
+
+	u := createTestUser(t)
+	other, err := db.CreateUser(db.Connection, "other", "", testPassword)
+	assert.NoError(t, err)
+	createTestFile(t, other)
+	assert.NoError(t, db.Follow(db.Connection, u.ID, other.Username))
+
+	token, err := db.RotateFeedToken(db.Connection, u.ID)
+	assert.NoError(t, err)
+
+	t.Run("404 with wrong token", func(t *testing.T) {
+		assertNotFound(t, router, "/"+u.Username+"/following.rss?token=wrong", http.MethodGet)
+	})
+
+	t.Run("rss", func(t *testing.T) {
+		w := sendTestRequest(router, "/"+u.Username+"/following.rss?token="+token, http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "New file other/"+testAlias)
+	})
+
+	t.Run("atom", func(t *testing.T) {
+		w := sendTestRequest(router, "/"+u.Username+"/following.atom?token="+token, http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "atom")
+		assert.Contains(t, w.Body.String(), "<feed")
+	})
+}
+
+func TestUserAndFileCommitFeeds(t *testing.T) {
+	setupTestDB(t)
+	router := mux.NewRouter()
+	router.HandleFunc("/{username}/feed.{format:rss|atom|json}", userFeedHandler(Config{}))
+	router.HandleFunc("/{username}/{alias}/commits.{format:rss|atom|json}", fileFeedHandler(Config{}))
+
+	u := createTestUser(t)
+	f := createTestFile(t, u)
+	createTestTempFile(t, u.ID, "new content!")
+	assert.NoError(t, db.InitOrCommit(u.ID, testAlias, "second commit"))
+	userFeed := "/" + u.Username + "/feed."
+	fileFeed := "/" + u.Username + "/" + testAlias + "/commits."
+
+	t.Run("404 when user doesn't exist", func(t *testing.T) {
+		assertNotFound(t, router, "/missing/feed.rss", http.MethodGet)
+	})
+
+	t.Run("user feed", func(t *testing.T) {
+		w := sendTestRequest(router, userFeed+"rss", http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "second commit")
+	})
+
+	t.Run("file feed links to diffs", func(t *testing.T) {
+		w := sendTestRequest(router, fileFeed+"atom", http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "/diff?against=")
+		assert.Contains(t, w.Body.String(), "New file "+u.Username+"/"+testAlias)
+	})
+
+	t.Run("json feed", func(t *testing.T) {
+		w := sendTestRequest(router, fileFeed+"json", http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/feed+json")
+		assert.Contains(t, w.Body.String(), "second commit")
+	})
+
+	t.Run("private files", func(t *testing.T) {
+		record, err := db.File(db.Connection, u.Username, f.Alias)
+		assert.NoError(t, err)
+		assert.NoError(t, record.SetVisibility(db.Connection, db.FileVisibilityPrivate))
+
+		assertNotFound(t, router, fileFeed+"rss", http.MethodGet)
+
+		w := sendTestRequest(router, userFeed+"json", http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "second commit")
+	})
+}
@@ -2,6 +2,8 @@ package server
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/knoebber/dotfile/db"
 	"github.com/knoebber/usererror"
@@ -26,6 +28,61 @@ func updateFile(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	return true
 }
 
+func setFileVisibility(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	record, err := db.File(db.Connection, p.Username(), p.Vars["alias"])
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	if err := record.SetVisibility(db.Connection, db.FileVisibility(r.Form.Get("visibility"))); err != nil {
+		return p.setError(w, err)
+	}
+
+	p.flashSuccess("Updated visibility")
+	return
+}
+
+func handleFileSharesForm(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	var expiresAt *time.Time
+
+	record, err := db.File(db.Connection, p.Username(), p.Vars["alias"])
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	if revoke := r.Form.Get("revoke"); revoke != "" {
+		id, err := strconv.ParseInt(revoke, 10, 64)
+		if err != nil {
+			return p.setError(w, usererror.New("Invalid share link."))
+		}
+		if err := db.RevokeShare(db.Connection, record.ID, id); err != nil {
+			return p.setError(w, err)
+		}
+
+		p.flashSuccess("Revoked share link")
+		return
+	}
+
+	if days := r.Form.Get("expires"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return p.setError(w, usererror.New("Expiration must be a positive number of days."))
+		}
+
+		t := time.Now().AddDate(0, 0, n)
+		expiresAt = &t
+	}
+
+	token, err := db.CreateShare(db.Connection, record.ID, r.Form.Get("history") == "true", expiresAt)
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["token"] = token
+	p.flashSuccess("Created share link")
+	return
+}
+
 func clearFile(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	username := p.Vars["username"]
 	alias := p.Vars["alias"]
@@ -83,6 +140,35 @@ func loadFile(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	return
 }
 
+func loadFileVisibility(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	file, err := db.File(db.Connection, p.Vars["username"], p.Vars["alias"])
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["visibility"] = file.Visibility
+	p.Data["visibilities"] = db.FileVisibilities
+	return
+}
+
+func loadFileShares(config Config) pageBuilder {
+	return func(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+		record, err := db.File(db.Connection, p.Vars["username"], p.Vars["alias"])
+		if err != nil {
+			return p.setError(w, err)
+		}
+
+		shares, err := db.Shares(db.Connection, record.ID, p.Timezone())
+		if err != nil {
+			return p.setError(w, err)
+		}
+
+		p.Data["shares"] = shares
+		p.Data["shareURL"] = config.URL(r) + "/share/"
+		return
+	}
+}
+
 func fileSettingsHandler() http.HandlerFunc {
 	return createHandler(&pageDescription{
 		templateName: "file_settings.tmpl",
@@ -101,6 +187,26 @@ func updateFileHandler() http.HandlerFunc {
 	})
 }
 
+func fileVisibilityHandler() http.HandlerFunc {
+	return createHandler(&pageDescription{
+		templateName: "file_visibility.tmpl",
+		title:        "visibility",
+		loadData:     loadFileVisibility,
+		handleForm:   setFileVisibility,
+		protected:    true,
+	})
+}
+
+func fileSharesHandler(config Config) http.HandlerFunc {
+	return createHandler(&pageDescription{
+		templateName: "file_shares.tmpl",
+		title:        "shares",
+		loadData:     loadFileShares(config),
+		handleForm:   handleFileSharesForm,
+		protected:    true,
+	})
+}
+
 func clearFileHandler() http.HandlerFunc {
 	return createHandler(&pageDescription{
 		templateName: "delete_commits.tmpl",
@@ -7,6 +7,7 @@ import (
 
 	"github.com/knoebber/dotfile/db"
 	"github.com/knoebber/dotfile/dotfile"
+	"github.com/knoebber/dotfile/highlight"
 	"github.com/knoebber/usererror"
 )
 
@@ -87,6 +88,9 @@ func forkFile(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	if loggedInUserID < 1 {
 		return p.setError(w, usererror.New("Must be logged in to fork file."))
 	}
+	if _, err := db.FileAccess(db.Connection, username, alias, loggedInUserID); err != nil {
+		return p.setError(w, err)
+	}
 
 	if err := db.ForkFile(username, alias, hash, loggedInUserID); err != nil {
 		return p.setError(w, err)
@@ -95,6 +99,17 @@ func forkFile(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	return
 }
 
+// Sets the path and the syntax highlighted content of a file to page data.
+// Lines are numbered and the lines in the ?lines query are selected.
+func setFileContent(r *http.Request, p *Page, path string, content []byte) lineRange {
+	selected := parseLineRange(r.URL.Query().Get("lines"))
+	highlighted := highlight.HTML(highlight.Language(path, string(content)), string(content))
+
+	p.Data["path"] = path
+	p.Data["content"] = numberLines(highlighted, selected)
+	return selected
+}
+
 // Uncompress file and sets content to page data.
 func uncompressFile(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
@@ -105,14 +120,22 @@ func uncompressFile(w http.ResponseWriter, r *http.Request, p *Page) (done bool)
 	username := p.Vars["username"]
 	alias := p.Vars["alias"]
 
+	if err := pageFileAccess(p); err != nil {
+		return p.setError(w, err)
+	}
+	if err := loadUpstreamSummary(p); err != nil {
+		return p.setError(w, err)
+	}
+
 	file, err := db.UncompressFile(db.Connection, username, alias)
 	if err != nil {
 		return p.setError(w, err)
 	}
 
-	p.Data["path"] = file.Path
-	p.Data["content"] = string(file.Content)
+	selected := setFileContent(r, p, file.Path, file.Content)
 	p.Data["hash"] = file.Hash
+	// Links to the current revision so that it doesn't change when the file does.
+	p.Data["permalink"] = fmt.Sprintf("/%s/%s/%s%s", username, alias, file.Hash, selected.suffix())
 
 	p.Title = file.Alias
 
@@ -192,18 +215,16 @@ func loadCommitConfirm(w http.ResponseWriter, r *http.Request, p *Page) (done bo
 	p.Data["alias"] = f.Alias
 	p.Data["path"] = f.Path
 
-	diff, err := getHtmlDiff(&db.FileContent{
+	content := &db.FileContent{
 		Connection: db.Connection,
 		Username:   p.Username(),
 		UserID:     p.userID(),
 		Alias:      alias,
-	}, f.Hash, "")
-
-	if err != nil {
+	}
+	if err := loadHtmlDiff(r, p, content, highlight.Language(f.Path, string(f.Content)), f.Hash, ""); err != nil {
 		return p.setError(w, err)
 	}
 
-	p.Data["diff"] = diff
 	return
 }
 
@@ -215,8 +236,7 @@ func loadNewFileConfirm(w http.ResponseWriter, r *http.Request, p *Page) (done b
 	}
 
 	p.Data["alias"] = tempFile.Alias
-	p.Data["path"] = tempFile.Path
-	p.Data["content"] = string(tempFile.Content)
+	setFileContent(r, p, tempFile.Path, tempFile.Content)
 	p.Data["editAction"] = "/new_file"
 	p.Title = "confirm new file"
 	return
@@ -1,10 +1,12 @@
 package server
 
 import (
+	"html/template"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/gorilla/mux"
 	"github.com/knoebber/dotfile/db"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,11 +17,47 @@ func TestFileHandler(t *testing.T) {
 		assertNotFound(t, router, testFilePath, http.MethodGet)
 	})
 
-	createTestFile(t, createTestUser(t))
+	f := createTestFile(t, createTestUser(t))
 
 	t.Run("ok", func(t *testing.T) {
 		assertOK(t, router, testFilePath, http.MethodGet)
 	})
+
+	t.Run("permalink keeps selected lines", func(t *testing.T) {
+		router := mux.NewRouter()
+		router.HandleFunc("/{username}/{alias}", fileHandler())
+
+		resp := sendTestRequest(router, "/"+testUsername+"/"+testAlias+"?lines=1", http.MethodGet)
+		assert.Contains(t, resp.Body.String(), `<span class="line selected" id="L1">`)
+		assert.Contains(t, resp.Body.String(), "/"+f.Hash+"?lines=1#L1")
+	})
+
+	t.Run("fragment without lines query", func(t *testing.T) {
+		router := mux.NewRouter()
+		router.HandleFunc("/{username}/{alias}", fileHandler())
+
+		// Browsers don't send fragments, so ranges are only selected by the query.
+		resp := sendTestRequest(router, "/"+testUsername+"/"+testAlias+"#L1-L2", http.MethodGet)
+		assert.NotContains(t, resp.Body.String(), "selected")
+		assert.NotContains(t, resp.Body.String(), `id="L1-L2"`)
+
+		// Every link to a line has the query form that can be shared.
+		assert.Contains(t, resp.Body.String(), `<a class="line-number" href="?lines=1#L1">1</a>`)
+		assert.NotContains(t, resp.Body.String(), `class="line-number" href="#`)
+	})
+}
+
+func TestSetFileContent(t *testing.T) {
+	p := &Page{Data: make(map[string]interface{})}
+	r := httptest.NewRequest(http.MethodGet, "/?lines=2", nil)
+
+	selected := setFileContent(r, p, "~/bin/hello", []byte("#!/bin/sh\necho <hi>\n"))
+	assert.Equal(t, lineRange{2, 2}, selected)
+	assert.Equal(t, "~/bin/hello", p.Data["path"])
+	assert.Equal(t,
+		template.HTML(`<span class="line" id="L1"><a class="line-number" href="?lines=1#L1">1</a><span class="hl-comment">#!/bin/sh</span></span>`+"\n"+
+			`<span class="line selected" id="L2"><a class="line-number" href="?lines=2#L2">2</a>echo &lt;hi&gt;</span>`+"\n"),
+		p.Data["content"])
 }
 
 func TestNewTempFile(t *testing.T) {
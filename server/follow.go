@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/knoebber/dotfile/db"
+)
+
+// Handles the follow button on a user's page.
+func handleFollowForm(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	var err error
+
+	username := p.Vars["username"]
+
+	if p.Session == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return true
+	}
+
+	if r.Form.Get("unfollow") != "" {
+		err = db.Unfollow(db.Connection, p.userID(), username)
+	} else {
+		err = db.Follow(db.Connection, p.userID(), username)
+	}
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	http.Redirect(w, r, "/"+username, http.StatusSeeOther)
+	return true
+}
+
+// Loads the follower count of the page's user and whether the logged in user follows them.
+func loadFollow(p *Page) error {
+	username := p.Vars["username"]
+
+	followers, err := db.Followers(db.Connection, username)
+	if err != nil {
+		return err
+	}
+
+	following, err := db.IsFollowing(db.Connection, p.userID(), username)
+	if err != nil {
+		return err
+	}
+
+	p.Data["followers"] = followers
+	p.Data["following"] = following
+	return nil
+}
+
+// Generates a new token for the private feed URLs.
+// Only a hash of the token is saved so the URLs are shown once.
+func handleFeedTokenForm(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	token, err := db.RotateFeedToken(db.Connection, p.userID())
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["feedToken"] = token
+	p.flashSuccess("Generated new feed URL")
+	return
+}
+
+// Loads the newest commits of the users that the logged in user follows.
+func loadFollowingActivity(config Config) pageBuilder {
+	return func(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+		activity, err := db.FollowingActivity(db.Connection, p.userID(), feedSize, p.Timezone())
+		if err != nil {
+			return p.setError(w, err)
+		}
+
+		following, err := db.Following(db.Connection, p.userID())
+		if err != nil {
+			return p.setError(w, err)
+		}
+
+		p.Data["url"] = config.URL(r)
+		p.Data["activity"] = activity
+		p.Data["followingUsers"] = following
+		return
+	}
+}
+
+func followingHandler(config Config) http.HandlerFunc {
+	return createHandler(&pageDescription{
+		templateName: "following.tmpl",
+		title:        "Following",
+		loadData:     loadFollowingActivity(config),
+		handleForm:   handleFeedTokenForm,
+		protected:    true,
+	})
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/knoebber/dotfile/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleFollowForm(t *testing.T) {
+	setupTestDB(t)
+	_, r, p := setupTestPage(t)
+
+	_, err := db.CreateUser(db.Connection, "other", "", testPassword)
+	assert.NoError(t, err)
+	p.Vars["username"] = "other"
+
+	t.Run("redirects to login without a session", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		assert.True(t, handleFollowForm(w, r, &Page{Vars: p.Vars}))
+		assert.Equal(t, "/login", w.Header().Get("Location"))
+	})
+
+	t.Run("error when following yourself", func(t *testing.T) {
+		selfPage := &Page{Session: p.Session, Vars: map[string]string{"username": testUsername}}
+		assert.False(t, handleFollowForm(httptest.NewRecorder(), r, selfPage))
+		assert.NotEmpty(t, selfPage.ErrorMessage)
+	})
+
+	t.Run("follow", func(t *testing.T) {
+		assert.True(t, handleFollowForm(httptest.NewRecorder(), r, p))
+		assert.NoError(t, loadFollow(p))
+		assert.Equal(t, 1, p.Data["followers"])
+		assert.Equal(t, true, p.Data["following"])
+	})
+
+	t.Run("unfollow", func(t *testing.T) {
+		r.Form = url.Values{"unfollow": []string{"true"}}
+		assert.True(t, handleFollowForm(httptest.NewRecorder(), r, p))
+		assert.NoError(t, loadFollow(p))
+		assert.Equal(t, 0, p.Data["followers"])
+	})
+}
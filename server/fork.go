@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/knoebber/dotfile/db"
+	"github.com/knoebber/dotfile/highlight"
+)
+
+func loadForks(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	if err := pageFileAccess(p); err != nil {
+		return p.setError(w, err)
+	}
+
+	forks, err := db.Forks(db.Connection, p.Vars["username"], p.Vars["alias"], p.userID(), p.Timezone())
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["forks"] = forks
+	p.Title = "forks"
+	return
+}
+
+// Loads the upstream of the page's file when it is a fork.
+func loadUpstreamSummary(p *Page) error {
+	upstream, err := db.Upstream(db.Connection, p.Vars["username"], p.Vars["alias"], p.userID())
+	if db.NotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	p.Data["upstream"] = upstream
+	return nil
+}
+
+// Loads a diff of the fork's current revision against upstream's.
+func loadUpstream(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	username := p.Vars["username"]
+	alias := p.Vars["alias"]
+	p.Title = "upstream"
+
+	if err := pageFileAccess(p); err != nil {
+		return p.setError(w, err)
+	}
+
+	upstream, err := db.Upstream(db.Connection, username, alias, p.userID())
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	file, err := db.UncompressFile(db.Connection, username, alias)
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["upstream"] = upstream
+	p.Data["path"] = file.Path
+	if file.Hash == upstream.Hash {
+		return
+	}
+
+	content := &db.ForkContent{
+		Fork:     db.FileContent{Connection: db.Connection, Username: username, Alias: alias},
+		Upstream: db.FileContent{Connection: db.Connection, Username: upstream.Username, Alias: upstream.Alias},
+	}
+	if err := loadHtmlDiff(r, p, content, highlight.Language(file.Path, string(file.Content)), file.Hash, upstream.Hash); err != nil {
+		return p.setError(w, err)
+	}
+
+	return
+}
+
+// Handles the sync form on the upstream page.
+// Like restoring a commit only the owner can sync their fork.
+func syncFork(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	username := p.Vars["username"]
+
+	if p.Session == nil {
+		permissionDenied(w, "", username)
+		return true
+	}
+	if !p.Owned() {
+		permissionDenied(w, p.Session.Username, username)
+		return true
+	}
+
+	count, err := db.SyncFork(username, p.Vars["alias"])
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.flashSuccess(fmt.Sprintf("Imported %d commits from upstream", count))
+	return
+}
+
+func forksHandler() http.HandlerFunc {
+	return createHandler(&pageDescription{
+		templateName: "forks.tmpl",
+		loadData:     loadForks,
+	})
+}
+
+func upstreamHandler() http.HandlerFunc {
+	return createHandler(&pageDescription{
+		templateName: "upstream.tmpl",
+		loadData:     loadUpstream,
+		handleForm:   syncFork,
+	})
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/knoebber/dotfile/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForkUpstream(t *testing.T) {
+	setupTestDB(t)
+	w, r, p := setupTestPage(t)
+
+	owner, err := db.CreateUser(db.Connection, "owner", "", testPassword)
+	assert.NoError(t, err)
+	f := createTestFile(t, owner)
+	assert.NoError(t, db.ForkFile(owner.Username, testAlias, f.Hash, p.userID()))
+
+	// Upstream commits are only new when their timestamp is after the fork's.
+	time.Sleep(time.Second)
+	createTestTempFile(t, owner.ID, "new content!")
+	assert.NoError(t, db.InitOrCommit(owner.ID, testAlias, "upstream change"))
+
+	p.Vars["username"] = testUsername
+	p.Vars["alias"] = testAlias
+
+	t.Run("forks", func(t *testing.T) {
+		forksPage := &Page{Vars: map[string]string{"username": owner.Username, "alias": testAlias}, Data: map[string]interface{}{}}
+		assert.False(t, loadForks(w, r, forksPage))
+		assert.Len(t, forksPage.Data["forks"], 1)
+	})
+
+	t.Run("upstream diff", func(t *testing.T) {
+		assert.False(t, loadUpstream(w, r, p))
+		assert.Empty(t, p.ErrorMessage)
+		assert.Equal(t, 1, p.Data["upstream"].(*db.UpstreamFile).Ahead)
+		assert.Contains(t, p.Data["diff"], "<ins><mark>new </mark>content!</ins>")
+		assert.Equal(t, "1 file changed, 1 insertion(+), 1 deletion(-)", p.Data["diffStat"])
+	})
+
+	t.Run("403 when syncing another user's fork", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ownerPage := &Page{
+			Session: &db.UserSession{UserID: owner.ID, Username: owner.Username},
+			Vars:    p.Vars,
+		}
+		assert.True(t, syncFork(w, r, ownerPage))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		assert.False(t, syncFork(w, r, p))
+		assert.Empty(t, p.ErrorMessage)
+
+		assert.False(t, loadUpstream(w, r, p))
+		assert.Zero(t, p.Data["upstream"].(*db.UpstreamFile).Ahead)
+
+		file, err := db.UncompressFile(db.Connection, testUsername, testAlias)
+		assert.NoError(t, err)
+		assert.Equal(t, "new content!", string(file.Content))
+	})
+}
@@ -49,7 +49,6 @@ func setupTestPage(t *testing.T) (http.ResponseWriter, *http.Request, *Page) {
 	p.Session = &db.UserSession{
 		UserID:   u.ID,
 		Username: u.Username,
-		CLIToken: u.CLIToken,
 	}
 	p.Vars = make(map[string]string)
 	p.Data = make(map[string]interface{})
@@ -1,10 +1,23 @@
 package server
 
 import (
-	"github.com/knoebber/dotfile/db"
+	"html"
+	"html/template"
 	"net/http"
+	"strings"
+
+	"github.com/knoebber/dotfile/db"
 )
 
+// Escapes a search snippet and highlights its matches.
+func snippetHTML(snippet string) template.HTML {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, db.SnippetMatchStart, "<mark>")
+	escaped = strings.ReplaceAll(escaped, db.SnippetMatchEnd, "</mark>")
+
+	return template.HTML(escaped)
+}
+
 // Loads the contents of a file by its alias.
 func searchFiles(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	var err error
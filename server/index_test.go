@@ -1,8 +1,12 @@
 package server
 
 import (
+	"html/template"
 	"net/http"
 	"testing"
+
+	"github.com/knoebber/dotfile/db"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestIndexHandler(t *testing.T) {
@@ -11,3 +15,8 @@ func TestIndexHandler(t *testing.T) {
 		assertOK(t, router, testFilePath, http.MethodGet)
 	})
 }
+
+func TestSnippetHTML(t *testing.T) {
+	snippet := "<b>" + db.SnippetMatchStart + "vim" + db.SnippetMatchEnd + "</b>"
+	assert.Equal(t, template.HTML("&lt;b&gt;<mark>vim</mark>&lt;/b&gt;"), snippetHTML(snippet))
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+)
+
+// A range of selected lines from the lines query, for example ?lines=10-24.
+// Start is zero when no lines are selected.
+type lineRange struct {
+	start, end int
+}
+
+// Parses "10" or "10-24". Invalid ranges select nothing.
+func parseLineRange(s string) (l lineRange) {
+	start, end, isRange := strings.Cut(s, "-")
+
+	l.start, _ = strconv.Atoi(start)
+	l.end = l.start
+	if isRange {
+		l.end, _ = strconv.Atoi(end)
+	}
+
+	if l.start < 1 || l.end < l.start {
+		return lineRange{}
+	}
+	return
+}
+
+func (l lineRange) selected(n int) bool {
+	return l.start <= n && n <= l.end
+}
+
+func (l lineRange) anchor() string {
+	if l.start == l.end {
+		return fmt.Sprintf("L%d", l.start)
+	}
+	return fmt.Sprintf("L%d-L%d", l.start, l.end)
+}
+
+// Returns the query and fragment that link to the range.
+func (l lineRange) suffix() string {
+	if l.start == 0 {
+		return ""
+	}
+	if l.start == l.end {
+		return fmt.Sprintf("?lines=%d#%s", l.start, l.anchor())
+	}
+	return fmt.Sprintf("?lines=%d-%d#%s", l.start, l.end, l.anchor())
+}
+
+// Returns the range that clicking line n selects.
+// Lines after a single selected line extend it into a range, so ranges can be selected without JavaScript.
+func (l lineRange) next(n int) lineRange {
+	if l.start != 0 && l.start == l.end && n > l.start {
+		return lineRange{l.start, n}
+	}
+	return lineRange{n, n}
+}
+
+// Adds line numbers to highlighted content.
+// Each line has an anchor like #L10; a range selected by the lines query has an anchor like #L10-L24 before its first line.
+// Ranges can't be selected by the fragment alone because it isn't sent to the server.
+func numberLines(content template.HTML, selected lineRange) template.HTML {
+	var b strings.Builder
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	for i, line := range lines {
+		n := i + 1
+
+		class := "line"
+		if selected.selected(n) {
+			class += " selected"
+		}
+		if n == selected.start && selected.start != selected.end {
+			_, _ = fmt.Fprintf(&b, `<a id="%s"></a>`, selected.anchor())
+		}
+
+		_, _ = fmt.Fprintf(&b, `<span class="%s" id="L%d"><a class="line-number" href="%s">%d</a>%s</span>`,
+			class, n, selected.next(n).suffix(), n, line)
+		_ = b.WriteByte('\n')
+	}
+
+	return template.HTML(b.String())
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLineRange(t *testing.T) {
+	assert.Equal(t, lineRange{10, 10}, parseLineRange("10"))
+	assert.Equal(t, lineRange{10, 24}, parseLineRange("10-24"))
+	assert.Equal(t, lineRange{}, parseLineRange(""))
+	assert.Equal(t, lineRange{}, parseLineRange("24-10"))
+	assert.Equal(t, lineRange{}, parseLineRange("0"))
+	assert.Equal(t, lineRange{}, parseLineRange("L10"))
+}
+
+func TestLineRange_suffix(t *testing.T) {
+	assert.Empty(t, lineRange{}.suffix())
+	assert.Equal(t, "?lines=10#L10", lineRange{10, 10}.suffix())
+	assert.Equal(t, "?lines=10-24#L10-L24", lineRange{10, 24}.suffix())
+}
+
+func TestLineRange_next(t *testing.T) {
+	assert.Equal(t, lineRange{3, 3}, lineRange{}.next(3))
+	assert.Equal(t, lineRange{3, 5}, lineRange{3, 3}.next(5))
+	assert.Equal(t, lineRange{2, 2}, lineRange{3, 3}.next(2))
+	assert.Equal(t, lineRange{6, 6}, lineRange{3, 5}.next(6))
+}
+
+func TestNumberLines(t *testing.T) {
+	assert.Equal(t,
+		template.HTML(`<a id="L1-L2"></a>`+
+			`<span class="line selected" id="L1"><a class="line-number" href="?lines=1#L1">1</a>a</span>`+"\n"+
+			`<span class="line selected" id="L2"><a class="line-number" href="?lines=2#L2">2</a></span>`+"\n"+
+			`<span class="line" id="L3"><a class="line-number" href="?lines=3#L3">3</a>c</span>`+"\n"),
+		numberLines("a\n\nc", lineRange{1, 2}))
+}
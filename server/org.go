@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/knoebber/dotfile/db"
+)
+
+func handleOrgsForm(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	name := r.Form.Get("name")
+
+	if err := db.CreateOrg(name, p.userID()); err != nil {
+		return p.setError(w, err)
+	}
+
+	http.Redirect(w, r, "/settings/orgs/"+name, http.StatusSeeOther)
+	return true
+}
+
+func loadOrgs(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	orgs, err := db.OrgsByUserID(db.Connection, p.userID())
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["orgs"] = orgs
+	return
+}
+
+// Handles the member forms of an organization.
+// Only owners can change members; the db functions enforce it.
+func handleOrgMembersForm(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	org := p.Vars["org"]
+
+	if remove := r.Form.Get("remove"); remove != "" {
+		if err := db.RemoveOrgMember(db.Connection, org, p.userID(), remove); err != nil {
+			return p.setError(w, err)
+		}
+
+		p.flashSuccess("Removed " + remove)
+		return
+	}
+
+	username := r.Form.Get("username")
+	role := db.OrgRole(r.Form.Get("role"))
+	if err := db.SetOrgMember(db.Connection, org, p.userID(), username, role); err != nil {
+		return p.setError(w, err)
+	}
+
+	p.flashSuccess("Set " + username + " to " + string(role))
+	return
+}
+
+// Loads the members of an organization.
+// The page is not found for users that aren't members.
+func loadOrgMembers(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	org := p.Vars["org"]
+
+	role, err := db.OrgRoleFor(db.Connection, org, p.userID())
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	members, err := db.OrgMembers(db.Connection, org, p.Timezone())
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["org"] = org
+	p.Data["isOwner"] = role == db.OrgRoleOwner
+	p.Data["members"] = members
+	p.Data["roles"] = db.OrgRoles
+	return
+}
+
+func orgsHandler() http.HandlerFunc {
+	return createHandler(&pageDescription{
+		templateName: "orgs.tmpl",
+		title:        "Organizations",
+		loadData:     loadOrgs,
+		handleForm:   handleOrgsForm,
+		protected:    true,
+	})
+}
+
+func orgMembersHandler() http.HandlerFunc {
+	return createHandler(&pageDescription{
+		templateName: "org_members.tmpl",
+		title:        "Members",
+		loadData:     loadOrgMembers,
+		handleForm:   handleOrgMembersForm,
+		protected:    true,
+	})
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/knoebber/dotfile/db"
+	"github.com/stretchr/testify/assert"
+)
+
+const testOrgName = "acme"
+
+func TestOrgFileAPI(t *testing.T) {
+	setupTestDB(t)
+	router := mux.NewRouter()
+	apiRoutes(router)
+
+	owner := createTestUser(t)
+	member, err := db.CreateUser(db.Connection, "member", "", testPassword)
+	assert.NoError(t, err)
+	assert.NoError(t, db.CreateOrg(testOrgName, owner.ID))
+	assert.NoError(t, db.SetOrgMember(db.Connection, testOrgName, owner.ID, member.Username, db.OrgRoleReader))
+
+	f := createTestFile(t, owner)
+	assert.NoError(t, f.SetVisibility(db.Connection, db.FileVisibilityPrivate))
+	_, err = db.Connection.Exec("UPDATE files SET user_id = (SELECT id FROM users WHERE username = ?)", testOrgName)
+	assert.NoError(t, err)
+	filePath := "/api/v1/user/" + testOrgName + "/" + f.Alias
+
+	send := func(method, path, body string, u *db.UserRecord) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if u != nil {
+			r.SetBasicAuth(u.Username, u.CLIToken)
+		}
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("members can read private files", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, filePath, "", nil).Code)
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, filePath, "", member).Code)
+		assert.Contains(t, send(http.MethodGet, "/api/v1/user/"+testOrgName, "", member).Body.String(), f.Alias)
+	})
+
+	t.Run("readers can't update files", func(t *testing.T) {
+		w := send(http.MethodPatch, filePath, `{"path": "~/.new_path"}`, member)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("maintainers can update files", func(t *testing.T) {
+		assert.NoError(t, db.SetOrgMember(db.Connection, testOrgName, owner.ID, member.Username, db.OrgRoleMaintainer))
+
+		w := send(http.MethodPatch, filePath, `{"path": "~/.new_path"}`, member)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "~/.new_path")
+	})
+}
+
+func TestHandleOrgMembersForm(t *testing.T) {
+	setupTestDB(t)
+	w, r, p := setupTestPage(t)
+	_, err := db.CreateUser(db.Connection, "member", "", testPassword)
+	assert.NoError(t, err)
+
+	r.Form.Set("name", testOrgName)
+	assert.True(t, handleOrgsForm(httptest.NewRecorder(), r, p))
+	p.Vars["org"] = testOrgName
+
+	t.Run("add member", func(t *testing.T) {
+		r.Form = url.Values{"username": []string{"member"}, "role": []string{"reader"}}
+		handleOrgMembersForm(w, r, p)
+		assert.Empty(t, p.ErrorMessage)
+
+		loadOrgMembers(w, r, p)
+		assert.Equal(t, true, p.Data["isOwner"])
+		assert.Len(t, p.Data["members"], 2)
+	})
+
+	t.Run("error when removing last owner", func(t *testing.T) {
+		r.Form = url.Values{"remove": []string{testUsername}}
+		handleOrgMembersForm(w, r, p)
+		assert.NotEmpty(t, p.ErrorMessage)
+	})
+
+	t.Run("remove member", func(t *testing.T) {
+		p.ErrorMessage = ""
+		r.Form = url.Values{"remove": []string{"member"}}
+		handleOrgMembersForm(w, r, p)
+		assert.Empty(t, p.ErrorMessage)
+	})
+}
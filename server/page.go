@@ -97,15 +97,6 @@ func (p *Page) Theme() db.UserTheme {
 	return ""
 }
 
-// CLIToken returns the logged in user's CLI token.
-func (p *Page) CLIToken() string {
-	if p.Session != nil {
-		return p.Session.CLIToken
-	}
-
-	return ""
-}
-
 // UserCreatedAt returns the logged in user's creation date.
 func (p *Page) UserCreatedAt() string {
 	if p.Session != nil {
@@ -302,6 +293,7 @@ func loadTemplates() (err error) {
 	pageFunctions := template.FuncMap{
 		// Global functions that page templates can call.
 		"shortenHash": dotfile.ShortenHash,
+		"snippet":     snippetHTML,
 	}
 
 	pageTemplates, err = template.
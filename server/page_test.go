@@ -18,7 +18,6 @@ func TestSessionGetters(t *testing.T) {
 	assert.Empty(t, p.Username())
 	assert.Empty(t, p.Email())
 	assert.Empty(t, p.Theme())
-	assert.Empty(t, p.CLIToken())
 	assert.Empty(t, p.UserCreatedAt())
 	assert.Empty(t, p.session())
 	assert.Empty(t, p.Timezone())
@@ -27,7 +26,6 @@ func TestSessionGetters(t *testing.T) {
 	assert.NotEmpty(t, p.Username())
 	assert.NotEmpty(t, p.Email())
 	assert.NotEmpty(t, p.Theme())
-	assert.NotEmpty(t, p.CLIToken())
 	assert.NotEmpty(t, p.UserCreatedAt())
 	assert.NotEmpty(t, p.session())
 	assert.NotEmpty(t, p.userID())
@@ -96,6 +94,9 @@ func testPage(t *testing.T) *Page {
 			Path            string
 			Alias           string
 			UpdatedAtString string
+			Stars           int
+			Forks           int
+			Snippet         string
 		}
 	)
 	testData := make(map[string]interface{})
@@ -110,7 +111,6 @@ func testPage(t *testing.T) *Page {
 		UserID:        1,
 		Username:      testUsername,
 		Email:         &testEmail,
-		CLIToken:      "1234token",
 		Timezone:      &testTZ,
 		Theme:         db.UserThemeDark,
 		UserCreatedAt: time.Now().Format(time.RFC3339),
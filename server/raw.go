@@ -11,11 +11,20 @@ import (
 func handleRawFile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
+	visibility, err := fileAccess(r)
+	if err != nil {
+		rawContentError(w, err)
+		return
+	}
+
 	file, err := db.UncompressFile(db.Connection, vars["username"], vars["alias"])
 	if err != nil {
 		rawContentError(w, err)
 		return
 	}
+	if notModified(w, r, file.Hash, fileCacheControl(visibility, revalidateCacheControl)) {
+		return
+	}
 
 	_, err = w.Write(file.Content)
 	if err != nil {
@@ -28,6 +37,11 @@ func handleRawFile(w http.ResponseWriter, r *http.Request) {
 func handleRawUncompressedCommit(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
+	if _, err := fileAccess(r); err != nil {
+		rawContentError(w, err)
+		return
+	}
+
 	commit, err := db.UncompressCommit(db.Connection, vars["username"], vars["alias"], vars["hash"], nil)
 	if err != nil {
 		rawContentError(w, err)
@@ -49,10 +49,17 @@ func assetRoutes(r *mux.Router) error {
 }
 
 func apiRoutes(r *mux.Router) {
+	r.HandleFunc("/api/v1/search", handleSearchJSON)
 	r.HandleFunc("/api/v1/user/{username}", handleFileListJSON)
 	r.HandleFunc("/api/v1/user/{username}/{alias}", handleFileJSON).Methods("GET")
 	r.HandleFunc("/api/v1/user/{username}/{alias}", handlePush).Methods("POST")
+	r.HandleFunc("/api/v1/user/{username}/{alias}", handleUpdateFile).Methods("PATCH")
+	r.HandleFunc("/api/v1/user/{username}/{alias}", handleDeleteFile).Methods("DELETE")
+	r.HandleFunc("/api/v1/user/{username}/{alias}/commits", handleClearCommits).Methods("DELETE")
+	r.HandleFunc("/api/v1/user/{username}/{alias}/revision", handleSetRevision).Methods("PUT")
+	r.HandleFunc("/api/v1/user/{username}/{alias}/shares", handleCreateShare).Methods("POST")
 	r.HandleFunc("/api/v1/user/{username}/{alias}/raw", handleRawFile)
+	r.HandleFunc("/api/v1/user/{username}/{alias}/revisions", handleRevisions).Methods("POST")
 	r.HandleFunc("/api/v1/user/{username}/{alias}/{hash}", handleRawCompressedCommit)
 }
 
@@ -71,17 +78,33 @@ func dotfileRoutes(r *mux.Router, config Config) {
 	r.HandleFunc("/settings/password", passwordHandler())
 	r.HandleFunc("/settings/theme", themeHandler())
 	r.HandleFunc("/settings/cli", cliHandler(config))
+	r.HandleFunc("/settings/tokens", apiTokensHandler())
+	r.HandleFunc("/settings/orgs", orgsHandler())
+	r.HandleFunc("/settings/orgs/{org}", orgMembersHandler())
 	r.HandleFunc("/settings/delete", deleteUserHandler())
+	r.HandleFunc("/following", followingHandler(config))
+	r.HandleFunc("/share/{token}", sharedFileHandler())
+	r.HandleFunc("/share/{token}/raw", handleSharedRawFile)
+	r.HandleFunc("/share/{token}/{hash}", sharedFileHandler())
+	r.HandleFunc("/share/{token}/{hash}/raw", handleSharedRawFile)
 	r.HandleFunc("/{username}", userHandler())
+	r.HandleFunc("/{username}/following.{format:rss|atom|json}", followingFeedHandler(config))
+	r.HandleFunc("/{username}/feed.{format:rss|atom|json}", userFeedHandler(config))
 	r.HandleFunc("/{username}/{alias}", fileHandler())
 	r.HandleFunc("/{username}/{alias}/raw", handleRawFile)
 	r.HandleFunc("/{username}/{alias}/commits", commitsHandler())
+	r.HandleFunc("/{username}/{alias}/commits.{format:rss|atom|json}", fileFeedHandler(config))
 	r.HandleFunc("/{username}/{alias}/edit", editFileHandler())
 	r.HandleFunc("/{username}/{alias}/diff", diffHandler())
 	r.HandleFunc("/{username}/{alias}/init", confirmNewFileHandler())
 	r.HandleFunc("/{username}/{alias}/commit", confirmEditHandler())
+	r.HandleFunc("/{username}/{alias}/star", starHandler())
+	r.HandleFunc("/{username}/{alias}/forks", forksHandler())
+	r.HandleFunc("/{username}/{alias}/upstream", upstreamHandler())
 	r.HandleFunc("/{username}/{alias}/settings", fileSettingsHandler())
 	r.HandleFunc("/{username}/{alias}/settings/update", updateFileHandler())
+	r.HandleFunc("/{username}/{alias}/settings/visibility", fileVisibilityHandler())
+	r.HandleFunc("/{username}/{alias}/settings/shares", fileSharesHandler(config))
 	r.HandleFunc("/{username}/{alias}/settings/delete", deleteFileHandler())
 	r.HandleFunc("/{username}/{alias}/settings/clear", clearFileHandler())
 	r.HandleFunc("/{username}/{alias}/{hash}", commitHandler())
@@ -90,6 +113,7 @@ func dotfileRoutes(r *mux.Router, config Config) {
 
 // Prevent users for registering any username that conflicts with an existing route.
 // For example a user named "login" wouldn't be able to see their files.
+// Organizations are in the same namespace as users so the names apply to them too.
 func createReservedUsernames(r *mux.Router) error {
 	var reserved []interface{}
 	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
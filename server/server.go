@@ -15,6 +15,9 @@ import (
 
 const timeout = 10 * time.Second
 
+// DefaultResetExpiry is how long password reset links are valid when Config.ResetExpiry is not set.
+const DefaultResetExpiry = time.Hour
+
 //go:embed assets
 //go:embed html
 //go:embed templates/base.tmpl templates/auth/* templates/file/* templates/user/*
@@ -22,13 +25,14 @@ var serverContent embed.FS
 
 // Config configures the server.
 type Config struct {
-	Addr           string      // Address to listen at.
-	DBPath         string      // The path to store the sqlite database file.
-	Secure         bool        // Tell the server code that the host is using https.
-	ProxyHeaders   bool        // Sets request IP from reverse proxy headers.
-	Host           string      // Overrides http.Request.Host when not empty.
-	SMTP           *SMTPConfig // Sets up a SMTP Client
-	SMTPConfigPath string      // Sets SMTP from this file's JSON when not empty.
+	Addr           string        // Address to listen at.
+	DBPath         string        // The path to store the sqlite database file.
+	Secure         bool          // Tell the server code that the host is using https.
+	ProxyHeaders   bool          // Sets request IP from reverse proxy headers.
+	Host           string        // Overrides http.Request.Host when not empty.
+	SMTP           *SMTPConfig   // Sets up a SMTP Client
+	SMTPConfigPath string        // Sets SMTP from this file's JSON when not empty.
+	ResetExpiry    time.Duration // How long password reset links are valid. Defaults to DefaultResetExpiry.
 }
 
 // URL returns the configured url.
@@ -54,6 +58,10 @@ func New(config Config) (*http.Server, error) {
 		return nil, errors.Wrapf(err, "starting database")
 	}
 
+	if config.ResetExpiry <= 0 {
+		config.ResetExpiry = DefaultResetExpiry
+	}
+
 	if config.SMTPConfigPath != "" {
 		config.SMTP, err = smtpConfig(config.SMTPConfigPath)
 		if err != nil {
@@ -74,9 +82,9 @@ func New(config Config) (*http.Server, error) {
 	}
 
 	if config.ProxyHeaders {
-		s.Handler = handlers.LoggingHandler(os.Stdout, handlers.ProxyHeaders(r))
+		s.Handler = handlers.CombinedLoggingHandler(os.Stdout, handlers.ProxyHeaders(r))
 	} else {
-		s.Handler = handlers.LoggingHandler(os.Stdout, r)
+		s.Handler = handlers.CombinedLoggingHandler(os.Stdout, r)
 	}
 
 	if err := loadTemplates(); err != nil {
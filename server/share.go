@@ -0,0 +1,121 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/knoebber/dotfile/db"
+	"github.com/knoebber/dotfile/dotfile"
+	"github.com/pkg/errors"
+)
+
+// Share links are secrets in the URL.
+// Keeps browsers from sending them to other sites and search engines from indexing them.
+func setShareHeaders(w http.ResponseWriter) {
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex")
+}
+
+// Opens the share link in the route.
+// Commits are reported as not found when the link doesn't include history.
+func openShare(r *http.Request) (*db.ShareView, error) {
+	vars := mux.Vars(r)
+
+	share, err := db.OpenShare(db.Connection, vars["token"])
+	if err != nil {
+		return nil, err
+	}
+	if vars["hash"] != "" && !share.History {
+		return nil, errors.Wrapf(sql.ErrNoRows, "share %d does not include history", share.ShareID)
+	}
+
+	return share, nil
+}
+
+func loadSharedFile(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	setShareHeaders(w)
+
+	share, err := openShare(r)
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["username"] = share.Username
+	p.Data["alias"] = share.Alias
+	p.Data["shareLink"] = "/share/" + p.Vars["token"]
+	p.Title = share.Alias
+
+	if hash := p.Vars["hash"]; hash != "" {
+		commit, err := db.UncompressCommit(db.Connection, share.Username, share.Alias, hash, p.Timezone())
+		if err != nil {
+			return p.setError(w, err)
+		}
+
+		p.Data["hash"] = hash
+		setFileContent(r, p, commit.Path, commit.Content)
+		p.Data["message"] = commit.Message
+		p.Data["dateString"] = commit.DateString
+		p.Title += " at " + dotfile.ShortenHash(hash)
+		return
+	}
+
+	file, err := db.UncompressFile(db.Connection, share.Username, share.Alias)
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	setFileContent(r, p, file.Path, file.Content)
+
+	if share.History {
+		commits, err := db.CommitList(db.Connection, share.Username, share.Alias, p.Timezone())
+		if err != nil {
+			return p.setError(w, err)
+		}
+
+		p.Data["commits"] = commits
+	}
+
+	return
+}
+
+// Sets the content of a shared file or one of its commits to the response writer.
+func handleSharedRawFile(w http.ResponseWriter, r *http.Request) {
+	var content []byte
+
+	setShareHeaders(w)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	share, err := openShare(r)
+	if err != nil {
+		rawContentError(w, err)
+		return
+	}
+
+	if hash := mux.Vars(r)["hash"]; hash != "" {
+		commit, err := db.UncompressCommit(db.Connection, share.Username, share.Alias, hash, nil)
+		if err != nil {
+			rawContentError(w, err)
+			return
+		}
+		content = commit.Content
+	} else {
+		file, err := db.UncompressFile(db.Connection, share.Username, share.Alias)
+		if err != nil {
+			rawContentError(w, err)
+			return
+		}
+		content = file.Content
+	}
+
+	if _, err := w.Write(content); err != nil {
+		rawContentError(w, err)
+	}
+}
+
+func sharedFileHandler() http.HandlerFunc {
+	return createHandler(&pageDescription{
+		templateName: "share.tmpl",
+		loadData:     loadSharedFile,
+	})
+}
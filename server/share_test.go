@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/knoebber/dotfile/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShareLinks(t *testing.T) {
+	setupTestDB(t)
+	if err := loadTemplates(); err != nil {
+		t.Fatalf("loading templates: %v", err)
+	}
+
+	router := mux.NewRouter()
+	apiRoutes(router)
+	router.HandleFunc("/share/{token}", sharedFileHandler())
+	router.HandleFunc("/share/{token}/raw", handleSharedRawFile)
+	router.HandleFunc("/share/{token}/{hash}", sharedFileHandler())
+	router.HandleFunc("/share/{token}/{hash}/raw", handleSharedRawFile)
+
+	u := createTestUser(t)
+	f := createTestFile(t, u)
+	assert.NoError(t, f.SetVisibility(db.Connection, db.FileVisibilityPrivate))
+
+	createShare := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/user/"+u.Username+"/"+f.Alias+"/shares", strings.NewReader(body))
+		r.SetBasicAuth(u.Username, u.CLIToken)
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("400 on negative expiration", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, createShare(`{"expires_in": -1}`).Code)
+	})
+
+	t.Run("without history", func(t *testing.T) {
+		var share ShareResponse
+
+		w := createShare(`{"expires_in": 3600}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&share))
+		assert.NotNil(t, share.ExpiresAt)
+
+		w = sendTestRequest(router, share.Path, http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "content!")
+		assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+
+		w = sendTestRequest(router, share.Path+"/raw", http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "content!", w.Body.String())
+
+		assertNotFound(t, router, share.Path+"/"+f.Hash, http.MethodGet)
+		assertNotFound(t, router, share.Path+"/"+f.Hash+"/raw", http.MethodGet)
+	})
+
+	t.Run("with history", func(t *testing.T) {
+		var share ShareResponse
+
+		w := createShare(`{"history": true}`)
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&share))
+		assert.Nil(t, share.ExpiresAt)
+
+		w = sendTestRequest(router, share.Path, http.MethodGet)
+		assert.Contains(t, w.Body.String(), share.Path+"/"+f.Hash)
+
+		assertOK(t, router, share.Path+"/"+f.Hash, http.MethodGet)
+		assertOK(t, router, share.Path+"/"+f.Hash+"/raw", http.MethodGet)
+	})
+
+	t.Run("404 on unknown token", func(t *testing.T) {
+		assertNotFound(t, router, "/share/unknown", http.MethodGet)
+		assertNotFound(t, router, "/share/unknown/raw", http.MethodGet)
+	})
+}
+
+func TestHandleFileSharesForm(t *testing.T) {
+	setupTestDB(t)
+	defer clearTestUser(t)
+
+	w, r, p := setupTestPage(t)
+	createTestTempFile(t, p.Session.UserID, "content!")
+	if err := db.InitOrCommit(p.Session.UserID, testAlias, ""); err != nil {
+		t.Fatalf("creating test file: %s", err)
+	}
+	p.Vars["username"] = testUsername
+	p.Vars["alias"] = testAlias
+
+	t.Run("error on invalid expiration", func(t *testing.T) {
+		r.Form.Set("expires", "0")
+		handleFileSharesForm(w, r, p)
+		assert.NotEmpty(t, p.ErrorMessage)
+	})
+
+	t.Run("create and revoke", func(t *testing.T) {
+		p.ErrorMessage = ""
+		r.Form = url.Values{"expires": []string{"7"}, "history": []string{"true"}}
+		handleFileSharesForm(w, r, p)
+		assert.Empty(t, p.ErrorMessage)
+		assert.NotEmpty(t, p.Data["token"])
+
+		loadFileShares(Config{})(w, r, p)
+		shares := p.Data["shares"].([]db.ShareSummary)
+		if !assert.Len(t, shares, 1) {
+			return
+		}
+		assert.True(t, shares[0].History)
+
+		r.Form = url.Values{"revoke": []string{strconv.FormatInt(shares[0].ID, 10)}}
+		handleFileSharesForm(w, r, p)
+		assert.Empty(t, p.ErrorMessage)
+	})
+}
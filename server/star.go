@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/knoebber/dotfile/db"
+)
+
+// Stars or unstars the file in the route for the logged in user.
+func handleStar(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	var err error
+
+	username := p.Vars["username"]
+	alias := p.Vars["alias"]
+
+	if p.Session == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return true
+	}
+
+	if r.Form.Get("unstar") != "" {
+		err = db.Unstar(db.Connection, p.userID(), username, alias)
+	} else {
+		err = db.Star(db.Connection, p.userID(), username, alias)
+	}
+	if db.NotFound(err) {
+		return p.setError(w, err)
+	} else if err != nil {
+		setError(w, err, "Failed to star file", http.StatusInternalServerError)
+		return true
+	}
+
+	return redirectToFile(w, r, p)
+}
+
+// Star forms post to their own route; everything else goes back to the file.
+func redirectToFile(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	http.Redirect(w, r, fmt.Sprintf("/%s/%s", p.Vars["username"], p.Vars["alias"]), http.StatusSeeOther)
+	return true
+}
+
+func starHandler() http.HandlerFunc {
+	return createHandler(&pageDescription{
+		templateName: "file.tmpl",
+		handleForm:   handleStar,
+		loadData:     redirectToFile,
+	})
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/knoebber/dotfile/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleStar(t *testing.T) {
+	setupTestDB(t)
+	_, r, p := setupTestPage(t)
+
+	owner, err := db.CreateUser(db.Connection, "owner", "", testPassword)
+	assert.NoError(t, err)
+	createTestFile(t, owner)
+	p.Vars["username"] = owner.Username
+	p.Vars["alias"] = testAlias
+
+	t.Run("redirects to login without a session", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		assert.True(t, handleStar(w, r, &Page{Vars: p.Vars}))
+		assert.Equal(t, "/login", w.Header().Get("Location"))
+	})
+
+	t.Run("404 when file doesn't exist", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		assert.True(t, handleStar(w, r, &Page{
+			Session: p.Session,
+			Vars:    map[string]string{"username": owner.Username, "alias": "missing"},
+		}))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("star", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		assert.True(t, handleStar(w, r, p))
+		assert.Equal(t, "/owner/"+testAlias, w.Header().Get("Location"))
+
+		assert.NoError(t, pageFileAccess(p))
+		assert.Equal(t, 1, p.Data["stars"])
+		assert.Equal(t, true, p.Data["starred"])
+	})
+
+	t.Run("unstar", func(t *testing.T) {
+		r.Form = url.Values{"unstar": []string{"true"}}
+		assert.True(t, handleStar(httptest.NewRecorder(), r, p))
+
+		assert.NoError(t, pageFileAccess(p))
+		assert.Equal(t, 0, p.Data["stars"])
+		assert.Equal(t, false, p.Data["starred"])
+	})
+}
+
+func TestStarredListJSON(t *testing.T) {
+	setupTestDB(t)
+	router := mux.NewRouter()
+	apiRoutes(router)
+
+	u := createTestUser(t)
+	owner, err := db.CreateUser(db.Connection, "owner", "", testPassword)
+	assert.NoError(t, err)
+	f := createTestFile(t, owner)
+	assert.NoError(t, db.Star(db.Connection, u.ID, owner.Username, f.Alias))
+
+	t.Run("404 when user doesn't exist", func(t *testing.T) {
+		assertNotFound(t, router, "/api/v1/user/missing?starred=true", http.MethodGet)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		w := sendTestRequest(router, "/api/v1/user/"+u.Username+"?starred=true&path=true", http.MethodGet)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `["owner/`+f.Alias+` `+f.Path+`"]`, w.Body.String())
+	})
+}
@@ -2,6 +2,8 @@ package server
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/knoebber/dotfile/db"
 	"github.com/knoebber/usererror"
@@ -16,15 +18,67 @@ func handleEmail(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	return
 }
 
+// Generates a new CLI token.
+// Only a hash of the token is saved so it is shown once.
+// The password is required so that other sites can't post the form to end CLI access.
 func handleTokenForm(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
-	if err := db.RotateCLIToken(db.Connection, p.Session.UserID, r.Form.Get("token")); err != nil {
+	if err := db.CheckPassword(db.Connection, p.Username(), r.Form.Get("password")); err != nil {
 		return p.setError(w, err)
 	}
 
+	token, err := db.RotateCLIToken(db.Connection, p.userID())
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["token"] = token
 	p.flashSuccess("Generated new token")
 	return
 }
 
+// Creates a new API token or revokes an existing one.
+// A new token's value is shown once.
+func handleAPITokenForm(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	var expiresAt *time.Time
+
+	if revoke := r.Form.Get("revoke"); revoke != "" {
+		id, err := strconv.ParseInt(revoke, 10, 64)
+		if err != nil {
+			return p.setError(w, usererror.New("Invalid token."))
+		}
+		if err := db.RevokeToken(db.Connection, p.userID(), id); err != nil {
+			return p.setError(w, err)
+		}
+
+		p.flashSuccess("Revoked token")
+		return
+	}
+
+	scopes := make([]db.TokenScope, len(r.Form["scope"]))
+	for i, s := range r.Form["scope"] {
+		scopes[i] = db.TokenScope(s)
+	}
+
+	if days := r.Form.Get("expires"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return p.setError(w, usererror.New("Expiration must be a positive number of days."))
+		}
+
+		t := time.Now().AddDate(0, 0, n)
+		expiresAt = &t
+	}
+
+	token, err := db.CreateToken(db.Connection, p.userID(), r.Form.Get("name"), scopes, expiresAt)
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["token"] = token
+	p.flashSuccess("Created token")
+	return
+}
+
 func handlePassword(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	currentPass := r.Form.Get("current")
 
@@ -83,6 +137,17 @@ func loadUserCLI(config Config) pageBuilder {
 	}
 }
 
+func loadAPITokens(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
+	tokens, err := db.Tokens(db.Connection, p.userID(), p.Timezone())
+	if err != nil {
+		return p.setError(w, err)
+	}
+
+	p.Data["tokens"] = tokens
+	p.Data["scopes"] = db.TokenScopes
+	return
+}
+
 func loadThemes(w http.ResponseWriter, r *http.Request, p *Page) (done bool) {
 	p.Data["themes"] = []db.UserTheme{
 		db.UserThemeLight,
@@ -100,7 +165,36 @@ func loadUserFiles(w http.ResponseWriter, r *http.Request, p *Page) (done bool)
 		return p.setError(w, err)
 	}
 
-	files, err := db.FilesByUsername(db.Connection, username, p.Timezone())
+	isOrg, err := db.IsOrg(db.Connection, username)
+	if err != nil {
+		return p.setError(w, err)
+	}
+	if isOrg {
+		_, err := db.OrgRoleFor(db.Connection, username, p.userID())
+		if err != nil && !db.NotFound(err) {
+			return p.setError(w, err)
+		}
+
+		p.Data["org"] = true
+		p.Data["orgMember"] = err == nil
+	}
+
+	if err := loadFollow(p); err != nil {
+		return p.setError(w, err)
+	}
+
+	if r.URL.Query().Get("starred") == "true" {
+		starred, err := db.StarredFiles(db.Connection, username, p.userID(), p.Timezone())
+		if err != nil {
+			return p.setError(w, err)
+		}
+
+		p.Data["starredView"] = true
+		p.Data["starredFiles"] = starred
+		return
+	}
+
+	files, err := db.FilesByUsername(db.Connection, username, p.userID(), p.Timezone())
 	if db.NotFound(err) {
 		return
 	} else if err != nil {
@@ -130,6 +224,7 @@ func userHandler() http.HandlerFunc {
 	return createHandler(&pageDescription{
 		templateName: "user.tmpl",
 		loadData:     loadUserFiles,
+		handleForm:   handleFollowForm,
 	})
 }
 
@@ -151,6 +246,16 @@ func cliHandler(config Config) http.HandlerFunc {
 	})
 }
 
+func apiTokensHandler() http.HandlerFunc {
+	return createHandler(&pageDescription{
+		templateName: "tokens.tmpl",
+		title:        "API Tokens",
+		loadData:     loadAPITokens,
+		handleForm:   handleAPITokenForm,
+		protected:    true,
+	})
+}
+
 func themeHandler() http.HandlerFunc {
 	return createHandler(&pageDescription{
 		templateName: "theme.tmpl",
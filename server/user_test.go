@@ -1,9 +1,13 @@
 package server
 
 import (
-	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/url"
+	"strconv"
 	"testing"
+
+	"github.com/knoebber/dotfile/db"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestUserHandler(t *testing.T) {
@@ -70,18 +74,59 @@ func TestHandleTheme(t *testing.T) {
 func TestHandleTokenForm(t *testing.T) {
 	setupTestDB(t)
 
-	t.Run("error on token mismatch", func(t *testing.T) {
+	t.Run("error when password does not match", func(t *testing.T) {
 		defer clearTestUser(t)
 		w, r, p := setupTestPage(t)
+		r.Form.Set("password", "wrong")
 		handleTokenForm(w, r, p)
 		assert.NotEmpty(t, p.ErrorMessage)
+		assert.Nil(t, p.Data["token"])
 	})
 
 	t.Run("ok", func(t *testing.T) {
+		defer clearTestUser(t)
 		w, r, p := setupTestPage(t)
-		r.Form.Set("token", p.CLIToken())
+		r.Form.Set("password", testPassword)
 		handleTokenForm(w, r, p)
 		assert.Empty(t, p.ErrorMessage)
+
+		token, ok := p.Data["token"].(string)
+		if assert.True(t, ok) {
+			_, err := db.UserLoginAPI(db.Connection, p.Username(), token, db.TokenScopePush, "")
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestHandleAPITokenForm(t *testing.T) {
+	setupTestDB(t)
+
+	t.Run("error without scopes", func(t *testing.T) {
+		defer clearTestUser(t)
+		w, r, p := setupTestPage(t)
+		r.Form.Set("name", "laptop")
+		handleAPITokenForm(w, r, p)
+		assert.NotEmpty(t, p.ErrorMessage)
 	})
 
+	t.Run("create and revoke", func(t *testing.T) {
+		defer clearTestUser(t)
+		w, r, p := setupTestPage(t)
+		r.Form.Set("name", "laptop")
+		r.Form["scope"] = []string{string(db.TokenScopePush)}
+		r.Form.Set("expires", "30")
+		handleAPITokenForm(w, r, p)
+		assert.Empty(t, p.ErrorMessage)
+		assert.NotEmpty(t, p.Data["token"])
+
+		loadAPITokens(w, r, p)
+		tokens := p.Data["tokens"].([]db.TokenSummary)
+		if !assert.Len(t, tokens, 1) {
+			return
+		}
+
+		r.Form = url.Values{"revoke": []string{strconv.FormatInt(tokens[0].ID, 10)}}
+		handleAPITokenForm(w, r, p)
+		assert.Empty(t, p.ErrorMessage)
+	})
 }